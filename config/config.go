@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/viktorxhzj/mykv/datastructure"
+)
+
+// Parameters of the server, which can be changed at runtime with Set.
+// A change applies to the objects created afterward.
+var (
+	// ListMaxZiplistSize is the fill of the quicklist of a list:
+	// a positive value limits the number of elements of a node,
+	// while -1 to -5 limit the size of a node to 4, 8, 16, 32 or 64 KB.
+	ListMaxZiplistSize = -2
+
+	// ListCompressDepth is the number of quicklist nodes at each end of a list
+	// that are left uncompressed, 0 disables compression.
+	ListCompressDepth = 0
+
+	// HashMaxZiplistEntries is the maximum number of fields
+	// of a hash encoded as a ziplist.
+	HashMaxZiplistEntries = 128
+
+	// HashMaxZiplistValue is the maximum length of the fields and values
+	// of a hash encoded as a ziplist.
+	HashMaxZiplistValue = 64
+
+	// SetMaxIntsetEntries is the maximum number of members
+	// of a set encoded as an intset.
+	SetMaxIntsetEntries = 512
+
+	// ZSetMaxZiplistEntries is the maximum number of members
+	// of a sorted set encoded as a ziplist.
+	ZSetMaxZiplistEntries = 128
+
+	// ZSetMaxZiplistValue is the maximum length of the members
+	// of a sorted set encoded as a ziplist.
+	ZSetMaxZiplistValue = 64
+
+	// StreamNodeMaxBytes is the maximum size of a listpack node of a stream,
+	// 0 for unlimited.
+	StreamNodeMaxBytes = 4096
+
+	// StreamNodeMaxEntries is the maximum number of entries
+	// of a listpack node of a stream, 0 for unlimited.
+	StreamNodeMaxEntries = 100
+
+	// HllSparseMaxBytes is the maximum size of a HyperLogLog in the sparse encoding,
+	// beyond which it is converted to the dense one.
+	HllSparseMaxBytes = 3000
+)
+
+var (
+	ErrUnknownParameter = errors.New("ERR Unknown CONFIG parameter")
+)
+
+type parameter struct {
+	get func() string
+	set func(string) error
+}
+
+var parameters = map[string]parameter{
+	"list-max-ziplist-size": intParameter(&ListMaxZiplistSize, datastructure.ValidateFill),
+	"list-compress-depth":   intParameter(&ListCompressDepth, datastructure.ValidateCompressDepth),
+
+	// a ziplist holds a field and its value as two entries
+	"hash-max-ziplist-entries": intParameter(&HashMaxZiplistEntries, validateRange(0, datastructure.ZL_MAX_LEN/2)),
+	"hash-max-ziplist-value":   intParameter(&HashMaxZiplistValue, validateRange(0, math.MaxInt32)),
+	"set-max-intset-entries":   intParameter(&SetMaxIntsetEntries, validateRange(0, math.MaxInt32)),
+
+	// a ziplist holds a member and its score as two entries
+	"zset-max-ziplist-entries": intParameter(&ZSetMaxZiplistEntries, validateRange(0, datastructure.ZL_MAX_LEN/2)),
+	"zset-max-ziplist-value":   intParameter(&ZSetMaxZiplistValue, validateRange(0, math.MaxInt32)),
+
+	"stream-node-max-bytes":   intParameter(&StreamNodeMaxBytes, validateRange(0, math.MaxInt32)),
+	"stream-node-max-entries": intParameter(&StreamNodeMaxEntries, validateRange(0, math.MaxInt32)),
+
+	"hll-sparse-max-bytes": intParameter(&HllSparseMaxBytes, validateRange(0, math.MaxInt32)),
+}
+
+// Get returns the value of the parameter, as CONFIG GET does.
+func Get(name string) (string, error) {
+	p, ok := parameters[strings.ToLower(name)]
+	if !ok {
+		return "", ErrUnknownParameter
+	}
+	return p.get(), nil
+}
+
+// Set changes the value of the parameter, as CONFIG SET does.
+// The value is validated before it is applied.
+func Set(name, value string) error {
+	name = strings.ToLower(name)
+	p, ok := parameters[name]
+	if !ok {
+		return ErrUnknownParameter
+	}
+	if err := p.set(value); err != nil {
+		return fmt.Errorf("ERR Invalid argument '%s' for CONFIG SET '%s' - %v", value, name, err)
+	}
+	return nil
+}
+
+// intParameter binds an integer parameter to v,
+// where validate checks a new value before it is set.
+func intParameter(v *int, validate func(int) error) parameter {
+	return parameter{
+		get: func() string {
+			return strconv.Itoa(*v)
+		},
+		set: func(s string) error {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return errors.New("argument must be an integer")
+			}
+			if validate != nil {
+				if err := validate(n); err != nil {
+					return err
+				}
+			}
+			*v = n
+			return nil
+		},
+	}
+}
+
+// validateRange returns a validation that checks a value is in range [min, max].
+func validateRange(min, max int) func(int) error {
+	return func(n int) error {
+		if n < min || n > max {
+			return fmt.Errorf("argument must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import "testing"
+
+func TestConfig_SetAndGet(t *testing.T) {
+	defer func(fill, depth int) {
+		ListMaxZiplistSize, ListCompressDepth = fill, depth
+	}(ListMaxZiplistSize, ListCompressDepth)
+
+	if err := Set("LIST-MAX-ZIPLIST-SIZE", "-5"); err != nil || ListMaxZiplistSize != -5 {
+		t.Errorf("Set list-max-ziplist-size = %v, %d", err, ListMaxZiplistSize)
+	}
+	if v, err := Get("list-max-ziplist-size"); err != nil || v != "-5" {
+		t.Errorf("Get list-max-ziplist-size = %s, %v", v, err)
+	}
+	if err := Set("list-compress-depth", "3"); err != nil || ListCompressDepth != 3 {
+		t.Errorf("Set list-compress-depth = %v, %d", err, ListCompressDepth)
+	}
+
+	for _, bad := range [][2]string{
+		{"list-max-ziplist-size", "0"},
+		{"list-max-ziplist-size", "-6"},
+		{"list-max-ziplist-size", "abc"},
+		{"list-compress-depth", "-1"},
+		{"hash-max-ziplist-entries", "-1"},
+		{"hash-max-ziplist-entries", "40000"},
+		{"hash-max-ziplist-value", "-1"},
+		{"set-max-intset-entries", "-1"},
+		{"zset-max-ziplist-entries", "40000"},
+		{"zset-max-ziplist-value", "-1"},
+		{"stream-node-max-entries", "-1"},
+		{"hll-sparse-max-bytes", "-1"},
+	} {
+		if err := Set(bad[0], bad[1]); err == nil {
+			t.Errorf("Set(%s, %s) should fail", bad[0], bad[1])
+		}
+	}
+	if ListMaxZiplistSize != -5 || ListCompressDepth != 3 {
+		t.Errorf("invalid values are applied: %d, %d", ListMaxZiplistSize, ListCompressDepth)
+	}
+
+	if _, err := Get("no-such-parameter"); err != ErrUnknownParameter {
+		t.Errorf("Get unknown parameter err = %v", err)
+	}
+}
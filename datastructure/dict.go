@@ -1,5 +1,7 @@
 package datastructure
 
+import "math/rand"
+
 type Dict struct {
 	rehashIdx int64
 	table     [2]dictTable
@@ -71,6 +73,66 @@ func (d *Dict) Get(key string) string {
 	return ""
 }
 
+// Find gets the value designated by the key,
+// and reports whether the key-pair is in the dictionary,
+// which tells a missing key from an empty value.
+func (d *Dict) Find(key string) (string, bool) {
+	if d.Size() == 0 {
+		return "", false
+	}
+	if d.isRehashing() {
+		d.rehash(1)
+	}
+	hash := stringHash(key)
+	for i := 0; i < 2; i++ {
+		idx := hash & d.table[i].SizeMask
+		for he := d.table[i].Entries[idx]; he != nil; he = he.Next {
+			if key == he.Key {
+				return he.Val, true
+			}
+		}
+		if !d.isRehashing() {
+			break
+		}
+	}
+	return "", false
+}
+
+// RandomEntry returns a random key-pair of the dictionary,
+// and false if the dictionary is empty.
+func (d *Dict) RandomEntry() (DictEntry, bool) {
+	if d.Size() == 0 {
+		return DictEntry{}, false
+	}
+	if d.isRehashing() {
+		d.rehash(1)
+	}
+
+	// pick a non-empty bucket, skipping the buckets already rehashed,
+	// then a random entry of the chain.
+	var he *dictEntry
+	for he == nil {
+		if d.isRehashing() {
+			idx := d.rehashIdx + rand.Int63n(d.table[0].Size+d.table[1].Size-d.rehashIdx)
+			if idx >= d.table[0].Size {
+				he = d.table[1].Entries[idx-d.table[0].Size]
+			} else {
+				he = d.table[0].Entries[idx]
+			}
+		} else {
+			he = d.table[0].Entries[rand.Int63n(d.table[0].Size)]
+		}
+	}
+	n := 0
+	for e := he; e != nil; e = e.Next {
+		n++
+	}
+	for i := rand.Intn(n); i > 0; i-- {
+		he = he.Next
+	}
+	return DictEntry{he.Key, he.Val}, true
+}
+
 func (d *Dict) Delete(key string) {
 	if d.Size() == 0 {
 		return
@@ -263,3 +325,64 @@ func stringHash(str string) int64 {
 	}
 	return h
 }
+
+// DictEntry is a key-pair returned by DictIterator.
+type DictEntry struct {
+	Key string
+	Val string
+}
+
+// DictIterator iterates over the key-pairs of a dict in no particular order.
+// The dict must not be modified nor looked up during the iteration,
+// as either may move the entries between the tables.
+type DictIterator struct {
+	d     *Dict
+	table int
+	idx   int64
+	next  *dictEntry
+}
+
+func (d *Dict) Iterator() *DictIterator {
+	it := &DictIterator{d: d}
+	it.Reset()
+	return it
+}
+
+// Reset moves the iterator back to the first key-pair.
+func (it *DictIterator) Reset() {
+	it.table, it.idx, it.next = 0, -1, nil
+	it.advance()
+}
+
+// Next returns the next key-pair as a DictEntry,
+// or nil if the iteration is over.
+func (it *DictIterator) Next() interface{} {
+	if it.next == nil {
+		return nil
+	}
+	e := DictEntry{it.next.Key, it.next.Val}
+	it.next = it.next.Next
+	if it.next == nil {
+		it.advance()
+	}
+	return e
+}
+
+// advance moves to the first entry of the next non-empty bucket.
+func (it *DictIterator) advance() {
+	for {
+		it.idx++
+		if it.idx >= it.d.table[it.table].Size {
+			if it.table == 1 || !it.d.isRehashing() {
+				return
+			}
+			it.table, it.idx = 1, 0
+		}
+		if it.idx < it.d.table[it.table].Size {
+			if e := it.d.table[it.table].Entries[it.idx]; e != nil {
+				it.next = e
+				return
+			}
+		}
+	}
+}
@@ -3,6 +3,8 @@ package datastructure
 import (
 	crand "crypto/rand"
 	"encoding/base64"
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -38,6 +40,64 @@ func TestDict_Api(t *testing.T) {
 	}
 }
 
+func TestDict_FindAndIterator(t *testing.T) {
+	dict := NewDict()
+	if _, ok := dict.RandomEntry(); ok {
+		t.Errorf("RandomEntry of empty dict")
+	}
+	if e := dict.Iterator().Next(); e != nil {
+		t.Errorf("Iterator of empty dict returns %v", e)
+	}
+
+	want := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		k := randstring(10)
+		want[k] = fmt.Sprint(i)
+		dict.Put(k, want[k])
+	}
+	dict.Put("empty", "")
+	want["empty"] = ""
+
+	if v, ok := dict.Find("empty"); v != "" || !ok {
+		t.Errorf("Find empty value = %q, %v", v, ok)
+	}
+	if _, ok := dict.Find("missing"); ok {
+		t.Errorf("Find missing key")
+	}
+
+	// stop in the middle of rehashing, so that both tables are iterated
+	dict.Put("one more", "x")
+	want["one more"] = "x"
+	for i := 0; i < 100 && !dict.isRehashing(); i++ {
+		k := randstring(10)
+		dict.Put(k, "y")
+		want[k] = "y"
+	}
+	if !dict.isRehashing() {
+		t.Fatalf("dict is not rehashing")
+	}
+
+	got := make(map[string]string)
+	it := dict.Iterator()
+	for e := it.Next(); e != nil; e = it.Next() {
+		de := e.(DictEntry)
+		if _, ok := got[de.Key]; ok {
+			t.Errorf("Iterator returns %q twice", de.Key)
+		}
+		got[de.Key] = de.Val
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Iterator returns %d entries, want %d", len(got), len(want))
+	}
+
+	for i := 0; i < 100; i++ {
+		e, ok := dict.RandomEntry()
+		if v, found := want[e.Key]; !ok || !found || v != e.Val {
+			t.Errorf("RandomEntry = %v, %v", e, ok)
+		}
+	}
+}
+
 func randstring(n int) string {
 	b := make([]byte, 2*n)
 	crand.Read(b)
@@ -0,0 +1,439 @@
+package datastructure
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/viktorxhzj/mykv/util"
+)
+
+// HyperLogLog is a byte-slice-based probabilistic data structure
+// that estimates the number of distinct elements added to it,
+// with a standard error of 0.81%, in the same layout as Redis.
+//
+// An element is hashed with MurmurHash64A, where the lowest 14 bits
+// select one of the 16384 registers, and the register keeps the maximum
+// position of the first set bit of the rest of the hash.
+//
+// Layout:
+// <"HYLL"> <encoding uint8> <unused 3 bytes> <cardinality uint64 little-endian> <registers>
+// where the most significant bit of the cached cardinality set means it is invalid.
+//
+// The dense encoding packs the registers in 6 bits each, from the least significant bit of each byte.
+// The sparse encoding run-length encodes the registers in opcodes:
+// |00xx xxxx|                ZERO:  x+1 registers of 0, up to 64;
+// |01xx xxxx|yyyy yyyy|      XZERO: xy+1 registers of 0, up to 16384;
+// |1vvv vvxx|                VAL:   x+1 registers of v+1, up to 4 registers of up to 32.
+// A sparse HyperLogLog is converted to dense once a register exceeds 32,
+// or it grows larger than the limit given to Add.
+//
+// Time Complexity:
+// Add 		O(1) if dense, O(n) if sparse;
+// Count 	O(1) if cached, O(m) otherwise;
+// where m is the number of registers.
+type HyperLogLog []byte
+
+const (
+	HLL_P            = 14
+	HLL_Q            = 64 - HLL_P
+	HLL_REGISTERS    = 1 << HLL_P
+	HLL_P_MASK       = HLL_REGISTERS - 1
+	HLL_BITS         = 6
+	HLL_REGISTER_MAX = 1<<HLL_BITS - 1
+	HLL_HDR_SIZE     = 16
+	HLL_DENSE_SIZE   = HLL_HDR_SIZE + (HLL_REGISTERS*HLL_BITS+7)/8
+
+	HLL_DENSE        = 0
+	HLL_SPARSE       = 1
+	HLL_MAX_ENCODING = 1
+
+	HLL_SPARSE_ZERO_BIT      = 0x00
+	HLL_SPARSE_XZERO_BIT     = 0x40
+	HLL_SPARSE_VAL_BIT       = 0x80
+	HLL_SPARSE_OPCODE_MASK   = 0xC0
+	HLL_SPARSE_VAL_MAX_VALUE = 32
+	HLL_SPARSE_VAL_MAX_LEN   = 4
+	HLL_SPARSE_ZERO_MAX_LEN  = 64
+	HLL_SPARSE_XZERO_MAX_LEN = 16384
+
+	HLL_HASH_SEED = 0xadc83b19
+	HLL_ALPHA_INF = 0.721347520444481703680
+
+	hllMagic           = "HYLL"
+	hllEncodingOffset  = 4
+	hllCardOffset      = 8
+	hllCardInvalidByte = hllCardOffset + 7
+)
+
+var (
+	ErrHLLCorrupted = errors.New("corrupted HyperLogLog")
+)
+
+// NewHyperLogLog creates an empty sparse HyperLogLog.
+func NewHyperLogLog() *HyperLogLog {
+	h := make(HyperLogLog, HLL_HDR_SIZE, HLL_HDR_SIZE+2)
+	copy(h, hllMagic)
+	h[hllEncodingOffset] = HLL_SPARSE
+	h = appendSparseZero(h, HLL_REGISTERS)
+	return &h
+}
+
+// NewDenseHyperLogLog creates a dense HyperLogLog of the registers.
+func NewDenseHyperLogLog(registers []uint8) *HyperLogLog {
+	h := make(HyperLogLog, HLL_DENSE_SIZE)
+	copy(h, hllMagic)
+	h[hllEncodingOffset] = HLL_DENSE
+	h.invalidateCache()
+	regs := h[HLL_HDR_SIZE:]
+	for i, v := range registers {
+		denseSet(regs, i, v)
+	}
+	return &h
+}
+
+// IsHyperLogLog reports whether b has the header of a HyperLogLog,
+// and the size of a dense one if it is dense.
+// The sparse opcodes are only checked when they are read.
+func IsHyperLogLog(b []byte) bool {
+	if len(b) < HLL_HDR_SIZE || string(b[:len(hllMagic)]) != hllMagic || b[hllEncodingOffset] > HLL_MAX_ENCODING {
+		return false
+	}
+	return b[hllEncodingOffset] != HLL_DENSE || len(b) == HLL_DENSE_SIZE
+}
+
+// IsSparse reports whether the HyperLogLog is sparse.
+func (h *HyperLogLog) IsSparse() bool {
+	return (*h)[hllEncodingOffset] == HLL_SPARSE
+}
+
+// Add adds the element, converting a sparse HyperLogLog to dense
+// if it would grow larger than sparseMaxBytes,
+// and returns true if a register changed.
+func (h *HyperLogLog) Add(element []byte, sparseMaxBytes int) (bool, error) {
+	index, count := hllPatLen(element)
+	var changed bool
+	var err error
+	if h.IsSparse() {
+		changed, err = h.sparseSet(index, count, sparseMaxBytes)
+	} else {
+		changed = denseUpdate((*h)[HLL_HDR_SIZE:], index, count)
+	}
+	if changed {
+		h.invalidateCache()
+	}
+	return changed, err
+}
+
+// Count returns the estimated cardinality,
+// which is cached in the header until a register changes.
+func (h *HyperLogLog) Count() (uint64, error) {
+	b := *h
+	if b[hllCardInvalidByte]&(1<<7) == 0 {
+		return binary.LittleEndian.Uint64(b[hllCardOffset:]), nil
+	}
+
+	var histogram [64]int
+	if h.IsSparse() {
+		err := h.walkSparse(func(_, value, n int) {
+			histogram[value] += n
+		})
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		regs := b[HLL_HDR_SIZE:]
+		for i := 0; i < HLL_REGISTERS; i++ {
+			histogram[denseGet(regs, i)]++
+		}
+	}
+	card := hllEstimate(&histogram)
+	binary.LittleEndian.PutUint64(b[hllCardOffset:], card)
+	return card, nil
+}
+
+// Merge sets each of the registers to the maximum of it and the register of the HyperLogLog.
+func (h *HyperLogLog) Merge(registers []uint8) error {
+	if h.IsSparse() {
+		return h.walkSparse(func(first, value, n int) {
+			for i := first; i < first+n; i++ {
+				if uint8(value) > registers[i] {
+					registers[i] = uint8(value)
+				}
+			}
+		})
+	}
+	regs := (*h)[HLL_HDR_SIZE:]
+	for i := range registers {
+		if v := denseGet(regs, i); v > registers[i] {
+			registers[i] = v
+		}
+	}
+	return nil
+}
+
+// CountRegisters returns the estimated cardinality of the registers.
+func CountRegisters(registers []uint8) uint64 {
+	var histogram [64]int
+	for _, v := range registers {
+		histogram[v&HLL_REGISTER_MAX]++
+	}
+	return hllEstimate(&histogram)
+}
+
+func (h *HyperLogLog) invalidateCache() {
+	(*h)[hllCardInvalidByte] |= 1 << 7
+}
+
+// hllPatLen returns the register of the element,
+// and the position of the first set bit of the rest of its hash, from 1.
+func hllPatLen(element []byte) (int, uint8) {
+	hash := util.MurmurHash64A(element, HLL_HASH_SEED)
+	index := int(hash & HLL_P_MASK)
+	// the sentinel bit makes the count at most HLL_Q+1
+	hash >>= HLL_P
+	hash |= 1 << HLL_Q
+	count := uint8(1)
+	for bit := uint64(1); hash&bit == 0; bit <<= 1 {
+		count++
+	}
+	return index, count
+}
+
+// hllEstimate estimates the cardinality from the histogram of the register values,
+// by the estimator of Otmar Ertl, "New cardinality estimation algorithms for HyperLogLog sketches".
+func hllEstimate(histogram *[64]int) uint64 {
+	m := float64(HLL_REGISTERS)
+	z := m * hllTau((m-float64(histogram[HLL_Q+1]))/m)
+	for j := HLL_Q; j >= 1; j-- {
+		z += float64(histogram[j])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(histogram[0])/m)
+	return uint64(math.Round(HLL_ALPHA_INF * m * m / z))
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		zPrime := z
+		z += x * y
+		y += y
+		if zPrime == z {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		zPrime := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if zPrime == z {
+			return z / 3
+		}
+	}
+}
+
+//
+// dense encoding
+//
+
+func denseGet(regs []byte, i int) uint8 {
+	b, fb := i*HLL_BITS/8, uint(i*HLL_BITS&7)
+	v := regs[b] >> fb
+	if b+1 < len(regs) {
+		v |= regs[b+1] << (8 - fb)
+	}
+	return v & HLL_REGISTER_MAX
+}
+
+func denseSet(regs []byte, i int, v uint8) {
+	b, fb := i*HLL_BITS/8, uint(i*HLL_BITS&7)
+	regs[b] &^= HLL_REGISTER_MAX << fb
+	regs[b] |= v << fb
+	if b+1 < len(regs) {
+		regs[b+1] &^= HLL_REGISTER_MAX >> (8 - fb)
+		regs[b+1] |= v >> (8 - fb)
+	}
+}
+
+// denseUpdate sets the register to count if it is greater, and returns true if it is set.
+func denseUpdate(regs []byte, i int, count uint8) bool {
+	if denseGet(regs, i) >= count {
+		return false
+	}
+	denseSet(regs, i, count)
+	return true
+}
+
+//
+// sparse encoding
+//
+
+// sparseOp decodes the opcode at p, and returns the value of its registers,
+// the number of them, and the size of the opcode.
+func sparseOp(b []byte, p int) (value, n, size int, ok bool) {
+	c := b[p]
+	switch c & HLL_SPARSE_OPCODE_MASK {
+	case HLL_SPARSE_ZERO_BIT:
+		return 0, int(c&0x3F) + 1, 1, true
+	case HLL_SPARSE_XZERO_BIT:
+		if p+1 >= len(b) {
+			return 0, 0, 0, false
+		}
+		return 0, (int(c&0x3F)<<8 | int(b[p+1])) + 1, 2, true
+	default:
+		return int(c>>2&0x1F) + 1, int(c&0x03) + 1, 1, true
+	}
+}
+
+func appendSparseZero(b []byte, n int) []byte {
+	if n <= HLL_SPARSE_ZERO_MAX_LEN {
+		return append(b, HLL_SPARSE_ZERO_BIT|byte(n-1))
+	}
+	return append(b, HLL_SPARSE_XZERO_BIT|byte((n-1)>>8), byte(n-1))
+}
+
+func sparseVal(value, n int) byte {
+	return HLL_SPARSE_VAL_BIT | byte(value-1)<<2 | byte(n-1)
+}
+
+// walkSparse calls f with the first register, the value and the number of the registers of each opcode.
+func (h *HyperLogLog) walkSparse(f func(first, value, n int)) error {
+	b := *h
+	idx := 0
+	for p := HLL_HDR_SIZE; p < len(b); {
+		value, n, size, ok := sparseOp(b, p)
+		if !ok || idx+n > HLL_REGISTERS {
+			return ErrHLLCorrupted
+		}
+		f(idx, value, n)
+		idx += n
+		p += size
+	}
+	if idx != HLL_REGISTERS {
+		return ErrHLLCorrupted
+	}
+	return nil
+}
+
+// sparseSet sets the register to count if it is greater,
+// splitting the opcode that covers it, and returns true if it is set.
+func (h *HyperLogLog) sparseSet(index int, count uint8, sparseMaxBytes int) (bool, error) {
+	if count > HLL_SPARSE_VAL_MAX_VALUE {
+		return h.promote(index, count)
+	}
+
+	// find the opcode covering the register
+	b := *h
+	p, prev, first := HLL_HDR_SIZE, -1, 0
+	var value, n, size int
+	for ; p < len(b); p += size {
+		var ok bool
+		if value, n, size, ok = sparseOp(b, p); !ok {
+			return false, ErrHLLCorrupted
+		}
+		if index < first+n {
+			break
+		}
+		prev = p
+		first += n
+	}
+	if p >= len(b) {
+		return false, ErrHLLCorrupted
+	}
+	if value >= int(count) {
+		return false, nil
+	}
+
+	if value > 0 && n == 1 {
+		b[p] = sparseVal(int(count), 1)
+	} else {
+		// split the opcode into up to three: the registers before, the register, the registers after
+		last := first + n - 1
+		seq := make([]byte, 0, 5)
+		appendRun := func(n int) {
+			if value == 0 {
+				seq = appendSparseZero(seq, n)
+			} else {
+				seq = append(seq, sparseVal(value, n))
+			}
+		}
+		if index > first {
+			appendRun(index - first)
+		}
+		seq = append(seq, sparseVal(int(count), 1))
+		if index < last {
+			appendRun(last - index)
+		}
+
+		if delta := len(seq) - size; delta > 0 && len(b)+delta > sparseMaxBytes {
+			return h.promote(index, count)
+		}
+		res := make([]byte, 0, len(b)+len(seq)-size)
+		res = append(res, b[:p]...)
+		res = append(res, seq...)
+		b = append(res, b[p+size:]...)
+	}
+
+	// merge the adjacent VAL opcodes of the same value around the change
+	if prev != -1 {
+		p = prev
+	} else {
+		p = HLL_HDR_SIZE
+	}
+	for scan := 5; p < len(b) && scan > 0; scan-- {
+		switch b[p] & HLL_SPARSE_OPCODE_MASK {
+		case HLL_SPARSE_XZERO_BIT:
+			p += 2
+			continue
+		case HLL_SPARSE_ZERO_BIT:
+			p++
+			continue
+		}
+		if p+1 < len(b) && b[p+1]&HLL_SPARSE_VAL_BIT != 0 {
+			v1, n1, _, _ := sparseOp(b, p)
+			v2, n2, _, _ := sparseOp(b, p+1)
+			if v1 == v2 && n1+n2 <= HLL_SPARSE_VAL_MAX_LEN {
+				b[p+1] = sparseVal(v1, n1+n2)
+				b = append(b[:p], b[p+1:]...)
+				continue
+			}
+		}
+		p++
+	}
+	*h = b
+	return true, nil
+}
+
+// promote converts the sparse HyperLogLog to dense, then sets the register.
+func (h *HyperLogLog) promote(index int, count uint8) (bool, error) {
+	dense := make(HyperLogLog, HLL_DENSE_SIZE)
+	copy(dense, (*h)[:HLL_HDR_SIZE])
+	dense[hllEncodingOffset] = HLL_DENSE
+	regs := dense[HLL_HDR_SIZE:]
+	err := h.walkSparse(func(first, value, n int) {
+		if value == 0 {
+			return
+		}
+		for i := first; i < first+n; i++ {
+			denseSet(regs, i, uint8(value))
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+	*h = dense
+	return denseUpdate(regs, index, count), nil
+}
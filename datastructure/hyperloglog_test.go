@@ -0,0 +1,148 @@
+package datastructure
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/util"
+)
+
+func hllRegisters(t *testing.T, h *HyperLogLog) []uint8 {
+	t.Helper()
+	registers := make([]uint8, HLL_REGISTERS)
+	if err := h.Merge(registers); err != nil {
+		t.Fatal(err)
+	}
+	return registers
+}
+
+func TestHyperLogLog_Hash(t *testing.T) {
+	// the hashes of the reference MurmurHash64A
+	for s, want := range map[string]uint64{
+		"":                  0xd8dfea6585bc9732,
+		"a":                 0x53d2470a9b43b1a7,
+		"hello":             0x0f656f01eecfe400,
+		"hello world!":      0x0fc444011f57220c,
+		"0123456789abcdef0": 0xca1802fd45a1ff6c,
+	} {
+		if got := util.MurmurHash64A([]byte(s), HLL_HASH_SEED); got != want {
+			t.Errorf("MurmurHash64A(%q) = %x, want %x", s, got, want)
+		}
+	}
+}
+
+func TestHyperLogLog_Empty(t *testing.T) {
+	h := NewHyperLogLog()
+	want := []byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xff")
+	if !bytes.Equal(*h, want) || !IsHyperLogLog(*h) {
+		t.Errorf("NewHyperLogLog() = %q, want %q", *h, want)
+	}
+	if n, err := h.Count(); n != 0 || err != nil {
+		t.Errorf("Count() = %d, %v", n, err)
+	}
+	if n := CountRegisters(make([]uint8, HLL_REGISTERS)); n != 0 {
+		t.Errorf("CountRegisters of zeros = %d", n)
+	}
+
+	for _, b := range []string{"", "HYLL", "HYLX\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00", "HYLL\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00", "HYLL\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"} {
+		if IsHyperLogLog([]byte(b)) {
+			t.Errorf("IsHyperLogLog(%q) = true", b)
+		}
+	}
+}
+
+func TestHyperLogLog_Add(t *testing.T) {
+	sparse, dense := NewHyperLogLog(), NewHyperLogLog()
+	for i := 0; i < 20; i++ {
+		e := []byte(fmt.Sprint("element:", i))
+		changed, err := sparse.Add(e, 3000)
+		if !changed || err != nil {
+			t.Errorf("Add(%s) = %v, %v", e, changed, err)
+		}
+		dense.Add(e, 0)
+		if changed, _ = sparse.Add(e, 3000); changed {
+			t.Errorf("Add(%s) again changed a register", e)
+		}
+	}
+	if !sparse.IsSparse() || dense.IsSparse() || len(*dense) != HLL_DENSE_SIZE {
+		t.Errorf("encodings = %d, %d", (*sparse)[4], (*dense)[4])
+	}
+	if !reflect.DeepEqual(hllRegisters(t, sparse), hllRegisters(t, dense)) {
+		t.Errorf("sparse and dense registers differ")
+	}
+	ns, _ := sparse.Count()
+	nd, _ := dense.Count()
+	if ns != 20 || nd != 20 {
+		t.Errorf("Count() = %d, %d, want 20", ns, nd)
+	}
+
+	// the cache is invalidated by a change only
+	(*sparse)[hllCardOffset] = 99
+	if n, _ := sparse.Count(); n != 99 {
+		t.Errorf("cached Count() = %d", n)
+	}
+	sparse.Add([]byte("another"), 3000)
+	if n, _ := sparse.Count(); n != 21 {
+		t.Errorf("Count() after Add = %d", n)
+	}
+}
+
+func TestHyperLogLog_Accuracy(t *testing.T) {
+	h := NewHyperLogLog()
+	const n = 100000
+	for i := 0; i < n; i++ {
+		h.Add([]byte(fmt.Sprint(i)), 3000)
+		if i == 1000 {
+			if c, _ := h.Count(); math.Abs(float64(c)-1001) > 1001*0.05 {
+				t.Errorf("Count() of 1001 = %d", c)
+			}
+		}
+	}
+	if h.IsSparse() {
+		t.Errorf("not converted to dense")
+	}
+	if c, _ := h.Count(); math.Abs(float64(c)-n) > n*0.03 {
+		t.Errorf("Count() of %d = %d", n, c)
+	}
+}
+
+func TestHyperLogLog_SparseToDense(t *testing.T) {
+	// registers beyond the maximum value of a sparse opcode
+	sparse := NewHyperLogLog()
+	for i := 0; i < 2000; i++ {
+		sparse.Add([]byte(fmt.Sprint(i)), 1<<20)
+	}
+	if !sparse.IsSparse() {
+		t.Fatalf("converted to dense below the size limit")
+	}
+	registers := hllRegisters(t, sparse)
+
+	dense := NewDenseHyperLogLog(registers)
+	if !reflect.DeepEqual(hllRegisters(t, dense), registers) {
+		t.Errorf("dense registers differ")
+	}
+	registers[5] = 40
+	if _, err := sparse.promote(5, 40); err != nil || !reflect.DeepEqual(hllRegisters(t, sparse), registers) {
+		t.Errorf("promote = %v, registers differ", err)
+	}
+	cs, _ := sparse.Count()
+	if cr := CountRegisters(registers); cs != cr {
+		t.Errorf("Count() = %d, CountRegisters() = %d", cs, cr)
+	}
+}
+
+func TestHyperLogLog_Corrupted(t *testing.T) {
+	// the opcodes cover fewer registers than there are
+	h := NewHyperLogLog()
+	(*h)[HLL_HDR_SIZE+1] = 0xFE
+	h.invalidateCache()
+	if _, err := h.Count(); err != ErrHLLCorrupted {
+		t.Errorf("Count() err = %v", err)
+	}
+	if err := h.Merge(make([]uint8, HLL_REGISTERS)); err != ErrHLLCorrupted {
+		t.Errorf("Merge() err = %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package datastructure
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"github.com/viktorxhzj/mykv/util"
 )
 
@@ -11,7 +12,8 @@ import (
 // Time Complexity:
 // Find		O(logn);
 // Add 		O(n);
-// Get 		O(n);
+// Get 		O(1);
+// Remove 	O(n);
 //
 // IntSet has a maximum length of UINT32_MAX
 // Padding:
@@ -134,6 +136,31 @@ func (is *IntSet) Get(idx int) (int, error) {
 	}
 }
 
+// Remove removes an integer from the intset.
+// It returns false if the integer is not in the intset.
+func (is *IntSet) Remove(n int) bool {
+	if intsetValueEncoding(n) > is.encoding {
+		return false
+	}
+	idx, exists := is.Find(n)
+	if !exists {
+		return false
+	}
+	enc := int(is.encoding)
+	copy(is.contents[idx*enc:], is.contents[(idx+1)*enc:])
+	is.len--
+	is.contents = is.contents[:int(is.len)*enc]
+	return true
+}
+
+// Random returns a random integer of the intset.
+func (is *IntSet) Random() (int, error) {
+	if is.len == 0 {
+		return 0, ErrEmpty
+	}
+	return is.Get(rand.Intn(int(is.len)))
+}
+
 func (is *IntSet) setAtIndex(n, idx int) {
 	if idx < 0 || idx > int(is.len) {
 		fmt.Println("invalid input idx")
@@ -171,7 +198,6 @@ func (is *IntSet) getEncoded(idx int, enc uint8) (res int) {
 		res = int(util.BToI64(is.contents, offset))
 
 	}
-	fmt.Println("res = ", res)
 	return
 }
 
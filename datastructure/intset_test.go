@@ -53,3 +53,37 @@ func TestIntSet_Iterator(t *testing.T) {
 		fmt.Println(is.Get(i))
 	}
 }
+
+func TestIntSet_Remove(t *testing.T) {
+	is := NewIntSet()
+	for _, v := range []int{5, 1, math.MaxInt32 + 1, -7, 3} {
+		is.Add(v)
+	}
+
+	if is.Remove(4) || is.Remove(math.MaxInt64) {
+		t.Errorf("Remove a missing integer")
+	}
+	if !is.Remove(math.MaxInt32+1) || !is.Remove(-7) {
+		t.Errorf("Remove an existing integer")
+	}
+	if is.Size() != 3 {
+		t.Fatalf("Size = %d", is.Size())
+	}
+	for i, want := range []int{1, 3, 5} {
+		if v, _ := is.Get(i); v != want {
+			t.Errorf("Get(%d) = %d, want %d", i, v, want)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		if v, err := is.Random(); err != nil || (v != 1 && v != 3 && v != 5) {
+			t.Errorf("Random = %d, %v", v, err)
+		}
+	}
+	is.Remove(1)
+	is.Remove(3)
+	is.Remove(5)
+	if _, err := is.Random(); err != ErrEmpty {
+		t.Errorf("Random of empty intset err = %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package datastructure
+
+import (
+	"math"
+
+	"github.com/viktorxhzj/mykv/util"
+)
+
+// ListPack is a byte-slice-based data structure
+// that holds integers or strings, like ZipList.
+//
+// Unlike a ziplist entry, which stores the length of the previous entry,
+// a listpack entry stores its own length at its end,
+// so that changing an entry never cascades to the next ones.
+//
+// Layout:
+// <total-bytes uint32> <num-elements uint16> <entry> ... <entry> <0xFF>
+// where each entry is <encoding+data> <backlen>,
+// and backlen is the length of encoding+data in 1 to 5 bytes,
+// 7 bits a byte, which are read from right to left.
+//
+// Time Complexity:
+// Add 		O(1);
+// Get 		O(n);
+// Next 	O(1);
+// Prev 	O(1);
+// Replace 	O(n);
+type ListPack []byte
+
+const (
+	LP_MAX_LEN = math.MaxUint16
+
+	LP_NUMELE_OFFSET = 4
+	LP_HEADER_SIZE   = 6
+	LP_EOF           = 0xFF
+
+	LP_7BIT_UINT      = 0x00
+	LP_7BIT_UINT_MASK = 0x80
+	LP_6BIT_STR       = 0x80
+	LP_6BIT_STR_MASK  = 0xC0
+	LP_13BIT_INT      = 0xC0
+	LP_13BIT_INT_MASK = 0xE0
+	LP_12BIT_STR      = 0xE0
+	LP_12BIT_STR_MASK = 0xF0
+	LP_32BIT_STR      = 0xF0
+	LP_16BIT_INT      = 0xF1
+	LP_24BIT_INT      = 0xF2
+	LP_32BIT_INT      = 0xF3
+	LP_64BIT_INT      = 0xF4
+)
+
+func NewListPack() *ListPack {
+	lp := new(ListPack)
+	*lp = make([]byte, LP_HEADER_SIZE+1)
+	util.UI32ToB(LP_HEADER_SIZE+1, *lp, 0)
+	util.UI16ToB(0, *lp, LP_NUMELE_OFFSET)
+	(*lp)[LP_HEADER_SIZE] = LP_EOF
+	return lp
+}
+
+// LPBytes returns the number of bytes that the listpack occupies.
+func (lp *ListPack) LPBytes() int {
+	return int(util.BToUI32(*lp, 0))
+}
+
+// LPLen returns the number of entries.
+func (lp *ListPack) LPLen() int {
+	return int(util.BToUI16(*lp, LP_NUMELE_OFFSET))
+}
+
+// AddInt adds an integer at the tail of the listpack.
+func (lp *ListPack) AddInt(i int) error {
+	return lp.add(i)
+}
+
+// AddString adds a string at the tail of the listpack.
+func (lp *ListPack) AddString(s string) error {
+	return lp.add(s)
+}
+
+// Get returns the entry at the given index, an int or a string.
+func (lp *ListPack) Get(idx int) (interface{}, error) {
+	if idx < 0 || idx >= lp.LPLen() {
+		return nil, ErrInvalidIdx
+	}
+	p := lp.First()
+	for i := 0; i < idx; i++ {
+		p = lp.Next(p)
+	}
+	return lp.GetAt(p), nil
+}
+
+// First returns the position of the first entry, or -1 if the listpack is empty.
+func (lp *ListPack) First() int {
+	if lp.LPLen() == 0 {
+		return -1
+	}
+	return LP_HEADER_SIZE
+}
+
+// Last returns the position of the last entry, or -1 if the listpack is empty.
+func (lp *ListPack) Last() int {
+	if lp.LPLen() == 0 {
+		return -1
+	}
+	return lp.Prev(lp.LPBytes() - 1)
+}
+
+// Next returns the position of the entry after the one at p,
+// or -1 if p is the last entry.
+func (lp *ListPack) Next(p int) int {
+	p += lp.entryLen(p)
+	if (*lp)[p] == LP_EOF {
+		return -1
+	}
+	return p
+}
+
+// Prev returns the position of the entry before the one at p,
+// or -1 if p is the first entry.
+// p may also be the position of the EOF byte.
+func (lp *ListPack) Prev(p int) int {
+	if p == LP_HEADER_SIZE {
+		return -1
+	}
+	l := decodeBacklen(*lp, p-1)
+	return p - l - backlenSize(l)
+}
+
+// GetAt returns the entry at the position p, an int or a string.
+func (lp *ListPack) GetAt(p int) interface{} {
+	b := *lp
+	enc := b[p]
+	switch {
+	case enc&LP_7BIT_UINT_MASK == LP_7BIT_UINT:
+		return int(enc)
+	case enc&LP_6BIT_STR_MASK == LP_6BIT_STR:
+		l := int(enc &^ LP_6BIT_STR_MASK)
+		return string(b[p+1 : p+1+l])
+	case enc&LP_13BIT_INT_MASK == LP_13BIT_INT:
+		u := uint64(enc&^LP_13BIT_INT_MASK)<<8 | uint64(b[p+1])
+		return int(int64(u<<51) >> 51)
+	case enc&LP_12BIT_STR_MASK == LP_12BIT_STR:
+		l := int(enc&^LP_12BIT_STR_MASK)<<8 | int(b[p+1])
+		return string(b[p+2 : p+2+l])
+	case enc == LP_32BIT_STR:
+		l := int(util.BToUI32(b, p+1))
+		return string(b[p+5 : p+5+l])
+	case enc == LP_16BIT_INT:
+		return int(util.BToI16(b, p+1))
+	case enc == LP_24BIT_INT:
+		u := uint64(b[p+1])<<16 | uint64(b[p+2])<<8 | uint64(b[p+3])
+		return int(int64(u<<40) >> 40)
+	case enc == LP_32BIT_INT:
+		return int(util.BToI32(b, p+1))
+	default:
+		return int(util.BToI64(b, p+1))
+	}
+}
+
+// ReplaceAt replaces the entry at the position p with an int or a string.
+// The position of the entry stays the same, while the positions of the next ones may change.
+func (lp *ListPack) ReplaceAt(p int, e interface{}) error {
+	entry, err := encodeListPackEntry(e)
+	if err != nil {
+		return err
+	}
+	if lp.LPBytes()-lp.entryLen(p)+len(entry) > math.MaxUint32 {
+		return ErrExceedLimit
+	}
+
+	old := *lp
+	res := make([]byte, 0, len(old)-lp.entryLen(p)+len(entry))
+	res = append(res, old[:p]...)
+	res = append(res, entry...)
+	res = append(res, old[p+lp.entryLen(p):]...)
+	*lp = res
+	util.UI32ToB(uint32(len(res)), *lp, 0)
+	return nil
+}
+
+func (lp *ListPack) add(e interface{}) error {
+	if lp.LPLen() == LP_MAX_LEN {
+		return ErrExceedLimit
+	}
+	entry, err := encodeListPackEntry(e)
+	if err != nil {
+		return err
+	}
+	if lp.LPBytes()+len(entry) > math.MaxUint32 {
+		return ErrExceedLimit
+	}
+
+	*lp = append((*lp)[:len(*lp)-1], entry...)
+	*lp = append(*lp, LP_EOF)
+	util.UI32ToB(uint32(len(*lp)), *lp, 0)
+	util.UI16ToB(uint16(lp.LPLen()+1), *lp, LP_NUMELE_OFFSET)
+	return nil
+}
+
+// entryLen returns the length of the entry at p, including its backlen.
+func (lp *ListPack) entryLen(p int) int {
+	b := *lp
+	enc := b[p]
+	var l int
+	switch {
+	case enc&LP_7BIT_UINT_MASK == LP_7BIT_UINT:
+		l = 1
+	case enc&LP_6BIT_STR_MASK == LP_6BIT_STR:
+		l = 1 + int(enc&^LP_6BIT_STR_MASK)
+	case enc&LP_13BIT_INT_MASK == LP_13BIT_INT:
+		l = 2
+	case enc&LP_12BIT_STR_MASK == LP_12BIT_STR:
+		l = 2 + (int(enc&^LP_12BIT_STR_MASK)<<8 | int(b[p+1]))
+	case enc == LP_32BIT_STR:
+		l = 5 + int(util.BToUI32(b, p+1))
+	case enc == LP_16BIT_INT:
+		l = 3
+	case enc == LP_24BIT_INT:
+		l = 4
+	case enc == LP_32BIT_INT:
+		l = 5
+	default:
+		l = 9
+	}
+	return l + backlenSize(l)
+}
+
+// encodeListPackEntry encodes an int or a string into encoding+data+backlen.
+func encodeListPackEntry(e interface{}) ([]byte, error) {
+	var b []byte
+	switch v := e.(type) {
+	case int:
+		switch {
+		case v >= 0 && v <= 127:
+			b = []byte{byte(v)}
+		case v >= -4096 && v <= 4095:
+			u := uint64(v) & 0x1FFF
+			b = []byte{LP_13BIT_INT | byte(u>>8), byte(u)}
+		case v >= math.MinInt16 && v <= math.MaxInt16:
+			b = make([]byte, 3)
+			b[0] = LP_16BIT_INT
+			util.I16ToB(int16(v), b, 1)
+		case v >= -1<<23 && v < 1<<23:
+			u := uint64(v)
+			b = []byte{LP_24BIT_INT, byte(u >> 16), byte(u >> 8), byte(u)}
+		case v >= math.MinInt32 && v <= math.MaxInt32:
+			b = make([]byte, 5)
+			b[0] = LP_32BIT_INT
+			util.I32ToB(int32(v), b, 1)
+		default:
+			b = make([]byte, 9)
+			b[0] = LP_64BIT_INT
+			util.I64ToB(int64(v), b, 1)
+		}
+	case string:
+		switch l := len(v); {
+		case l < 1<<6:
+			b = append([]byte{LP_6BIT_STR | byte(l)}, v...)
+		case l < 1<<12:
+			b = append([]byte{LP_12BIT_STR | byte(l>>8), byte(l)}, v...)
+		case uint64(l) <= math.MaxUint32:
+			b = make([]byte, 5, 5+l)
+			b[0] = LP_32BIT_STR
+			util.UI32ToB(uint32(l), b, 1)
+			b = append(b, v...)
+		default:
+			return nil, ErrExceedLimit
+		}
+	default:
+		return nil, ErrZLInvalidInput
+	}
+	return append(b, encodeBacklen(len(b))...), nil
+}
+
+// encodeBacklen encodes l into 1 to 5 bytes, 7 bits a byte,
+// where every byte but the leftmost one has its highest bit set.
+func encodeBacklen(l int) []byte {
+	n := backlenSize(l)
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(l & 127)
+		if i != 0 {
+			b[i] |= 128
+		}
+		l >>= 7
+	}
+	return b
+}
+
+// decodeBacklen decodes the backlen ending at the position p, from right to left.
+func decodeBacklen(b []byte, p int) int {
+	l, shift := 0, 0
+	for {
+		l |= int(b[p]&127) << shift
+		if b[p]&128 == 0 {
+			return l
+		}
+		shift += 7
+		p--
+	}
+}
+
+func backlenSize(l int) int {
+	switch {
+	case l < 1<<7:
+		return 1
+	case l < 1<<14:
+		return 2
+	case l < 1<<21:
+		return 3
+	case l < 1<<28:
+		return 4
+	default:
+		return 5
+	}
+}
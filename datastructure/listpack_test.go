@@ -0,0 +1,79 @@
+package datastructure
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// listPackCheck verifies the listpack against the expected entries,
+// walking it both forward and backward.
+func listPackCheck(t *testing.T, lp *ListPack, want []interface{}) {
+	t.Helper()
+	if lp.LPLen() != len(want) || lp.LPBytes() != len(*lp) {
+		t.Fatalf("LPLen() = %d, LPBytes() = %d, want %d entries in %d bytes", lp.LPLen(), lp.LPBytes(), len(want), len(*lp))
+	}
+	var got []interface{}
+	for p := lp.First(); p != -1; p = lp.Next(p) {
+		got = append(got, lp.GetAt(p))
+	}
+	if len(want) > 0 && !reflect.DeepEqual(got, want) {
+		t.Errorf("forward = %v, want %v", got, want)
+	}
+	got = got[:0]
+	for p := lp.Last(); p != -1; p = lp.Prev(p) {
+		got = append([]interface{}{lp.GetAt(p)}, got...)
+	}
+	if len(want) > 0 && !reflect.DeepEqual(got, want) {
+		t.Errorf("backward = %v, want %v", got, want)
+	}
+}
+
+func TestListPack_AddAndGet(t *testing.T) {
+	lp := NewListPack()
+	listPackCheck(t, lp, nil)
+
+	want := []interface{}{
+		0, 127, 128, -1, 4095, -4096, 4096, 1<<15 - 1, -1 << 15, 1 << 23, -1 << 23, 1<<31 - 1, -1 << 31, 1 << 40, -1 << 63, 1<<63 - 1,
+		"", "a", strings.Repeat("x", 63), strings.Repeat("y", 64), strings.Repeat("z", 4095), strings.Repeat("w", 4096), "分布式",
+	}
+	for _, e := range want {
+		var err error
+		if i, ok := e.(int); ok {
+			err = lp.AddInt(i)
+		} else {
+			err = lp.AddString(e.(string))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	listPackCheck(t, lp, want)
+
+	for i, e := range want {
+		if got, err := lp.Get(i); err != nil || !reflect.DeepEqual(got, e) {
+			t.Errorf("Get(%d) = %v, %v, want %v", i, got, err, e)
+		}
+	}
+	if _, err := lp.Get(len(want)); err != ErrInvalidIdx {
+		t.Errorf("Get out of range err = %v", err)
+	}
+}
+
+func TestListPack_ReplaceAt(t *testing.T) {
+	lp := NewListPack()
+	for _, i := range []int{1, 2, 3} {
+		lp.AddInt(i)
+	}
+
+	// growing and shrinking entries keep the next ones intact
+	p := lp.Next(lp.First())
+	if err := lp.ReplaceAt(p, strings.Repeat("s", 200)); err != nil {
+		t.Fatal(err)
+	}
+	listPackCheck(t, lp, []interface{}{1, strings.Repeat("s", 200), 3})
+	lp.ReplaceAt(p, 1<<40)
+	listPackCheck(t, lp, []interface{}{1, 1 << 40, 3})
+	lp.ReplaceAt(lp.First(), "a")
+	listPackCheck(t, lp, []interface{}{"a", 1 << 40, 3})
+}
@@ -1,6 +1,7 @@
 package datastructure
 
 import (
+	"errors"
 	"math"
 	"github.com/viktorxhzj/mykv/util"
 )
@@ -13,24 +14,41 @@ type QuickList struct {
 	Count int            // 8
 	Len   uint32         // 4
 	Fill  int16          // 2
+
+	// Compress is the number of nodes at each end that are left uncompressed.
+	// Every node in between is compressed with LZF.
+	// 0 disables compression.
+	Compress uint16 // 2
 }
 
 const (
 	QL_FILL_OPTION   = 3
+	QL_FILL_MAX      = math.MaxInt16
 	QL_MAX_LEN       = math.MaxUint32 - 2 // 2 for dummy head/tail
 	QL_MAX_SIZE      = math.MaxInt64
 	QL_ZL_SIZE_LIMIT = 1 << 13
+
+	QL_MAX_COMPRESS_DEPTH   = math.MaxUint16
+	QL_MIN_COMPRESS_BYTES   = 48 // nodes smaller than this are not compressed
+	QL_MIN_COMPRESS_IMPROVE = 8  // compression has to save at least this many bytes
+
+	QL_NODE_ENCODING_RAW = 1
+	QL_NODE_ENCODING_LZF = 2
 )
 
 var (
 	QLOptimizationLevel = [5]int{4096, 8192, 16384, 32768, 65536}
+
+	ErrQLInvalidFill          = errors.New("fill must be a positive count or in range -5 to -1")
+	ErrQLInvalidCompressDepth = errors.New("compress depth is out of range")
 )
 
 type QuickListNode struct {
 	Prev   *QuickListNode // 8
 	Next   *QuickListNode // 8
-	ZL     *ZipList       // 8
-	ZLSize uint32         // 4 ziplist size in bytes
+	ZL     *ZipList       // 8 nil if the node is compressed
+	LZF    *QuickListLZF  // 8 nil if the node is not compressed
+	ZLSize uint32         // 4 ziplist size in bytes, even if the node is compressed
 
 	// |Extra 			   |Count			   |
 	// |0000 0000 0000 0000|0000 0000 0000 0000|
@@ -43,10 +61,17 @@ type QuickListNode struct {
 	// considering a smallest entry of 2 bytes, the node has at most ~ 32k entries.
 	// As a result, a bitfield of 16 is enough for Count.
 	//
-	// Redis implementation has other bit-fields mainly for compression,
-	// here we just ignore them (because we don't support compression)
-	// and only keep "Count" left.
+	// Redis implementation packs the fields below into the same bit-fields,
+	// here we keep them apart.
 	Count int16 // 2
+
+	Encoding   uint8 // 1 QL_NODE_ENCODING_RAW or QL_NODE_ENCODING_LZF
+	Recompress bool  // 1 the node is temporarily decompressed for use
+}
+
+// QuickListLZF holds the LZF-compressed ziplist of a node.
+type QuickListLZF struct {
+	Compressed []byte
 }
 
 type QuickListEntry struct {
@@ -67,31 +92,69 @@ func NewQuickList() *QuickList {
 	return q
 }
 
+// NewQuickListWithOptions creates a quicklist with the given fill and compress depth.
+//
+// A positive fill limits the number of elements of a node,
+// while a fill from -1 to -5 limits the size of a node to 4, 8, 16, 32 or 64 KB.
+// The compress depth is the number of nodes at each end that are left uncompressed,
+// 0 disables compression.
+func NewQuickListWithOptions(fill, compressDepth int) (*QuickList, error) {
+	if err := ValidateFill(fill); err != nil {
+		return nil, err
+	}
+	if err := ValidateCompressDepth(compressDepth); err != nil {
+		return nil, err
+	}
+	q := NewQuickList()
+	q.Fill = int16(fill)
+	q.Compress = uint16(compressDepth)
+	return q, nil
+}
+
+// ValidateFill checks that fill is a positive count or in range -5 to -1.
+func ValidateFill(fill int) error {
+	if (fill >= -len(QLOptimizationLevel) && fill <= -1) || (fill >= 1 && fill <= QL_FILL_MAX) {
+		return nil
+	}
+	return ErrQLInvalidFill
+}
+
+// ValidateCompressDepth checks that the compress depth is within range.
+func ValidateCompressDepth(depth int) error {
+	if depth >= 0 && depth <= QL_MAX_COMPRESS_DEPTH {
+		return nil
+	}
+	return ErrQLInvalidCompressDepth
+}
+
 func (q *QuickList) Size() int {
 	return int(q.Count)
 }
 
+// Get returns the entry of the element at the given index.
+// A negative index counts from the tail, -1 being the last element.
 func (q *QuickList) Get(idx int) (entry QuickListEntry, err error) {
-	if idx >= q.Count || (idx == math.MinInt64 && q.Count != QL_MAX_SIZE) || (-idx > q.Count) {
+	node, offset, nodeIdx, ok := q.index(idx)
+	if !ok {
 		err = ErrInvalidIdx
 		return
 	}
 
-	node := q.Head.Next
-	var offset int
-
-	for (idx - int(node.Count)) >= 0 {
-		idx -= int(node.Count)
-		node = node.Next
-		offset++
-	}
+	node.decompressForUse()
+	entry = q.newEntry(node, nodeIdx, offset, node.ZL.index(offset))
+	node.recompressOnly()
+	return
+}
 
+// newEntry builds the entry of the element at p of the node's ziplist,
+// which is the offset-th element of the node.
+func (q *QuickList) newEntry(node *QuickListNode, nodeIdx, offset, p int) (entry QuickListEntry) {
 	entry.List = q
 	entry.Node = node
-	entry.Offset[0] = offset
-	entry.Offset[1] = idx
+	entry.Offset[0] = nodeIdx
+	entry.Offset[1] = offset
 
-	e, _ := node.ZL.Get(idx)
+	e := node.ZL.getAt(p)
 	if s, ok := e.(string); ok {
 		entry.IsString = true
 		entry.String = s
@@ -132,9 +195,9 @@ func (q *QuickList) PushHead(e interface{}) (created bool, err error) {
 
 	switch t {
 	case 0:
-		h.ZL.AddString(ss)
+		h.ZL.InsertString(0, ss)
 	case 1:
-		h.ZL.AddInt(ii)
+		h.ZL.InsertInt(0, ii)
 	}
 	h.UpdateSize()
 	q.Count++
@@ -185,6 +248,495 @@ func (q *QuickList) PushTail(e interface{}) (created bool, err error) {
 	return
 }
 
+// PopHead removes the first element and returns it.
+func (q *QuickList) PopHead() (interface{}, error) {
+	if q.Count == 0 {
+		return nil, ErrEmpty
+	}
+	node := q.Head.Next
+	node.decompressForUse()
+	e, _ := node.ZL.Get(0)
+	q.deleteAt(node, 0)
+	return e, nil
+}
+
+// PopTail removes the last element and returns it.
+func (q *QuickList) PopTail() (interface{}, error) {
+	if q.Count == 0 {
+		return nil, ErrEmpty
+	}
+	node := q.Tail.Prev
+	offset := int(node.Count) - 1
+	node.decompressForUse()
+	e, _ := node.ZL.Get(offset)
+	q.deleteAt(node, offset)
+	return e, nil
+}
+
+// DeleteIndex deletes the element at the given index.
+// A negative index counts from the tail, -1 being the last element.
+func (q *QuickList) DeleteIndex(idx int) error {
+	node, offset, _, ok := q.index(idx)
+	if !ok {
+		return ErrInvalidIdx
+	}
+	q.deleteAt(node, offset)
+	return nil
+}
+
+// DelRange deletes count elements starting from the given index,
+// and returns the number of deleted elements.
+// A negative index counts from the tail, -1 being the last element.
+func (q *QuickList) DelRange(start, count int) (int, error) {
+	if count <= 0 {
+		return 0, nil
+	}
+	node, offset, _, ok := q.index(start)
+	if !ok {
+		return 0, ErrInvalidIdx
+	}
+
+	// limit the extent to the elements available after start
+	extent := count
+	if start >= 0 && extent > q.Count-start {
+		extent = q.Count - start
+	} else if start < 0 && extent > -start {
+		extent = -start
+	}
+
+	// the node before the deleted elements, where merging starts
+	before := node
+	if offset == 0 {
+		before = node.Prev
+	}
+
+	deleted := extent
+	for extent > 0 {
+		next := node.Next
+		del := int(node.Count) - offset
+		if del > extent {
+			del = extent
+		}
+
+		if del == int(node.Count) {
+			q.deleteNode(node)
+		} else {
+			node.decompressForUse()
+			node.ZL.DeleteRange(offset, del)
+			node.Count -= int16(del)
+			node.UpdateSize()
+			q.Count -= del
+			q.compress(node)
+		}
+
+		extent -= del
+		node = next
+		offset = 0
+	}
+
+	if before == q.Head {
+		before = q.Head.Next
+	}
+	if before != q.Tail {
+		q.mergeNodes(before)
+	}
+	return deleted, nil
+}
+
+// ReplaceAtIndex replaces the element at the given index with e.
+// A negative index counts from the tail, -1 being the last element.
+func (q *QuickList) ReplaceAtIndex(idx int, e interface{}) error {
+	ss, ii, t := util.AssertValidType(e)
+	if t == -1 {
+		return ErrZLInvalidInput
+	}
+	node, offset, _, ok := q.index(idx)
+	if !ok {
+		return ErrInvalidIdx
+	}
+
+	node.decompressForUse()
+	p := node.ZL.index(offset)
+	switch t {
+	case 0:
+		node.ZL.replaceAt(p, []byte(ss))
+	case 1:
+		node.ZL.replaceAt(p, ii)
+	}
+	node.UpdateSize()
+	q.compress(node)
+	return nil
+}
+
+// Rotate moves the last element to the head.
+func (q *QuickList) Rotate() {
+	if q.Count <= 1 {
+		return
+	}
+
+	tail := q.Tail.Prev
+	tail.decompressForUse()
+	e, _ := tail.ZL.Get(int(tail.Count) - 1)
+	tail.recompressOnly()
+
+	// push first, so that a single node is not freed and allocated again
+	q.PushHead(e)
+	tail = q.Tail.Prev
+	q.deleteAt(tail, int(tail.Count)-1)
+}
+
+// Range returns the elements from index start to index stop (both inclusive).
+// Negative indices count from the tail, and out-of-range indices are clamped,
+// the same way LRANGE does.
+func (q *QuickList) Range(start, stop int) []interface{} {
+	start, stop, ok := q.normalizeRange(start, stop)
+	if !ok {
+		return nil
+	}
+
+	n := stop - start + 1
+	res := make([]interface{}, 0, n)
+	node, offset, _, _ := q.index(start)
+
+	// decode the elements of each node one after another,
+	// instead of looking up every index from the start.
+	for n > 0 {
+		node.decompressForUse()
+		for p := node.ZL.index(offset); p != -1 && n > 0; p = node.ZL.next(p) {
+			res = append(res, node.ZL.getAt(p))
+			n--
+		}
+		node.recompressOnly()
+		node = node.Next
+		offset = 0
+	}
+	return res
+}
+
+// Trim keeps only the elements from index start to index stop (both inclusive),
+// and deletes the others, the same way LTRIM does.
+// Whole nodes are dropped at both ends, and only the boundary nodes are cut.
+func (q *QuickList) Trim(start, stop int) {
+	start, stop, ok := q.normalizeRange(start, stop)
+	if !ok {
+		q.DelRange(0, q.Count)
+		return
+	}
+
+	ltrim, rtrim := start, q.Count-stop-1
+	q.DelRange(0, ltrim)
+	q.DelRange(-rtrim, rtrim)
+}
+
+// normalizeRange converts the indices to non-negative ones within [0, Count).
+// It returns false if the range is empty.
+func (q *QuickList) normalizeRange(start, stop int) (int, int, bool) {
+	if start < 0 {
+		start += q.Count
+	}
+	if stop < 0 {
+		stop += q.Count
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= q.Count {
+		stop = q.Count - 1
+	}
+	if start > stop || start >= q.Count {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
+
+// InsertBefore inserts e before the element designated by the entry,
+// which is returned from Get or an iterator.
+func (q *QuickList) InsertBefore(entry QuickListEntry, e interface{}) error {
+	return q.insert(entry, e, false)
+}
+
+// InsertAfter inserts e after the element designated by the entry,
+// which is returned from Get or an iterator.
+func (q *QuickList) InsertAfter(entry QuickListEntry, e interface{}) error {
+	return q.insert(entry, e, true)
+}
+
+// insert inserts e next to the element designated by the entry.
+// If the node of the entry is full, e goes into a neighbour node with room,
+// or into a new node, or the node is split at the entry.
+func (q *QuickList) insert(entry QuickListEntry, e interface{}, after bool) error {
+	if q.Count == QL_MAX_SIZE {
+		return ErrExceedLimit
+	}
+	ss, ii, t := util.AssertValidType(e)
+	if t == -1 {
+		return ErrZLInvalidInput
+	}
+
+	node, offset := entry.Node, entry.Offset[1]
+	full := !node.allowInsert(ss, ii, t, q.Fill)
+	atTail := after && offset == int(node.Count)-1
+	atHead := !after && offset == 0
+
+	if !full {
+		if after {
+			offset++
+		}
+		q.nodeInsert(node, offset, ss, ii, t)
+		return nil
+	}
+
+	switch {
+	case atTail && node.Next != q.Tail && node.Next.allowInsert(ss, ii, t, q.Fill):
+		// insert at the head of the next node
+		q.nodeInsert(node.Next, 0, ss, ii, t)
+	case atHead && node.Prev != q.Head && node.Prev.allowInsert(ss, ii, t, q.Fill):
+		// insert at the tail of the previous node
+		q.nodeInsert(node.Prev, int(node.Prev.Count), ss, ii, t)
+	case q.Len == QL_MAX_LEN:
+		// the other cases add a node
+		return ErrExceedLimit
+	case atTail || atHead:
+		// the neighbour is full as well, create a new node in between
+		nn := NewQuickListNode(NewZipList())
+		q.insertNode(node, nn, after)
+		q.nodeInsert(nn, 0, ss, ii, t)
+	default:
+		// insert in the middle of a full node, split it at the offset
+		nn := q.splitNode(node, offset, after)
+		if after {
+			q.nodeInsert(nn, 0, ss, ii, t)
+		} else {
+			q.nodeInsert(nn, int(nn.Count), ss, ii, t)
+		}
+		q.mergeNodes(node)
+	}
+	return nil
+}
+
+// splitNode splits the node at the offset into two nodes.
+// If after is set, the node keeps the elements till the offset (inclusive),
+// and the new node takes the rest and is linked after it.
+// Otherwise, the new node takes the elements before the offset and is linked before it.
+func (q *QuickList) splitNode(node *QuickListNode, offset int, after bool) *QuickListNode {
+	node.decompressForUse()
+	zl := make(ZipList, len(*node.ZL))
+	copy(zl, *node.ZL)
+	nn := NewQuickListNode(&zl)
+	nn.Encoding = QL_NODE_ENCODING_RAW
+
+	if after {
+		node.ZL.DeleteRange(offset+1, int(node.Count))
+		nn.ZL.DeleteRange(0, offset+1)
+	} else {
+		node.ZL.DeleteRange(0, offset)
+		nn.ZL.DeleteRange(offset, int(node.Count))
+	}
+	node.Count = int16(node.ZL.ZLLen())
+	node.UpdateSize()
+	nn.Count = int16(nn.ZL.ZLLen())
+	nn.UpdateSize()
+
+	// the elements are only moved, so q.Count stays the same
+	q.insertNode(node, nn, after)
+	return nn
+}
+
+// nodeInsert inserts an element at the offset of the node.
+// If the offset equals the node's count, the element is added at the tail.
+func (q *QuickList) nodeInsert(node *QuickListNode, offset int, ss string, ii, t int) {
+	node.decompressForUse()
+	switch t {
+	case 0:
+		node.ZL.InsertString(offset, ss)
+	case 1:
+		node.ZL.InsertInt(offset, ii)
+	}
+	node.UpdateSize()
+	node.Count++
+	q.Count++
+	q.compress(node)
+}
+
+// insertNode links the node after (or before) old,
+// which may have to be compressed now that it is farther from the end.
+// It leaves q.Count to the caller.
+func (q *QuickList) insertNode(old, node *QuickListNode, after bool) {
+	if after {
+		node.Prev = old
+		node.Next = old.Next
+	} else {
+		node.Prev = old.Prev
+		node.Next = old
+	}
+	node.Prev.Next = node
+	node.Next.Prev = node
+
+	q.Len++
+	q.compress(old)
+}
+
+// index finds the node holding the element at the given index,
+// the offset of the element within the node, and the position of the node.
+// A negative index counts from the tail, -1 being the last element.
+// The nodes are walked from whichever end is closer.
+func (q *QuickList) index(idx int) (node *QuickListNode, offset, nodeIdx int, ok bool) {
+	if idx < 0 {
+		idx += q.Count
+	}
+	if idx < 0 || idx >= q.Count {
+		return
+	}
+
+	if idx < q.Count/2 {
+		node = q.Head.Next
+		for idx >= int(node.Count) {
+			idx -= int(node.Count)
+			node = node.Next
+			nodeIdx++
+		}
+		return node, idx, nodeIdx, true
+	}
+
+	// walk backward, with idx counting from the tail
+	idx = q.Count - 1 - idx
+	node = q.Tail.Prev
+	nodeIdx = int(q.Len) - 1
+	for idx >= int(node.Count) {
+		idx -= int(node.Count)
+		node = node.Prev
+		nodeIdx--
+	}
+	return node, int(node.Count) - 1 - idx, nodeIdx, true
+}
+
+// deleteAt deletes the element at the given offset of the node,
+// then compresses the node and merges it with its neighbours if it is left.
+func (q *QuickList) deleteAt(node *QuickListNode, offset int) {
+	node.decompressForUse()
+	if !q.delIndex(node, offset) {
+		q.compress(node)
+		q.mergeNodes(node)
+	}
+}
+
+// delIndex deletes the element at the given offset of the node,
+// which must not be compressed.
+// The node is freed if it becomes empty, in which case it returns true.
+func (q *QuickList) delIndex(node *QuickListNode, offset int) bool {
+	if node.Count == 1 {
+		q.deleteNode(node)
+		return true
+	}
+	node.ZL.Delete(offset)
+	node.Count--
+	node.UpdateSize()
+	q.Count--
+	return false
+}
+
+// Compact merges every node with its following nodes,
+// as long as the merged ziplist still meets the fill requirement.
+func (q *QuickList) Compact() {
+	node := q.Head.Next
+	for node != q.Tail && node.Next != q.Tail {
+		if q.allowMerge(node, node.Next) {
+			q.mergeInto(node, node.Next)
+		} else {
+			node = node.Next
+		}
+	}
+}
+
+// mergeNodes tries to merge the center node with the two nodes before it
+// and the two nodes after it, which is what is left to do after
+// a node is split or gets smaller.
+//
+// The attempted merges are:
+//   - (center.Prev.Prev, center.Prev)
+//   - (center.Next, center.Next.Next)
+//   - (center.Prev, center)
+//   - (center, center.Next)
+func (q *QuickList) mergeNodes(center *QuickListNode) {
+	var prev, prevPrev, next, nextNext *QuickListNode
+	if center.Prev != q.Head {
+		prev = center.Prev
+		if prev.Prev != q.Head {
+			prevPrev = prev.Prev
+		}
+	}
+	if center.Next != q.Tail {
+		next = center.Next
+		if next.Next != q.Tail {
+			nextNext = next.Next
+		}
+	}
+
+	if q.allowMerge(prevPrev, prev) {
+		q.mergeInto(prevPrev, prev)
+	}
+	if q.allowMerge(next, nextNext) {
+		q.mergeInto(next, nextNext)
+	}
+
+	target := center
+	if q.allowMerge(center.Prev, center) {
+		target = q.mergeInto(center.Prev, center)
+	}
+	if q.allowMerge(target, target.Next) {
+		q.mergeInto(target, target.Next)
+	}
+}
+
+// allowMerge reports whether the nodes a and b can be merged into one node
+// that still meets the fill requirement.
+func (q *QuickList) allowMerge(a, b *QuickListNode) bool {
+	if a == nil || b == nil || a == q.Head || b == q.Tail {
+		return false
+	}
+
+	// approximately, as the prevLen of the first entry of b may grow
+	mergedSize := int(a.ZLSize) + int(b.ZLSize) - ZL_INIT_SIZE
+
+	if quickListNodeMeetsOptimizationRequirement(mergedSize, q.Fill) {
+		return true
+	} else if mergedSize > QL_ZL_SIZE_LIMIT {
+		return false
+	} else if int(a.Count)+int(b.Count) <= int(q.Fill) {
+		return true
+	}
+	return false
+}
+
+// mergeInto moves the elements of b to the tail of a, and frees b.
+// a must be the node right before b.
+func (q *QuickList) mergeInto(a, b *QuickListNode) *QuickListNode {
+	a.decompress()
+	b.decompress()
+	a.ZL.merge(b.ZL)
+	a.Count += b.Count
+	a.UpdateSize()
+
+	// the elements are only moved, so q.Count stays the same
+	b.Count = 0
+	q.deleteNode(b)
+	q.compress(a)
+	return a
+}
+
+// deleteNode unlinks the node together with all its elements.
+func (q *QuickList) deleteNode(node *QuickListNode) {
+	node.Prev.Next = node.Next
+	node.Next.Prev = node.Prev
+	node.Prev, node.Next = nil, nil
+
+	q.Count -= int(node.Count)
+	q.Len--
+
+	// nodes that were compressed may now be within the compress depth
+	q.compressWindow(nil)
+}
+
 func (q *QuickList) InsertHeadNode() *QuickListNode {
 	node := NewQuickListNode(NewZipList())
 
@@ -197,6 +749,7 @@ func (q *QuickList) InsertHeadNode() *QuickListNode {
 	q.Head.Next = node
 
 	q.Len++
+	q.compress(nxt)
 	return node
 }
 
@@ -212,13 +765,138 @@ func (q *QuickList) InsertTailNode() *QuickListNode {
 	q.Tail.Prev = node
 
 	q.Len++
+	q.compress(pre)
 	return node
 }
 
+// QuickListIterator walks the quicklist element by element,
+// from the head to the tail, or from the tail to the head if Reverse is set.
+//
+// While the iterator is on a node, the node is kept decompressed.
+// It is compressed again once the iterator leaves it, or on Release.
+type QuickListIterator struct {
+	list    *QuickList
+	current *QuickListNode
+	nodeIdx int
+	zi      int // offset in the ziplist of the last returned element, -1 to look it up by offset
+	offset  int // index of the element within the node, negative if Reverse is set
+	start   int // where Reset restarts from
+	Reverse bool
+}
+
+// Iterator returns an iterator starting at the head,
+// or at the tail if reverse is set.
+func (q *QuickList) Iterator(reverse bool) *QuickListIterator {
+	start := 0
+	if reverse {
+		start = -1
+	}
+	return q.IteratorAt(start, reverse)
+}
+
+// IteratorAt returns an iterator starting at the given index.
+// A negative index counts from the tail, -1 being the last element.
+// If the index is out of range, the iterator is over from the start.
+func (q *QuickList) IteratorAt(idx int, reverse bool) *QuickListIterator {
+	it := &QuickListIterator{list: q, start: idx, Reverse: reverse}
+	it.Reset()
+	return it
+}
+
+// Reset moves the iterator back to where it started.
+func (it *QuickListIterator) Reset() {
+	it.Release()
+	it.zi = -1
+	node, offset, nodeIdx, ok := it.list.index(it.start)
+	if !ok {
+		it.current = nil
+		return
+	}
+	it.current, it.nodeIdx, it.offset = node, nodeIdx, offset
+	if it.Reverse {
+		it.offset -= int(node.Count)
+	}
+}
+
+// Next returns the QuickListEntry of the current element and moves the iterator on.
+// When the iteration is over, it returns nil.
+func (it *QuickListIterator) Next() interface{} {
+	for it.current != nil {
+		node := it.current
+		if it.zi == -1 {
+			node.decompressForUse()
+			it.zi = node.ZL.index(it.offset)
+		} else if it.Reverse {
+			it.zi = node.ZL.prev(it.zi)
+			it.offset--
+		} else {
+			it.zi = node.ZL.next(it.zi)
+			it.offset++
+		}
+
+		if it.zi != -1 {
+			offset := it.offset
+			if offset < 0 {
+				offset += int(node.Count)
+			}
+			return it.list.newEntry(node, it.nodeIdx, offset, it.zi)
+		}
+
+		// we ran out of elements of this node, go on to the next one
+		it.Release()
+		if it.Reverse {
+			it.moveTo(node.Prev, it.nodeIdx-1, -1)
+		} else {
+			it.moveTo(node.Next, it.nodeIdx+1, 0)
+		}
+	}
+	return nil
+}
+
+// DelEntry deletes the element of the entry last returned by Next.
+// The iterator goes on with the element that follows the deleted one.
+func (it *QuickListIterator) DelEntry(entry QuickListEntry) {
+	prev, next := entry.Node.Prev, entry.Node.Next
+	deletedNode := it.list.delIndex(entry.Node, entry.Offset[1])
+
+	// the ziplist offset is now invalid, so the element is looked up by offset.
+	// If the node is not deleted, the offset already designates the next element:
+	//  - [1, 2, 3] => delete offset 1 => [1, 3]: next element still at offset 1
+	//  - [1, 2, 3] => delete offset -1 => [1, 2]: previous element still at offset -1
+	it.zi = -1
+	if deletedNode {
+		if it.Reverse {
+			it.moveTo(prev, it.nodeIdx-1, -1)
+		} else {
+			it.moveTo(next, it.nodeIdx, 0)
+		}
+	}
+}
+
+// Release compresses the current node again if the iterator decompressed it.
+// It is needed only if the iterator is dropped before the iteration is over.
+func (it *QuickListIterator) Release() {
+	if it.current != nil {
+		it.list.compress(it.current)
+	}
+}
+
+func (it *QuickListIterator) moveTo(node *QuickListNode, nodeIdx, offset int) {
+	it.zi = -1
+	it.nodeIdx = nodeIdx
+	it.offset = offset
+	if node == it.list.Head || node == it.list.Tail {
+		it.current = nil
+	} else {
+		it.current = node
+	}
+}
+
 func NewQuickListNode(z *ZipList) *QuickListNode {
 	node := new(QuickListNode)
 	if z != nil {
 		node.ZL = z
+		node.Encoding = QL_NODE_ENCODING_RAW
 	}
 	return node
 }
@@ -227,6 +905,132 @@ func (node *QuickListNode) UpdateSize() {
 	node.ZLSize = uint32(node.ZL.ZLBytes())
 }
 
+// SetCompressDepth changes the compress depth,
+// compressing or decompressing the existing nodes accordingly.
+func (q *QuickList) SetCompressDepth(depth int) {
+	if depth > QL_MAX_COMPRESS_DEPTH {
+		depth = QL_MAX_COMPRESS_DEPTH
+	} else if depth < 0 {
+		depth = 0
+	}
+	q.Compress = uint16(depth)
+
+	var i int
+	for node := q.Head.Next; node != q.Tail; node = node.Next {
+		if depth == 0 || i < depth || i >= int(q.Len)-depth {
+			node.decompress()
+		} else {
+			node.compress()
+		}
+		i++
+	}
+}
+
+// compress compresses the node again if it was decompressed for use,
+// otherwise it makes sure that the nodes within the compress depth are not compressed,
+// and compresses the node if it is out of the compress depth.
+func (q *QuickList) compress(node *QuickListNode) {
+	if node == q.Head || node == q.Tail {
+		return
+	}
+	if node.Recompress {
+		node.compress()
+	} else {
+		q.compressWindow(node)
+	}
+}
+
+// compressWindow decompresses the nodes within the compress depth at both ends,
+// and compresses the given node (if not nil) and the first nodes out of the depth.
+func (q *QuickList) compressWindow(node *QuickListNode) {
+	// if the length is less than our compress depth (from both sides),
+	// we can't compress anything.
+	if q.Compress == 0 || q.Len < uint32(q.Compress)*2 {
+		return
+	}
+
+	forward, reverse := q.Head.Next, q.Tail.Prev
+	var inDepth bool
+	for depth := 0; depth < int(q.Compress); depth++ {
+		forward.decompress()
+		reverse.decompress()
+		if forward == node || reverse == node {
+			inDepth = true
+		}
+
+		// we passed into compress depth of opposite side of the quicklist,
+		// so there's no need to compress anything.
+		if forward == reverse || forward.Next == reverse {
+			return
+		}
+		forward, reverse = forward.Next, reverse.Prev
+	}
+
+	if node != nil && !inDepth {
+		node.compress()
+	}
+
+	// at this point, forward and reverse are one node beyond depth
+	forward.compress()
+	reverse.compress()
+}
+
+// compress compresses the ziplist of the node with LZF.
+// It returns false if the ziplist is too small or does not compress well,
+// in which case the node stays raw.
+func (node *QuickListNode) compress() bool {
+	node.Recompress = false
+	if node.Encoding == QL_NODE_ENCODING_LZF {
+		return true
+	}
+	if node.ZLSize < QL_MIN_COMPRESS_BYTES {
+		return false
+	}
+
+	buf := make([]byte, node.ZLSize)
+	n := util.LZFCompress(*node.ZL, buf)
+	if n == 0 || n+QL_MIN_COMPRESS_IMPROVE >= int(node.ZLSize) {
+		return false
+	}
+
+	node.LZF = &QuickListLZF{Compressed: append([]byte(nil), buf[:n]...)}
+	node.ZL = nil
+	node.Encoding = QL_NODE_ENCODING_LZF
+	return true
+}
+
+// decompress restores the ziplist of the node if it is compressed.
+func (node *QuickListNode) decompress() {
+	if node.Encoding != QL_NODE_ENCODING_LZF {
+		return
+	}
+
+	zl := make(ZipList, node.ZLSize)
+	if util.LZFDecompress(node.LZF.Compressed, zl) != int(node.ZLSize) {
+		panic("quicklist: corrupted LZF node")
+	}
+
+	node.ZL = &zl
+	node.LZF = nil
+	node.Encoding = QL_NODE_ENCODING_RAW
+}
+
+// decompressForUse decompresses the node to be used,
+// and marks it so that it gets compressed again afterward.
+func (node *QuickListNode) decompressForUse() {
+	if node.Encoding == QL_NODE_ENCODING_LZF {
+		node.decompress()
+		node.Recompress = true
+	}
+}
+
+// recompressOnly compresses the node again if it was decompressed for use.
+func (node *QuickListNode) recompressOnly() {
+	if node.Recompress {
+		node.compress()
+	}
+}
+
 func (node *QuickListNode) AllowInsertString(ss string, fill int16) bool {
 	var overhead int
 	size := len(ss)
@@ -285,6 +1089,13 @@ func (node *QuickListNode) AllowInsertInt(ii int, fill int16) bool {
 	return false
 }
 
+func (node *QuickListNode) allowInsert(ss string, ii, t int, fill int16) bool {
+	if t == 0 {
+		return node.AllowInsertString(ss, fill)
+	}
+	return node.AllowInsertInt(ii, fill)
+}
+
 func quickListNodeMeetsOptimizationRequirement(size int, fill int16) bool {
 	// fill not in range -1 to -5
 	if fill >= 0 {
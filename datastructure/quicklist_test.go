@@ -2,7 +2,10 @@ package datastructure
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 	"testing"
+	"time"
 )
 
 func TestQuickList_PushHead(t *testing.T) {
@@ -32,3 +35,493 @@ func TestQuickList_PushTail(t *testing.T) {
 		fmt.Println(e)
 	}
 }
+
+// quickListCheck verifies the quicklist against the expected elements,
+// as well as the bookkeeping of every node.
+func quickListCheck(t *testing.T, q *QuickList, want []interface{}) {
+	t.Helper()
+	if q.Count != len(want) {
+		t.Fatalf("Count = %d, want %d", q.Count, len(want))
+	}
+	var i int
+	var nodes uint32
+	for node := q.Head.Next; node != q.Tail; node = node.Next {
+		nodes++
+		if node.Next.Prev != node {
+			t.Fatalf("node %d has a broken link", nodes)
+		}
+		if node.Recompress {
+			t.Fatalf("node %d is left decompressed for use", nodes)
+		}
+		if q.Compress > 0 && node.Encoding == QL_NODE_ENCODING_LZF &&
+			(nodes <= uint32(q.Compress) || nodes > q.Len-uint32(q.Compress)) {
+			t.Fatalf("node %d is compressed within the compress depth", nodes)
+		}
+
+		// look into a compressed node without changing it
+		zl := node.ZL
+		if node.Encoding == QL_NODE_ENCODING_LZF {
+			c := *node
+			c.decompress()
+			zl = c.ZL
+		}
+		if node.Count == 0 || int(node.Count) != zl.ZLLen() || int(node.ZLSize) != zl.ZLBytes() {
+			t.Fatalf("node %d: Count = %d, ZLLen() = %d, ZLSize = %d, ZLBytes() = %d",
+				nodes, node.Count, zl.ZLLen(), node.ZLSize, zl.ZLBytes())
+		}
+		for j := 0; j < int(node.Count); j++ {
+			if e, _ := zl.Get(j); e != want[i] {
+				t.Fatalf("element %d = %v, want %v", i, e, want[i])
+			}
+			i++
+		}
+	}
+	if nodes != q.Len {
+		t.Fatalf("Len = %d, but there are %d nodes", q.Len, nodes)
+	}
+}
+
+func TestQuickList_PopAndDelete(t *testing.T) {
+	q := NewQuickList()
+	var want []interface{}
+	for i := 0; i < 100; i++ {
+		q.PushTail(i)
+		want = append(want, i)
+	}
+	quickListCheck(t, q, want)
+
+	if e, err := q.PopHead(); err != nil || e != 0 {
+		t.Errorf("PopHead() = %v, %v", e, err)
+	}
+	if e, err := q.PopTail(); err != nil || e != 99 {
+		t.Errorf("PopTail() = %v, %v", e, err)
+	}
+	want = want[1:99]
+	quickListCheck(t, q, want)
+
+	q.DeleteIndex(-1)
+	q.DeleteIndex(10)
+	want = append(want[:10], want[11:len(want)-1]...)
+	quickListCheck(t, q, want)
+
+	if err := q.DeleteIndex(len(want)); err != ErrInvalidIdx {
+		t.Errorf("DeleteIndex out of range err = %v", err)
+	}
+
+	if n, _ := q.DelRange(5, 20); n != 20 {
+		t.Errorf("DelRange(5, 20) = %d", n)
+	}
+	want = append(want[:5], want[25:]...)
+	quickListCheck(t, q, want)
+
+	if n, _ := q.DelRange(-3, 100); n != 3 {
+		t.Errorf("DelRange(-3, 100) = %d", n)
+	}
+	want = want[:len(want)-3]
+	quickListCheck(t, q, want)
+
+	q.ReplaceAtIndex(0, "replaced")
+	q.ReplaceAtIndex(-1, 1<<40)
+	want[0], want[len(want)-1] = "replaced", 1<<40
+	quickListCheck(t, q, want)
+
+	for len(want) > 0 {
+		e, err := q.PopTail()
+		if err != nil || e != want[len(want)-1] {
+			t.Fatalf("PopTail() = %v, %v", e, err)
+		}
+		want = want[:len(want)-1]
+	}
+	quickListCheck(t, q, want)
+	if _, err := q.PopHead(); err != ErrEmpty {
+		t.Errorf("PopHead() on an empty list err = %v", err)
+	}
+}
+
+func TestQuickList_Insert(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	q := NewQuickList()
+	var want []interface{}
+
+	for i := 0; i < 2000; i++ {
+		var e interface{} = i
+		if i%3 == 0 {
+			e = fmt.Sprint("s", i)
+		}
+		if len(want) == 0 {
+			q.PushTail(e)
+			want = append(want, e)
+			continue
+		}
+
+		idx := rand.Intn(len(want))
+		entry, err := q.Get(idx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rand.Intn(2) == 0 {
+			q.InsertBefore(entry, e)
+		} else {
+			q.InsertAfter(entry, e)
+			idx++
+		}
+		want = append(want[:idx], append([]interface{}{e}, want[idx:]...)...)
+		quickListCheck(t, q, want)
+	}
+}
+
+func TestQuickList_InsertMaxLen(t *testing.T) {
+	q := NewQuickList()
+	for i := 0; i < 4; i++ {
+		q.PushTail(i)
+	}
+	// fill is 3, so a full node is followed by a node of 1 element
+	if q.Len != 2 {
+		t.Fatalf("Len = %d", q.Len)
+	}
+	q.Len = QL_MAX_LEN
+
+	// the neighbour has room, so no node is added
+	entry, _ := q.Get(2)
+	if err := q.InsertAfter(entry, 10); err != nil {
+		t.Errorf("InsertAfter into the next node = %v", err)
+	}
+	entry, _ = q.Get(0)
+	if err := q.InsertBefore(entry, 11); err != ErrExceedLimit {
+		t.Errorf("InsertBefore that adds a node = %v", err)
+	}
+	entry, _ = q.Get(1)
+	if err := q.InsertAfter(entry, 12); err != ErrExceedLimit {
+		t.Errorf("InsertAfter that splits a node = %v", err)
+	}
+
+	q.Len = 2
+	quickListCheck(t, q, []interface{}{0, 1, 2, 10, 3})
+}
+
+func TestQuickList_Merge(t *testing.T) {
+	q := NewQuickList()
+	var want []interface{}
+	for i := 0; i < 300; i++ {
+		q.PushTail(i)
+		want = append(want, i)
+	}
+	// fill is 3, so there are 100 full nodes.
+	if q.Len != 100 {
+		t.Fatalf("Len = %d", q.Len)
+	}
+
+	// deleting 2 elements of every node leaves nodes of 1 element,
+	// which get merged back into nodes of 3 elements.
+	for i := 0; i < 100; i++ {
+		q.DeleteIndex(i + 1)
+		q.DeleteIndex(i + 1)
+		want = append(want[:i+1], want[i+3:]...)
+	}
+	quickListCheck(t, q, want)
+	if q.Len > 50 {
+		t.Errorf("Len = %d after deletion, nodes are not merged", q.Len)
+	}
+
+	// build fragmented nodes directly, then compact them.
+	q = NewQuickList()
+	want = want[:0]
+	for i := 0; i < 30; i++ {
+		node := q.InsertTailNode()
+		q.nodeInsert(node, 0, "", i, 1)
+		want = append(want, i)
+	}
+	q.Compact()
+	quickListCheck(t, q, want)
+	if q.Len != 10 {
+		t.Errorf("Len = %d after Compact", q.Len)
+	}
+}
+
+func TestQuickList_RandomOperations(t *testing.T) {
+	for _, depth := range []int{0, 1, 2} {
+		quickListRandomOperations(t, depth)
+	}
+}
+
+func quickListRandomOperations(t *testing.T, depth int) {
+	rand.Seed(time.Now().UnixNano())
+	q := NewQuickList()
+	q.Fill = -1
+	q.SetCompressDepth(depth)
+	var want []interface{}
+
+	for round := 0; round < 5000; round++ {
+		var e interface{} = rand.Intn(1000)
+		if rand.Intn(2) == 0 {
+			e = RandStringRunes(rand.Intn(200))
+		}
+		switch op := rand.Intn(6); {
+		case op == 0 || len(want) == 0:
+			q.PushHead(e)
+			want = append([]interface{}{e}, want...)
+		case op == 1:
+			q.PushTail(e)
+			want = append(want, e)
+		case op == 2:
+			idx := rand.Intn(len(want))
+			entry, _ := q.Get(idx)
+			q.InsertAfter(entry, e)
+			want = append(want[:idx+1], append([]interface{}{e}, want[idx+1:]...)...)
+		case op == 3:
+			q.PopHead()
+			want = want[1:]
+		case op == 4:
+			q.PopTail()
+			want = want[:len(want)-1]
+		case op == 5:
+			idx, count := rand.Intn(len(want)), rand.Intn(20)
+			n, _ := q.DelRange(idx, count)
+			want = append(want[:idx], want[idx+n:]...)
+		}
+		quickListCheck(t, q, want)
+	}
+}
+
+func TestQuickList_Compress(t *testing.T) {
+	q := NewQuickList()
+	q.Fill = -1
+	q.SetCompressDepth(1)
+	var want []interface{}
+	for i := 0; i < 5000; i++ {
+		e := fmt.Sprint("job-history-entry-", i%100)
+		q.PushTail(e)
+		want = append(want, e)
+	}
+	quickListCheck(t, q, want)
+
+	var compressed uint32
+	for node := q.Head.Next; node != q.Tail; node = node.Next {
+		if node.Encoding == QL_NODE_ENCODING_LZF {
+			compressed++
+			if len(node.LZF.Compressed) >= int(node.ZLSize) {
+				t.Errorf("compressed node takes %d bytes, raw %d bytes", len(node.LZF.Compressed), node.ZLSize)
+			}
+		}
+	}
+	if compressed != q.Len-2 {
+		t.Errorf("%d out of %d nodes are compressed", compressed, q.Len)
+	}
+
+	// read and write in the middle
+	for i := 0; i < 100; i++ {
+		idx := rand.Intn(len(want))
+		entry, _ := q.Get(idx)
+		var e interface{} = entry.Integer
+		if entry.IsString {
+			e = entry.String
+		}
+		if e != want[idx] {
+			t.Fatalf("Get(%d) = %v, want %v", idx, e, want[idx])
+		}
+		q.ReplaceAtIndex(idx, i)
+		want[idx] = i
+	}
+	quickListCheck(t, q, want)
+
+	q.SetCompressDepth(0)
+	for node := q.Head.Next; node != q.Tail; node = node.Next {
+		if node.Encoding != QL_NODE_ENCODING_RAW {
+			t.Fatal("nodes are still compressed with compression disabled")
+		}
+	}
+	quickListCheck(t, q, want)
+}
+
+func quickListEntryValue(entry QuickListEntry) interface{} {
+	if entry.IsString {
+		return entry.String
+	}
+	return entry.Integer
+}
+
+func TestQuickList_Get(t *testing.T) {
+	q := NewQuickList()
+	for i := 0; i < 100; i++ {
+		q.PushTail(i)
+	}
+	for i := -100; i < 100; i++ {
+		entry, err := q.Get(i)
+		want := i
+		if i < 0 {
+			want += 100
+		}
+		if err != nil || entry.Integer != want {
+			t.Errorf("Get(%d) = %v, %v", i, entry.Integer, err)
+		}
+		if other, _ := q.Get(want); other.Node != entry.Node || other.Offset != entry.Offset {
+			t.Errorf("Get(%d) and Get(%d) disagree: %v, %v", i, want, entry.Offset, other.Offset)
+		}
+	}
+	for _, i := range []int{100, -101, math.MinInt64, math.MaxInt64} {
+		if _, err := q.Get(i); err != ErrInvalidIdx {
+			t.Errorf("Get(%d) err = %v", i, err)
+		}
+	}
+}
+
+func TestQuickList_Iterator(t *testing.T) {
+	for _, depth := range []int{0, 1} {
+		q := NewQuickList()
+		q.Fill = -1
+		q.SetCompressDepth(depth)
+		var want []interface{}
+		for i := 0; i < 2000; i++ {
+			var e interface{} = i
+			if i%2 == 0 {
+				e = fmt.Sprint("element-", i)
+			}
+			q.PushTail(e)
+			want = append(want, e)
+		}
+
+		for _, start := range []int{0, 1, 999, 1999, -1, -2000, 2000, -2001} {
+			for _, reverse := range []bool{false, true} {
+				idx := start
+				if idx < 0 {
+					idx += len(want)
+				}
+				inRange := idx >= 0 && idx < len(want)
+				it := q.IteratorAt(start, reverse)
+				for e := it.Next(); e != nil; e = it.Next() {
+					if !inRange {
+						t.Fatalf("IteratorAt(%d, %v) should be over from the start", start, reverse)
+					}
+					if got := quickListEntryValue(e.(QuickListEntry)); got != want[idx] {
+						t.Fatalf("IteratorAt(%d, %v) at %d got %v, want %v", start, reverse, idx, got, want[idx])
+					}
+					if reverse {
+						idx--
+					} else {
+						idx++
+					}
+				}
+				if inRange && ((reverse && idx != -1) || (!reverse && idx != len(want))) {
+					t.Fatalf("IteratorAt(%d, %v) stopped at %d", start, reverse, idx)
+				}
+			}
+		}
+		quickListCheck(t, q, want)
+
+		// delete the integers while iterating, in both directions
+		for _, reverse := range []bool{true, false} {
+			it := q.Iterator(reverse)
+			for e := it.Next(); e != nil; e = it.Next() {
+				entry := e.(QuickListEntry)
+				if !entry.IsString && entry.Integer%4 == (map[bool]int{true: 1, false: 3})[reverse] {
+					it.DelEntry(entry)
+				}
+			}
+		}
+		var left []interface{}
+		for _, e := range want {
+			if _, ok := e.(int); !ok {
+				left = append(left, e)
+			}
+		}
+		quickListCheck(t, q, left)
+
+		// delete everything
+		it := q.Iterator(depth == 1)
+		for e := it.Next(); e != nil; e = it.Next() {
+			it.DelEntry(e.(QuickListEntry))
+		}
+		quickListCheck(t, q, nil)
+	}
+}
+
+func TestQuickList_RotateRangeTrim(t *testing.T) {
+	for _, depth := range []int{0, 1} {
+		q := NewQuickList()
+		q.Fill = -1
+		q.SetCompressDepth(depth)
+		var want []interface{}
+		for i := 0; i < 3000; i++ {
+			e := fmt.Sprint("log-", i)
+			q.PushTail(e)
+			want = append(want, e)
+		}
+
+		for i := 0; i < 10; i++ {
+			q.Rotate()
+			want = append([]interface{}{want[len(want)-1]}, want[:len(want)-1]...)
+		}
+		quickListCheck(t, q, want)
+
+		cases := [][2]int{{0, -1}, {0, 0}, {-1, -1}, {100, 2000}, {-2000, -100}, {-5000, 5}, {2990, 5000}, {10, 5}, {3000, 3001}}
+		for _, c := range cases {
+			got := q.Range(c[0], c[1])
+			start, stop, ok := q.normalizeRange(c[0], c[1])
+			if !ok {
+				if len(got) != 0 {
+					t.Errorf("Range(%d, %d) got %d elements", c[0], c[1], len(got))
+				}
+				continue
+			}
+			if len(got) != stop-start+1 {
+				t.Fatalf("Range(%d, %d) got %d elements", c[0], c[1], len(got))
+			}
+			for i := range got {
+				if got[i] != want[start+i] {
+					t.Fatalf("Range(%d, %d)[%d] = %v, want %v", c[0], c[1], i, got[i], want[start+i])
+				}
+			}
+		}
+
+		q.Trim(100, -101)
+		want = want[100 : len(want)-100]
+		quickListCheck(t, q, want)
+		q.Trim(-1000, 5000)
+		want = want[len(want)-1000:]
+		quickListCheck(t, q, want)
+		q.Trim(5, 1)
+		quickListCheck(t, q, nil)
+
+		// a single element cannot be rotated, and a single node is reused
+		q.PushTail(1)
+		q.Rotate()
+		quickListCheck(t, q, []interface{}{1})
+		q.PushTail(2)
+		q.Rotate()
+		quickListCheck(t, q, []interface{}{2, 1})
+	}
+}
+
+func TestNewQuickListWithOptions(t *testing.T) {
+	for _, fill := range []int{-5, -1, 1, 128, QL_FILL_MAX} {
+		q, err := NewQuickListWithOptions(fill, 2)
+		if err != nil || int(q.Fill) != fill || q.Compress != 2 {
+			t.Errorf("NewQuickListWithOptions(%d, 2) = %v, %v", fill, q, err)
+		}
+	}
+	for _, fill := range []int{-6, 0, QL_FILL_MAX + 1} {
+		if _, err := NewQuickListWithOptions(fill, 0); err != ErrQLInvalidFill {
+			t.Errorf("NewQuickListWithOptions(%d, 0) err = %v", fill, err)
+		}
+	}
+	for _, depth := range []int{-1, QL_MAX_COMPRESS_DEPTH + 1} {
+		if _, err := NewQuickListWithOptions(-2, depth); err != ErrQLInvalidCompressDepth {
+			t.Errorf("NewQuickListWithOptions(-2, %d) err = %v", depth, err)
+		}
+	}
+
+	// nodes of a size-limited list are limited to 4 KB
+	q, _ := NewQuickListWithOptions(-1, 1)
+	var want []interface{}
+	for i := 0; i < 1000; i++ {
+		e := fmt.Sprint("entry-", i)
+		q.PushTail(e)
+		want = append(want, e)
+	}
+	quickListCheck(t, q, want)
+	for node := q.Head.Next; node != q.Tail; node = node.Next {
+		if node.ZLSize > uint32(QLOptimizationLevel[0]) {
+			t.Errorf("node takes %d bytes", node.ZLSize)
+		}
+	}
+}
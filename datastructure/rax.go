@@ -0,0 +1,345 @@
+package datastructure
+
+import (
+	"bytes"
+)
+
+// Rax is a radix tree mapping byte-string keys to values,
+// where the edges are labelled by byte strings,
+// so that a chain of nodes with a single child is compressed into one edge.
+// Keys are kept in lexicographic order.
+//
+// Time Complexity:
+// Insert 	O(k);
+// Find 	O(k);
+// Remove 	O(k);
+// Seek 	O(k);
+// where k is the length of the key.
+type Rax struct {
+	head *raxNode
+	size int
+}
+
+type raxNode struct {
+	isKey bool
+	value interface{}
+	edges []raxEdge // sorted by the first byte of the label
+}
+
+type raxEdge struct {
+	label []byte
+	child *raxNode
+}
+
+func NewRax() *Rax {
+	r := new(Rax)
+	r.head = new(raxNode)
+	return r
+}
+
+// Size returns the number of keys.
+func (r *Rax) Size() int {
+	return r.size
+}
+
+// Insert sets the value of the key,
+// and returns false if the key already exists, whose value is overwritten.
+func (r *Rax) Insert(key []byte, value interface{}) bool {
+	n := r.head
+	for {
+		if len(key) == 0 {
+			isNew := !n.isKey
+			n.isKey = true
+			n.value = value
+			if isNew {
+				r.size++
+			}
+			return isNew
+		}
+
+		i, found := n.findEdge(key[0])
+		if !found {
+			leaf := &raxNode{isKey: true, value: value}
+			n.edges = append(n.edges, raxEdge{})
+			copy(n.edges[i+1:], n.edges[i:])
+			n.edges[i] = raxEdge{append([]byte(nil), key...), leaf}
+			r.size++
+			return true
+		}
+
+		e := &n.edges[i]
+		common := commonPrefixLen(e.label, key)
+		if common < len(e.label) {
+			// split the edge at the end of the common prefix
+			mid := &raxNode{edges: []raxEdge{{e.label[common:], e.child}}}
+			e.label = e.label[:common:common]
+			e.child = mid
+		}
+		n = e.child
+		key = key[common:]
+	}
+}
+
+// Find returns the value of the key, and false if the key does not exist.
+func (r *Rax) Find(key []byte) (interface{}, bool) {
+	n := r.head
+	for len(key) > 0 {
+		i, found := n.findEdge(key[0])
+		if !found || !bytes.HasPrefix(key, n.edges[i].label) {
+			return nil, false
+		}
+		key = key[len(n.edges[i].label):]
+		n = n.edges[i].child
+	}
+	return n.value, n.isKey
+}
+
+// Remove removes the key, and returns false if the key does not exist.
+func (r *Rax) Remove(key []byte) bool {
+	// the nodes and the indexes of the edges on the path
+	var parents []*raxNode
+	var idxs []int
+
+	n := r.head
+	for len(key) > 0 {
+		i, found := n.findEdge(key[0])
+		if !found || !bytes.HasPrefix(key, n.edges[i].label) {
+			return false
+		}
+		parents = append(parents, n)
+		idxs = append(idxs, i)
+		key = key[len(n.edges[i].label):]
+		n = n.edges[i].child
+	}
+	if !n.isKey {
+		return false
+	}
+	n.isKey = false
+	n.value = nil
+	r.size--
+
+	// remove the empty leaves upwards, then compress the node with a single child
+	for len(parents) > 0 && !n.isKey && len(n.edges) == 0 {
+		p, i := parents[len(parents)-1], idxs[len(idxs)-1]
+		p.edges = append(p.edges[:i], p.edges[i+1:]...)
+		parents, idxs = parents[:len(parents)-1], idxs[:len(idxs)-1]
+		n = p
+	}
+	if len(parents) > 0 && !n.isKey && len(n.edges) == 1 {
+		p, i := parents[len(parents)-1], idxs[len(idxs)-1]
+		e := &p.edges[i]
+		label := make([]byte, 0, len(e.label)+len(n.edges[0].label))
+		label = append(label, e.label...)
+		e.label = append(label, n.edges[0].label...)
+		e.child = n.edges[0].child
+	}
+	return true
+}
+
+// findEdge returns the index of the edge whose label starts with c,
+// or the index to insert it at if there is none.
+func (n *raxNode) findEdge(c byte) (int, bool) {
+	lo, hi := 0, len(n.edges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if n.edges[mid].label[0] < c {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, lo < len(n.edges) && n.edges[lo].label[0] == c
+}
+
+func commonPrefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+//
+// RaxIterator
+//
+
+// RaxIterator walks the keys of a Rax in order.
+// It looks each key up again from the head,
+// so the rax can be modified while iterating.
+//
+// Seek positions the iterator, then each call to Next or Prev
+// returns the sought key first, and the greater or the less keys afterwards.
+type RaxIterator struct {
+	r       *Rax
+	Key     []byte
+	Value   interface{}
+	pending bool // the sought key is yet to be returned
+	eof     bool
+}
+
+// Iterator returns an iterator of the rax, which is to be positioned by Seek.
+func (r *Rax) Iterator() *RaxIterator {
+	return &RaxIterator{r: r, eof: true}
+}
+
+// Seek positions the iterator at the first key that is op key, where op is
+// ">=", ">", "<=", "<", "==", "^" for the first key, or "$" for the last key.
+// It returns false if there is no such key.
+func (it *RaxIterator) Seek(op string, key []byte) bool {
+	var k []byte
+	var n *raxNode
+	switch op {
+	case "^":
+		k, n = first(it.r.head, nil)
+	case "$":
+		k, n = last(it.r.head, nil)
+	case ">=", ">":
+		k, n = seekGE(it.r.head, nil, key, op == ">")
+	case "<=", "<":
+		k, n = seekLE(it.r.head, nil, key, op == "<")
+	case "==":
+		if v, ok := it.r.Find(key); ok {
+			k, n = append([]byte(nil), key...), &raxNode{isKey: true, value: v}
+		}
+	}
+	it.setPosition(k, n)
+	it.pending = !it.eof
+	return !it.eof
+}
+
+// Next moves the iterator to the next key, and returns false if there is none.
+func (it *RaxIterator) Next() bool {
+	if it.pending {
+		it.pending = false
+		return true
+	}
+	if it.eof {
+		return false
+	}
+	it.setPosition(seekGE(it.r.head, nil, it.Key, true))
+	return !it.eof
+}
+
+// Prev moves the iterator to the previous key, and returns false if there is none.
+func (it *RaxIterator) Prev() bool {
+	if it.pending {
+		it.pending = false
+		return true
+	}
+	if it.eof {
+		return false
+	}
+	it.setPosition(seekLE(it.r.head, nil, it.Key, true))
+	return !it.eof
+}
+
+func (it *RaxIterator) setPosition(key []byte, n *raxNode) {
+	if n == nil {
+		it.Key, it.Value, it.eof = nil, nil, true
+		return
+	}
+	it.Key, it.Value, it.eof = key, n.value, false
+}
+
+// first returns the least key in the subtree of n, whose path is prefix.
+func first(n *raxNode, prefix []byte) ([]byte, *raxNode) {
+	for !n.isKey {
+		if len(n.edges) == 0 {
+			return nil, nil
+		}
+		prefix = concat(prefix, n.edges[0].label)
+		n = n.edges[0].child
+	}
+	return prefix, n
+}
+
+// last returns the greatest key in the subtree of n, whose path is prefix.
+func last(n *raxNode, prefix []byte) ([]byte, *raxNode) {
+	for len(n.edges) > 0 {
+		e := n.edges[len(n.edges)-1]
+		prefix = concat(prefix, e.label)
+		n = e.child
+	}
+	if !n.isKey {
+		return nil, nil
+	}
+	return prefix, n
+}
+
+// seekGE returns the least key >= target, or > target if strict,
+// in the subtree of n, whose path prefix is a prefix of target.
+func seekGE(n *raxNode, prefix, target []byte, strict bool) ([]byte, *raxNode) {
+	if len(prefix) == len(target) {
+		if n.isKey && !strict {
+			return prefix, n
+		}
+		for _, e := range n.edges {
+			if k, m := first(e.child, concat(prefix, e.label)); m != nil {
+				return k, m
+			}
+		}
+		return nil, nil
+	}
+
+	for _, e := range n.edges {
+		path := concat(prefix, e.label)
+		rest := target[len(prefix):]
+		l := len(e.label)
+		if l > len(rest) {
+			l = len(rest)
+		}
+		switch c := bytes.Compare(e.label[:l], rest[:l]); {
+		case c < 0:
+			continue
+		case c > 0 || len(e.label) > len(rest):
+			return first(e.child, path)
+		}
+		if k, m := seekGE(e.child, path, target, strict); m != nil {
+			return k, m
+		}
+	}
+	return nil, nil
+}
+
+// seekLE returns the greatest key <= target, or < target if strict,
+// in the subtree of n, whose path prefix is a prefix of target.
+func seekLE(n *raxNode, prefix, target []byte, strict bool) ([]byte, *raxNode) {
+	if len(prefix) == len(target) {
+		if n.isKey && !strict {
+			return prefix, n
+		}
+		return nil, nil
+	}
+
+	for i := len(n.edges) - 1; i >= 0; i-- {
+		e := n.edges[i]
+		path := concat(prefix, e.label)
+		rest := target[len(prefix):]
+		l := len(e.label)
+		if l > len(rest) {
+			l = len(rest)
+		}
+		switch c := bytes.Compare(e.label[:l], rest[:l]); {
+		case c > 0 || (c == 0 && len(e.label) > len(rest)):
+			continue
+		case c < 0:
+			if k, m := last(e.child, path); m != nil {
+				return k, m
+			}
+			continue
+		}
+		if k, m := seekLE(e.child, path, target, strict); m != nil {
+			return k, m
+		}
+	}
+	if n.isKey {
+		return prefix, n
+	}
+	return nil, nil
+}
+
+func concat(a, b []byte) []byte {
+	res := make([]byte, 0, len(a)+len(b))
+	res = append(res, a...)
+	return append(res, b...)
+}
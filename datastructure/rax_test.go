@@ -0,0 +1,136 @@
+package datastructure
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func raxKeys(r *Rax) []string {
+	var keys []string
+	it := r.Iterator()
+	it.Seek("^", nil)
+	for it.Next() {
+		keys = append(keys, string(it.Key))
+	}
+	return keys
+}
+
+func TestRax_InsertFindRemove(t *testing.T) {
+	r := NewRax()
+	keys := []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus", "rom", "r", ""}
+	for i, k := range keys {
+		if !r.Insert([]byte(k), i) {
+			t.Errorf("Insert(%q) = false", k)
+		}
+	}
+	if r.Insert([]byte("rom"), -1) || r.Size() != len(keys) {
+		t.Errorf("overwriting Insert, Size() = %d", r.Size())
+	}
+	if v, ok := r.Find([]byte("rom")); !ok || v != -1 {
+		t.Errorf("Find(rom) = %v, %v", v, ok)
+	}
+	for _, k := range []string{"ro", "roma", "rubicons", "x"} {
+		if _, ok := r.Find([]byte(k)); ok {
+			t.Errorf("Find(%q) found a missing key", k)
+		}
+	}
+
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	if got := fmt.Sprint(raxKeys(r)); got != fmt.Sprint(sorted) {
+		t.Errorf("keys = %v, want %v", got, sorted)
+	}
+
+	if r.Remove([]byte("roma")) || !r.Remove([]byte("rom")) || r.Remove([]byte("rom")) {
+		t.Errorf("Remove reports wrongly")
+	}
+	for _, k := range []string{"romane", "romanus", "romulus"} {
+		r.Remove([]byte(k))
+	}
+	if v, ok := r.Find([]byte("rubicon")); !ok || v != 5 || r.Size() != len(keys)-4 {
+		t.Errorf("after Remove, Find(rubicon) = %v, %v, Size() = %d", v, ok, r.Size())
+	}
+	if got := fmt.Sprint(raxKeys(r)); got != "[ r rubens ruber rubicon rubicundus]" {
+		t.Errorf("keys = %v", got)
+	}
+}
+
+func TestRax_Seek(t *testing.T) {
+	r := NewRax()
+	for _, k := range []string{"b", "ba", "bab", "c", "ca", "e"} {
+		r.Insert([]byte(k), k)
+	}
+
+	tests := []struct {
+		op, key string
+		want    string // "" for none
+	}{
+		{"^", "", "b"},
+		{"$", "", "e"},
+		{">=", "ba", "ba"},
+		{">", "ba", "bab"},
+		{">", "bab", "c"},
+		{">=", "a", "b"},
+		{">=", "bb", "c"},
+		{">", "e", ""},
+		{"<=", "bab", "bab"},
+		{"<", "bab", "ba"},
+		{"<", "c", "bab"},
+		{"<=", "d", "ca"},
+		{"<=", "z", "e"},
+		{"<", "b", ""},
+		{"==", "ca", "ca"},
+		{"==", "cb", ""},
+	}
+	for _, tt := range tests {
+		it := r.Iterator()
+		ok := it.Seek(tt.op, []byte(tt.key))
+		if ok != (tt.want != "") || ok && (!it.Next() || string(it.Key) != tt.want || it.Value != tt.want) {
+			t.Errorf("Seek(%s, %q) = %v, %q, want %q", tt.op, tt.key, ok, it.Key, tt.want)
+		}
+	}
+
+	// iterate backward, removing keys on the way
+	it := r.Iterator()
+	it.Seek("<=", []byte("c"))
+	var got []string
+	for it.Prev() {
+		got = append(got, string(it.Key))
+		r.Remove(it.Key)
+	}
+	if fmt.Sprint(got) != "[c bab ba b]" || fmt.Sprint(raxKeys(r)) != "[ca e]" {
+		t.Errorf("backward = %v, left %v", got, raxKeys(r))
+	}
+}
+
+func TestRax_Random(t *testing.T) {
+	r := NewRax()
+	m := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		k := fmt.Sprintf("%x", rand.Intn(500))
+		if rand.Intn(3) == 0 {
+			_, exists := m[k]
+			if r.Remove([]byte(k)) != exists {
+				t.Fatalf("Remove(%q) disagrees", k)
+			}
+			delete(m, k)
+		} else {
+			m[k] = i
+			r.Insert([]byte(k), i)
+		}
+	}
+
+	var want []string
+	for k, v := range m {
+		want = append(want, k)
+		if got, ok := r.Find([]byte(k)); !ok || got != v {
+			t.Fatalf("Find(%q) = %v, %v, want %d", k, got, ok, v)
+		}
+	}
+	sort.Strings(want)
+	if r.Size() != len(m) || fmt.Sprint(raxKeys(r)) != fmt.Sprint(want) {
+		t.Errorf("Size() = %d, keys differ from %d keys", r.Size(), len(m))
+	}
+}
@@ -11,6 +11,16 @@ type SkipList interface {
 	Delete(string, float64) error
 	Contains(string, float64) bool
 	GetRank(string, float64) int
+	UpdateScore(string, float64, float64) error
+	GetByRank(int) *SkipListNode
+	RangeByRank(int, int) []*SkipListNode
+	RangeByLex(*LexRangeSpec) []*SkipListNode
+	CountByLex(*LexRangeSpec) int
+	DeleteRangeByRank(int, int) []*SkipListNode
+	DeleteRangeByScore(*RangeSpec) []*SkipListNode
+	DeleteRangeByLex(*LexRangeSpec) []*SkipListNode
+	IteratorFromRank(int, bool) *SkipListIterator
+	IteratorFromScore(float64, bool) *SkipListIterator
 	Size() int
 }
 
@@ -20,7 +30,8 @@ const (
 )
 
 var (
-	ErrSLInputNotFound = errors.New("no given input has been found")
+	ErrSLInputNotFound   = errors.New("no given input has been found")
+	ErrSLInvalidLexRange = errors.New("min or max not valid string range item")
 )
 
 type SkipListImpl struct {
@@ -68,9 +79,17 @@ func (sl *SkipListImpl) Size() int {
 
 
 func (sl *SkipListImpl) Add(key string, score float64) error {
+	return sl.insert(key, score, nil)
+}
+
+// insert links a node with the given key and score into the skiplist.
+// If x is nil, a new node with a random level is created,
+// otherwise x is reused along with its levels.
+func (sl *SkipListImpl) insert(key string, score float64, x *SkipListNode) error {
 	var rank [SL_MAX_LEVEL]int
 	var update [SL_MAX_LEVEL]*SkipListNode
-	x := sl.Head
+	reuse := x
+	x = sl.Head
 
 	for i := sl.Level - 1; i >= 0; i-- {
 		if i == sl.Level-1 {
@@ -78,9 +97,6 @@ func (sl *SkipListImpl) Add(key string, score float64) error {
 		} else {
 			rank[i] = rank[i+1]
 		}
-		if x.Levels[i].Forward != nil && (x.Levels[i].Forward.Score == score && key != x.Levels[i].Forward.Key) {
-			return ErrDuplicateInput
-		}
 		for x.Levels[i].Forward != nil && (x.Levels[i].Forward.Score < score || (x.Levels[i].Forward.Score == score && strings.Compare(key, x.Levels[i].Forward.Key) > 0)) {
 
 			rank[i] += x.Levels[i].Span
@@ -89,9 +105,22 @@ func (sl *SkipListImpl) Add(key string, score float64) error {
 		update[i] = x
 	}
 
-	curLevel := randomLevel()
-	x = NewSkipListNode(key, score)
-	x.initLevels(curLevel)
+	// nodes are ordered by (score, key), so the only forbidden input
+	// is a node that is equal in both.
+	if nxt := update[0].Levels[0].Forward; nxt != nil && nxt.Score == score && nxt.Key == key {
+		return ErrDuplicateInput
+	}
+
+	var curLevel int
+	if reuse != nil {
+		x = reuse
+		curLevel = len(x.Levels)
+		x.Backward = nil
+	} else {
+		curLevel = randomLevel()
+		x = NewSkipListNode(key, score)
+		x.initLevels(curLevel)
+	}
 
 	if curLevel > sl.Level {
 		for i := sl.Level; i < curLevel; i++ {
@@ -110,7 +139,8 @@ func (sl *SkipListImpl) Add(key string, score float64) error {
 		update[i].Levels[i].Span = rank[0] - rank[i] + 1
 	}
 
-	for i := sl.Level; i < curLevel; i++ {
+	// increment span for untouched levels
+	for i := curLevel; i < sl.Level; i++ {
 		update[i].Levels[i].Span++
 	}
 
@@ -127,6 +157,37 @@ func (sl *SkipListImpl) Add(key string, score float64) error {
 	return nil
 }
 
+// UpdateScore changes the score of the node with the given key and oldScore.
+// If the node stays between its neighbours, only the score is rewritten,
+// otherwise the node is unlinked and linked back at its new position.
+func (sl *SkipListImpl) UpdateScore(key string, oldScore, newScore float64) error {
+	var update [SL_MAX_LEVEL]*SkipListNode
+	x := sl.Head
+
+	for i := sl.Level - 1; i >= 0; i-- {
+		for x.Levels[i].Forward != nil && (x.Levels[i].Forward.Score < oldScore || (x.Levels[i].Forward.Score == oldScore && strings.Compare(key, x.Levels[i].Forward.Key) > 0)) {
+			x = x.Levels[i].Forward
+		}
+		update[i] = x
+	}
+
+	x = x.Levels[0].Forward
+	if x == nil || x.Key != key || x.Score != oldScore {
+		return ErrSLInputNotFound
+	}
+
+	// the node is still in order with its neighbours, update it in place.
+	if (x.Backward == nil || skipListNodeLess(x.Backward.Key, x.Backward.Score, key, newScore)) &&
+		(x.Levels[0].Forward == nil || skipListNodeLess(key, newScore, x.Levels[0].Forward.Key, x.Levels[0].Forward.Score)) {
+		x.Score = newScore
+		return nil
+	}
+
+	sl.deleteNode(x, update)
+	x.Score = newScore
+	return sl.insert(key, newScore, x)
+}
+
 func (sl *SkipListImpl) Delete(key string, score float64) error {
 	var update [SL_MAX_LEVEL]*SkipListNode
 	x := sl.Head
@@ -172,13 +233,157 @@ func (sl *SkipListImpl) GetRank(key string, score float64) int {
 			rank += x.Levels[i].Span
 			x = x.Levels[i].Forward
 		}
-		if x.Key != "" && x.Key == key {
+		if x != sl.Head && x.Key == key && x.Score == score {
 			return rank - 1
 		}
 	}
 	return -1
 }
 
+// GetByRank returns the node at the given 0-based rank.
+// A negative rank counts from the tail, -1 being the last node.
+// If the rank is out of range, it returns nil.
+func (sl *SkipListImpl) GetByRank(rank int) *SkipListNode {
+	if rank < 0 {
+		rank += sl.Len
+	}
+	if rank < 0 || rank >= sl.Len {
+		return nil
+	}
+	return sl.getByRank(rank + 1)
+}
+
+// getByRank finds the node by its 1-based rank, using the spans.
+func (sl *SkipListImpl) getByRank(rank int) *SkipListNode {
+	var traversed int
+	x := sl.Head
+	for i := sl.Level - 1; i >= 0; i-- {
+		for x.Levels[i].Forward != nil && traversed+x.Levels[i].Span <= rank {
+			traversed += x.Levels[i].Span
+			x = x.Levels[i].Forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+	return nil
+}
+
+// RangeByRank returns the nodes from rank start to rank stop (both inclusive).
+// Negative ranks count from the tail, and out-of-range ranks are clamped,
+// the same way ZRANGE does.
+func (sl *SkipListImpl) RangeByRank(start, stop int) []*SkipListNode {
+	start, stop, ok := sl.normalizeRankRange(start, stop)
+	if !ok {
+		return nil
+	}
+	res := make([]*SkipListNode, 0, stop-start+1)
+	x := sl.getByRank(start + 1)
+	for i := start; i <= stop; i++ {
+		res = append(res, x)
+		x = x.Levels[0].Forward
+	}
+	return res
+}
+
+// normalizeRankRange converts the ranks to non-negative ones within [0, Len).
+// It returns false if the range is empty.
+func (sl *SkipListImpl) normalizeRankRange(start, stop int) (int, int, bool) {
+	if start < 0 {
+		start += sl.Len
+	}
+	if stop < 0 {
+		stop += sl.Len
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= sl.Len {
+		stop = sl.Len - 1
+	}
+	if start > stop || start >= sl.Len {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
+
+// DeleteRangeByRank deletes the nodes from rank start to rank stop (both inclusive),
+// and returns the deleted nodes. Ranks are handled the same way RangeByRank does.
+func (sl *SkipListImpl) DeleteRangeByRank(start, stop int) []*SkipListNode {
+	start, stop, ok := sl.normalizeRankRange(start, stop)
+	if !ok {
+		return nil
+	}
+
+	var update [SL_MAX_LEVEL]*SkipListNode
+	var traversed int
+	x := sl.Head
+	for i := sl.Level - 1; i >= 0; i-- {
+		for x.Levels[i].Forward != nil && traversed+x.Levels[i].Span <= start {
+			traversed += x.Levels[i].Span
+			x = x.Levels[i].Forward
+		}
+		update[i] = x
+	}
+
+	removed := make([]*SkipListNode, 0, stop-start+1)
+	x = x.Levels[0].Forward
+	for i := start; i <= stop; i++ {
+		next := x.Levels[0].Forward
+		sl.deleteNode(x, update)
+		removed = append(removed, x)
+		x = next
+	}
+	return removed
+}
+
+// DeleteRangeByScore deletes the nodes whose scores are within the range,
+// and returns the deleted nodes.
+func (sl *SkipListImpl) DeleteRangeByScore(spec *RangeSpec) []*SkipListNode {
+	var update [SL_MAX_LEVEL]*SkipListNode
+	x := sl.Head
+	for i := sl.Level - 1; i >= 0; i-- {
+		for x.Levels[i].Forward != nil && !spec.gteMin(x.Levels[i].Forward.Score) {
+			x = x.Levels[i].Forward
+		}
+		update[i] = x
+	}
+
+	var removed []*SkipListNode
+	x = x.Levels[0].Forward
+	for x != nil && spec.lteMax(x.Score) {
+		next := x.Levels[0].Forward
+		sl.deleteNode(x, update)
+		removed = append(removed, x)
+		x = next
+	}
+	return removed
+}
+
+// DeleteRangeByLex deletes the nodes whose keys are within the range,
+// and returns the deleted nodes.
+// It only makes sense when all the nodes have the same score.
+func (sl *SkipListImpl) DeleteRangeByLex(spec *LexRangeSpec) []*SkipListNode {
+	var update [SL_MAX_LEVEL]*SkipListNode
+	x := sl.Head
+	for i := sl.Level - 1; i >= 0; i-- {
+		for x.Levels[i].Forward != nil && !spec.gteMin(x.Levels[i].Forward.Key) {
+			x = x.Levels[i].Forward
+		}
+		update[i] = x
+	}
+
+	var removed []*SkipListNode
+	x = x.Levels[0].Forward
+	for x != nil && spec.lteMax(x.Key) {
+		next := x.Levels[0].Forward
+		sl.deleteNode(x, update)
+		removed = append(removed, x)
+		x = next
+	}
+	return removed
+}
+
 func (sl *SkipListImpl) deleteNode(x *SkipListNode, update [SL_MAX_LEVEL]*SkipListNode) {
 
 	for i := 0; i < sl.Level; i++ {
@@ -204,6 +409,299 @@ func (sl *SkipListImpl) deleteNode(x *SkipListNode, update [SL_MAX_LEVEL]*SkipLi
 	sl.Len--
 }
 
+// RangeSpec is a range of scores, as given to ZRANGEBYSCORE and friends.
+type RangeSpec struct {
+	Min, Max     float64
+	MinEx, MaxEx bool // whether the bound is exclusive
+}
+
+// gteMin reports whether score is greater than (or equal to) the lower bound.
+func (spec *RangeSpec) gteMin(score float64) bool {
+	if spec.MinEx {
+		return score > spec.Min
+	}
+	return score >= spec.Min
+}
+
+// lteMax reports whether score is less than (or equal to) the upper bound.
+func (spec *RangeSpec) lteMax(score float64) bool {
+	if spec.MaxEx {
+		return score < spec.Max
+	}
+	return score <= spec.Max
+}
+
+// Contains reports whether score is within the range.
+func (spec *RangeSpec) Contains(score float64) bool {
+	return spec.gteMin(score) && spec.lteMax(score)
+}
+
+// LexRangeSpec is a lexicographic range of keys,
+// as given to ZRANGEBYLEX and friends.
+//
+// A bound is either a key prefixed by "[" (inclusive) or "(" (exclusive),
+// or one of the special bounds "-" and "+",
+// which stand for the smallest and the largest possible key.
+type LexRangeSpec struct {
+	Min, Max     string
+	MinEx, MaxEx bool // whether the bound is exclusive
+	MinInf       int  // -1 if Min is "-", 1 if Min is "+", 0 otherwise
+	MaxInf       int  // -1 if Max is "-", 1 if Max is "+", 0 otherwise
+}
+
+// ParseLexRange parses the Redis-style bounds min and max into a LexRangeSpec.
+func ParseLexRange(min, max string) (*LexRangeSpec, error) {
+	spec := new(LexRangeSpec)
+	var err error
+	if spec.Min, spec.MinEx, spec.MinInf, err = parseLexRangeItem(min); err != nil {
+		return nil, err
+	}
+	if spec.Max, spec.MaxEx, spec.MaxInf, err = parseLexRangeItem(max); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func parseLexRangeItem(item string) (key string, ex bool, inf int, err error) {
+	if len(item) == 0 {
+		err = ErrSLInvalidLexRange
+		return
+	}
+	switch item[0] {
+	case '+':
+		if len(item) != 1 {
+			err = ErrSLInvalidLexRange
+			return
+		}
+		inf = 1
+	case '-':
+		if len(item) != 1 {
+			err = ErrSLInvalidLexRange
+			return
+		}
+		inf = -1
+	case '(':
+		key = item[1:]
+		ex = true
+	case '[':
+		key = item[1:]
+	default:
+		err = ErrSLInvalidLexRange
+	}
+	return
+}
+
+// compareLex compares key against a bound of the range,
+// where inf tells whether the bound is "-" or "+".
+func compareLex(key, bound string, inf int) int {
+	if inf != 0 {
+		return -inf
+	}
+	return strings.Compare(key, bound)
+}
+
+// gteMin reports whether key is greater than (or equal to) the lower bound.
+func (spec *LexRangeSpec) gteMin(key string) bool {
+	if spec.MinEx {
+		return compareLex(key, spec.Min, spec.MinInf) > 0
+	}
+	return compareLex(key, spec.Min, spec.MinInf) >= 0
+}
+
+// lteMax reports whether key is less than (or equal to) the upper bound.
+func (spec *LexRangeSpec) lteMax(key string) bool {
+	if spec.MaxEx {
+		return compareLex(key, spec.Max, spec.MaxInf) < 0
+	}
+	return compareLex(key, spec.Max, spec.MaxInf) <= 0
+}
+
+// Contains reports whether key is within the range.
+func (spec *LexRangeSpec) Contains(key string) bool {
+	return spec.gteMin(key) && spec.lteMax(key)
+}
+
+// isEmpty reports whether no key can ever fall in the range.
+func (spec *LexRangeSpec) isEmpty() bool {
+	if spec.MinInf == 1 || spec.MaxInf == -1 {
+		return true
+	}
+	if spec.MinInf == -1 || spec.MaxInf == 1 {
+		return false
+	}
+	cmp := strings.Compare(spec.Min, spec.Max)
+	return cmp > 0 || (cmp == 0 && (spec.MinEx || spec.MaxEx))
+}
+
+// isInLexRange reports whether a part of the skiplist is in the range.
+func (sl *SkipListImpl) isInLexRange(spec *LexRangeSpec) bool {
+	if spec.isEmpty() {
+		return false
+	}
+	x := sl.Tail
+	if x == nil || !spec.gteMin(x.Key) {
+		return false
+	}
+	x = sl.Head.Levels[0].Forward
+	if x == nil || !spec.lteMax(x.Key) {
+		return false
+	}
+	return true
+}
+
+// firstInLexRange returns the first node in the range and its 1-based rank.
+// If there is no such a node, it returns nil.
+func (sl *SkipListImpl) firstInLexRange(spec *LexRangeSpec) (*SkipListNode, int) {
+	if !sl.isInLexRange(spec) {
+		return nil, 0
+	}
+
+	var rank int
+	x := sl.Head
+	for i := sl.Level - 1; i >= 0; i-- {
+		// go forward while *out* of range.
+		for x.Levels[i].Forward != nil && !spec.gteMin(x.Levels[i].Forward.Key) {
+			rank += x.Levels[i].Span
+			x = x.Levels[i].Forward
+		}
+	}
+
+	// this is an inner range, so the next node cannot be nil.
+	x = x.Levels[0].Forward
+	rank++
+	if !spec.lteMax(x.Key) {
+		return nil, 0
+	}
+	return x, rank
+}
+
+// lastInLexRange returns the last node in the range and its 1-based rank.
+// If there is no such a node, it returns nil.
+func (sl *SkipListImpl) lastInLexRange(spec *LexRangeSpec) (*SkipListNode, int) {
+	if !sl.isInLexRange(spec) {
+		return nil, 0
+	}
+
+	var rank int
+	x := sl.Head
+	for i := sl.Level - 1; i >= 0; i-- {
+		// go forward while *in* range.
+		for x.Levels[i].Forward != nil && spec.lteMax(x.Levels[i].Forward.Key) {
+			rank += x.Levels[i].Span
+			x = x.Levels[i].Forward
+		}
+	}
+
+	// this is an inner range, so this node cannot be the dummy head.
+	if !spec.gteMin(x.Key) {
+		return nil, 0
+	}
+	return x, rank
+}
+
+// RangeByLex returns the nodes whose keys are within the range, in order.
+// It only makes sense when all the nodes have the same score.
+func (sl *SkipListImpl) RangeByLex(spec *LexRangeSpec) []*SkipListNode {
+	var res []*SkipListNode
+	x, _ := sl.firstInLexRange(spec)
+	for x != nil && spec.lteMax(x.Key) {
+		res = append(res, x)
+		x = x.Levels[0].Forward
+	}
+	return res
+}
+
+// CountByLex returns the number of nodes whose keys are within the range.
+// It only makes sense when all the nodes have the same score.
+func (sl *SkipListImpl) CountByLex(spec *LexRangeSpec) int {
+	_, first := sl.firstInLexRange(spec)
+	if first == 0 {
+		return 0
+	}
+	_, last := sl.lastInLexRange(spec)
+	return last - first + 1
+}
+
+// SkipListIterator walks the skiplist node by node,
+// forward through Levels[0], or backward through Backward if Reverse is set.
+//
+// The iterator fetches the following node before returning the current one,
+// so the node returned by Next can be safely deleted.
+type SkipListIterator struct {
+	sl      *SkipListImpl
+	next    *SkipListNode
+	Reverse bool
+
+	// where Reset restarts from
+	fromScore bool
+	rank      int
+	score     float64
+}
+
+// IteratorFromRank returns an iterator starting at the given 0-based rank,
+// where a negative rank counts from the tail.
+func (sl *SkipListImpl) IteratorFromRank(rank int, reverse bool) *SkipListIterator {
+	it := &SkipListIterator{sl: sl, Reverse: reverse, rank: rank}
+	it.Reset()
+	return it
+}
+
+// IteratorFromScore returns an iterator starting at the first node whose score is >= score,
+// or, if reverse is set, at the last node whose score is <= score.
+func (sl *SkipListImpl) IteratorFromScore(score float64, reverse bool) *SkipListIterator {
+	it := &SkipListIterator{sl: sl, Reverse: reverse, fromScore: true, score: score}
+	it.Reset()
+	return it
+}
+
+// Reset moves the iterator back to where it started.
+func (it *SkipListIterator) Reset() {
+	if !it.fromScore {
+		it.next = it.sl.GetByRank(it.rank)
+		return
+	}
+
+	x := it.sl.Head
+	for i := it.sl.Level - 1; i >= 0; i-- {
+		for x.Levels[i].Forward != nil && (x.Levels[i].Forward.Score < it.score || (it.Reverse && x.Levels[i].Forward.Score == it.score)) {
+			x = x.Levels[i].Forward
+		}
+	}
+	if it.Reverse {
+		if x == it.sl.Head {
+			x = nil
+		}
+		it.next = x
+	} else {
+		it.next = x.Levels[0].Forward
+	}
+}
+
+// Next returns the current *SkipListNode and moves the iterator on.
+// When the iteration is over, it returns nil.
+func (it *SkipListIterator) Next() interface{} {
+	x := it.next
+	if x == nil {
+		return nil
+	}
+	if it.Reverse {
+		it.next = x.Backward
+	} else {
+		it.next = x.Levels[0].Forward
+	}
+	return x
+}
+
+// HasNext reports whether Next would return a node.
+func (it *SkipListIterator) HasNext() bool {
+	return it.next != nil
+}
+
+// skipListNodeLess reports whether (key1, score1) goes before (key2, score2).
+func skipListNodeLess(key1 string, score1 float64, key2 string, score2 float64) bool {
+	return score1 < score2 || (score1 == score2 && strings.Compare(key1, key2) < 0)
+}
+
 func randomLevel() int {
 	level := 1
 	for rand.Float64() < SL_PROBABILITY && level < SL_MAX_LEVEL {
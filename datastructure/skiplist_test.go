@@ -44,3 +44,219 @@ func RandStringRunes(n int) string {
     }
     return string(b)
 }
+
+func TestSkipList_RangeByLex(t *testing.T) {
+	list := NewSkipList()
+	keys := []string{"a", "b", "c", "d", "e", "f", "g"}
+	for _, k := range keys {
+		list.Add(k, 0)
+	}
+
+	cases := []struct {
+		min, max string
+		want     []string
+	}{
+		{"-", "+", keys},
+		{"[b", "[d", []string{"b", "c", "d"}},
+		{"(b", "(d", []string{"c"}},
+		{"[c", "+", []string{"c", "d", "e", "f", "g"}},
+		{"-", "(c", []string{"a", "b"}},
+		{"(g", "+", nil},
+		{"[d", "[b", nil},
+		{"+", "-", nil},
+		{"[aa", "[bb", []string{"b"}},
+	}
+
+	for _, c := range cases {
+		spec, err := ParseLexRange(c.min, c.max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes := list.RangeByLex(spec)
+		if len(nodes) != len(c.want) {
+			t.Fatalf("RangeByLex(%s, %s) got %d nodes, want %d", c.min, c.max, len(nodes), len(c.want))
+		}
+		for i := range nodes {
+			if nodes[i].Key != c.want[i] {
+				t.Errorf("RangeByLex(%s, %s)[%d] = %s, want %s", c.min, c.max, i, nodes[i].Key, c.want[i])
+			}
+		}
+		if n := list.CountByLex(spec); n != len(c.want) {
+			t.Errorf("CountByLex(%s, %s) = %d, want %d", c.min, c.max, n, len(c.want))
+		}
+	}
+
+	for _, bad := range [][2]string{{"a", "+"}, {"-", "b"}, {"", "+"}, {"-x", "+"}} {
+		if _, err := ParseLexRange(bad[0], bad[1]); err != ErrSLInvalidLexRange {
+			t.Errorf("ParseLexRange(%q, %q) err = %v", bad[0], bad[1], err)
+		}
+	}
+}
+
+func newSkipListForRank(n int) SkipList {
+	list := NewSkipList()
+	for i := 0; i < n; i++ {
+		list.Add(string(rune('a'+i)), float64(i))
+	}
+	return list
+}
+
+func skipListNodeKeys(nodes []*SkipListNode) string {
+	var keys string
+	for _, node := range nodes {
+		keys += node.Key
+	}
+	return keys
+}
+
+func TestSkipList_GetByRank(t *testing.T) {
+	list := newSkipListForRank(26)
+	for i := 0; i < 26; i++ {
+		if node := list.GetByRank(i); node == nil || node.Key != string(rune('a'+i)) {
+			t.Fatalf("GetByRank(%d) = %v", i, node)
+		}
+		if node := list.GetByRank(i - 26); node == nil || node.Key != string(rune('a'+i)) {
+			t.Fatalf("GetByRank(%d) = %v", i-26, node)
+		}
+	}
+	if list.GetByRank(26) != nil || list.GetByRank(-27) != nil {
+		t.Error("GetByRank out of range should return nil")
+	}
+
+	cases := []struct {
+		start, stop int
+		want        string
+	}{
+		{0, -1, "abcdefghijklmnopqrstuvwxyz"},
+		{0, 2, "abc"},
+		{-3, -1, "xyz"},
+		{-100, 1, "ab"},
+		{24, 100, "yz"},
+		{5, 3, ""},
+		{26, 30, ""},
+	}
+	for _, c := range cases {
+		if got := skipListNodeKeys(list.RangeByRank(c.start, c.stop)); got != c.want {
+			t.Errorf("RangeByRank(%d, %d) = %s, want %s", c.start, c.stop, got, c.want)
+		}
+	}
+}
+
+func TestSkipList_DeleteRange(t *testing.T) {
+	list := newSkipListForRank(10)
+	if got := skipListNodeKeys(list.DeleteRangeByRank(-2, -1)); got != "ij" {
+		t.Errorf("DeleteRangeByRank(-2, -1) = %s", got)
+	}
+	if got := skipListNodeKeys(list.DeleteRangeByScore(&RangeSpec{Min: 1, Max: 3, MaxEx: true})); got != "bc" {
+		t.Errorf("DeleteRangeByScore([1, 3)) = %s", got)
+	}
+	spec, _ := ParseLexRange("(d", "[g")
+	if got := skipListNodeKeys(list.DeleteRangeByLex(spec)); got != "efg" {
+		t.Errorf("DeleteRangeByLex((d, [g) = %s", got)
+	}
+	if got := skipListNodeKeys(list.RangeByRank(0, -1)); got != "adh" {
+		t.Errorf("remaining nodes = %s", got)
+	}
+	if list.Size() != 3 {
+		t.Errorf("Size() = %d", list.Size())
+	}
+	for i, k := range []string{"a", "d", "h"} {
+		if node := list.GetByRank(i); node == nil || node.Key != k {
+			t.Errorf("GetByRank(%d) = %v, want %s", i, node, k)
+		}
+	}
+}
+
+func TestSkipList_UpdateScore(t *testing.T) {
+	list := NewSkipList()
+	scores := make(map[string]float64)
+	for _, i := range rand.Perm(200) {
+		k := fmt.Sprintf("k%03d", i)
+		scores[k] = float64(i)
+		list.Add(k, float64(i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		k := fmt.Sprintf("k%03d", rand.Intn(200))
+		newScore := scores[k] + rand.Float64()*20 - 10
+		if err := list.UpdateScore(k, scores[k], newScore); err != nil {
+			t.Fatalf("UpdateScore(%s) err = %v", k, err)
+		}
+		scores[k] = newScore
+	}
+
+	if err := list.UpdateScore("k000", -1000, 0); err != ErrSLInputNotFound {
+		t.Errorf("UpdateScore with a wrong score err = %v", err)
+	}
+
+	nodes := list.RangeByRank(0, -1)
+	if len(nodes) != 200 || list.Size() != 200 {
+		t.Fatalf("got %d nodes, size %d", len(nodes), list.Size())
+	}
+	for i, node := range nodes {
+		if node.Score != scores[node.Key] {
+			t.Errorf("node %s has score %v, want %v", node.Key, node.Score, scores[node.Key])
+		}
+		if i > 0 && !skipListNodeLess(nodes[i-1].Key, nodes[i-1].Score, node.Key, node.Score) {
+			t.Errorf("node %d is out of order", i)
+		}
+		if i > 0 && node.Backward != nodes[i-1] {
+			t.Errorf("node %d has a wrong backward pointer", i)
+		}
+		if got := list.GetByRank(i); got != node {
+			t.Errorf("GetByRank(%d) = %v, want %v", i, got, node)
+		}
+	}
+}
+
+func TestSkipList_Iterator(t *testing.T) {
+	list := newSkipListForRank(10)
+
+	collect := func(it *SkipListIterator) string {
+		var keys string
+		for e := it.Next(); e != nil; e = it.Next() {
+			keys += e.(*SkipListNode).Key
+		}
+		return keys
+	}
+
+	cases := []struct {
+		it   *SkipListIterator
+		want string
+	}{
+		{list.IteratorFromRank(0, false), "abcdefghij"},
+		{list.IteratorFromRank(-1, true), "jihgfedcba"},
+		{list.IteratorFromRank(7, false), "hij"},
+		{list.IteratorFromRank(2, true), "cba"},
+		{list.IteratorFromRank(10, false), ""},
+		{list.IteratorFromScore(3, false), "defghij"},
+		{list.IteratorFromScore(3.5, false), "efghij"},
+		{list.IteratorFromScore(3, true), "dcba"},
+		{list.IteratorFromScore(3.5, true), "dcba"},
+		{list.IteratorFromScore(-1, true), ""},
+		{list.IteratorFromScore(100, false), ""},
+	}
+	for i, c := range cases {
+		if got := collect(c.it); got != c.want {
+			t.Errorf("case %d: got %s, want %s", i, got, c.want)
+		}
+		c.it.Reset()
+		if got := collect(c.it); got != c.want {
+			t.Errorf("case %d after Reset: got %s, want %s", i, got, c.want)
+		}
+	}
+
+	// delete every other node while iterating backward.
+	it := list.IteratorFromRank(-1, true)
+	var i int
+	for e := it.Next(); e != nil; e = it.Next() {
+		node := e.(*SkipListNode)
+		if i%2 == 0 {
+			list.Delete(node.Key, node.Score)
+		}
+		i++
+	}
+	if got := collect(list.IteratorFromRank(0, false)); got != "acegi" {
+		t.Errorf("after deletion got %s", got)
+	}
+}
@@ -14,6 +14,8 @@ import (
 // Add 		O(1);
 // Get 		O(n);
 // Find 	O(mn);
+// Insert 	O(n), O(n^2) at worst because of the cascade update;
+// Delete 	O(n), O(n^2) at worst because of the cascade update;
 //
 // Size:
 // 1. ZipList uses uint32 to store the number of bytes it occupies,
@@ -27,6 +29,7 @@ const (
 
 	ZL_TAIL_OFFSET  = 4
 	ZL_ZLLEN_OFFSET = 8
+	ZL_HEADER_SIZE  = 10
 	ZL_INIT_SIZE    = 11
 	ZL_END          = 0xFF
 	ZL_BIG_PREVLEN  = 0xFE
@@ -57,8 +60,6 @@ var (
 	ErrZLEntryExceedLimit = errors.New("input data is too large")
 )
 
-// Future: supports deletion and cascadeUpdate
-
 func NewZipList() *ZipList {
 	z := new(ZipList)
 	*z = append(*z, make([]byte, 11)...)
@@ -112,17 +113,195 @@ func (z *ZipList) Get(idx int) (interface{}, error) {
 			e := z.newZipListEntry(p)
 			p -= e.PrevRawLen
 		}
+		return z.getAt(p), nil
+	}
+}
+
+// InsertInt inserts an integer before the entry at the given index.
+// If idx equals the length of the ziplist, the integer is added at the tail.
+func (z *ZipList) InsertInt(idx, i int) error {
+	return z.insertByIndex(idx, i)
+}
+
+// InsertString inserts a string before the entry at the given index.
+// If idx equals the length of the ziplist, the string is added at the tail.
+func (z *ZipList) InsertString(idx int, s string) error {
+	return z.insertByIndex(idx, []byte(s))
+}
+
+// ReplaceInt replaces the entry at the given index with an integer.
+// A negative index counts from the tail, -1 being the last entry.
+func (z *ZipList) ReplaceInt(idx, i int) error {
+	return z.replaceByIndex(idx, i)
+}
+
+// ReplaceString replaces the entry at the given index with a string.
+// A negative index counts from the tail, -1 being the last entry.
+func (z *ZipList) ReplaceString(idx int, s string) error {
+	return z.replaceByIndex(idx, []byte(s))
+}
+
+func (z *ZipList) replaceByIndex(idx int, e interface{}) error {
+	p := z.index(idx)
+	if p == -1 {
+		return ErrInvalidIdx
+	}
+	return z.replaceAt(p, e)
+}
+
+// Range returns the elements from index start to index stop (both inclusive).
+// Negative indices count from the tail, and out-of-range indices are clamped.
+func (z *ZipList) Range(start, stop int) []interface{} {
+	l := z.ZLLen()
+	if start < 0 {
+		start += l
+	}
+	if stop < 0 {
+		stop += l
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= l {
+		stop = l - 1
+	}
+	if start > stop {
+		return nil
+	}
+
+	res := make([]interface{}, 0, stop-start+1)
+	for p := z.index(start); p != -1 && len(res) < cap(res); p = z.next(p) {
+		res = append(res, z.getAt(p))
+	}
+	return res
+}
+
+// Find searches from the head for the first entry that equals the string s,
+// comparing only one entry out of every skip+1 entries,
+// and returns its index, or -1 if there is no such an entry.
+// An integer entry equals s if s is the canonical form of the integer.
+//
+// A skip of 1 searches the keys of a ziplist of key-value pairs.
+func (z *ZipList) Find(s string, skip int) int {
+	ii, isInt := util.StringToInt64([]byte(s))
+	idx := 0
+	for p := z.index(0); p != -1; idx++ {
 		e := z.newZipListEntry(p)
 		if e.Encoding < ZL_STR_MASK {
-			return string(z.loadString(p+int(e.HeaderSize), e.Len)), nil
-		} else if e.Encoding >= ZL_INT_IMM_MIN && e.Encoding <= ZL_INT_IMM_MAX {
-			return int(e.Encoding - ZL_INT_IMM_MIN), nil
-		} else {
-			return z.loadInteger(p+int(e.HeaderSize), e.Len), nil
+			if string(z.loadString(p+int(e.HeaderSize), e.Len)) == s {
+				return idx
+			}
+		} else if isInt && z.getAt(p) == int(ii) {
+			return idx
 		}
+		for i := 0; i <= skip && p != -1; i++ {
+			p = z.next(p)
+		}
+		idx += skip
+	}
+	return -1
+}
+
+func (z *ZipList) insertByIndex(idx int, e interface{}) error {
+	l := z.ZLLen()
+	if l == ZL_MAX_LEN {
+		return ErrExceedLimit
+	} else if idx < 0 || idx > l {
+		return ErrInvalidIdx
+	}
+	p := len(*z) - 1
+	if idx < l {
+		p = z.index(idx)
+	}
+	return z.insertAt(p, e)
+}
+
+// Delete deletes the entry at the given index.
+// A negative index counts from the tail, -1 being the last entry.
+func (z *ZipList) Delete(idx int) error {
+	return z.DeleteRange(idx, 1)
+}
+
+// DeleteRange deletes num entries starting from the given index.
+// A negative index counts from the tail, -1 being the last entry.
+func (z *ZipList) DeleteRange(idx, num int) error {
+	p := z.index(idx)
+	if p == -1 {
+		return ErrInvalidIdx
+	}
+	z.deleteAt(p, num)
+	return nil
+}
+
+// index returns the offset of the entry at the given index,
+// or -1 if the index is out of range.
+// A negative index counts from the tail, -1 being the last entry.
+func (z *ZipList) index(idx int) int {
+	l := z.ZLLen()
+	if idx >= l || idx < -l {
+		return -1
+	}
+	if idx < 0 {
+		p := z.ZLTail()
+		for i := -1; i > idx; i-- {
+			p -= z.newZipListEntry(p).PrevRawLen
+		}
+		return p
+	}
+	p := ZL_HEADER_SIZE
+	for i := 0; i < idx; i++ {
+		p += z.rawEntryLength(p)
+	}
+	return p
+}
+
+// next returns the offset of the entry after the one at p,
+// or -1 if there is no such an entry.
+func (z *ZipList) next(p int) int {
+	if (*z)[p] == ZL_END {
+		return -1
+	}
+	p += z.rawEntryLength(p)
+	if (*z)[p] == ZL_END {
+		return -1
+	}
+	return p
+}
+
+// prev returns the offset of the entry before the one at p,
+// or -1 if there is no such an entry.
+// If p points to ZL_END, it returns the offset of the last entry.
+func (z *ZipList) prev(p int) int {
+	if (*z)[p] == ZL_END {
+		if t := z.ZLTail(); (*z)[t] != ZL_END {
+			return t
+		}
+		return -1
+	}
+	if p == ZL_HEADER_SIZE {
+		return -1
+	}
+	return p - z.newZipListEntry(p).PrevRawLen
+}
+
+// getAt returns the element at the given offset, either a string or an int.
+func (z *ZipList) getAt(p int) interface{} {
+	e := z.newZipListEntry(p)
+	if e.Encoding < ZL_STR_MASK {
+		return string(z.loadString(p+int(e.HeaderSize), e.Len))
+	} else if e.Encoding >= ZL_INT_IMM_MIN && e.Encoding <= ZL_INT_IMM_MAX {
+		return int(e.Encoding - ZL_INT_IMM_MIN)
+	} else {
+		return z.loadInteger(p+int(e.HeaderSize), e.Len)
 	}
 }
 
+// rawEntryLength returns the number of bytes the entry at p takes.
+func (z *ZipList) rawEntryLength(p int) int {
+	e := z.newZipListEntry(p)
+	return int(e.HeaderSize) + e.Len
+}
+
 // FindInt searches for the position of the given integer in the ziplist and returns the index.
 // If the ziplist does not contain the given element, it returns -1.
 func (z *ZipList) FindInt(ii int) int {
@@ -169,6 +348,10 @@ func (z *ZipList) updateZLLen() {
 	util.UI16ToB(uint16(z.ZLLen() + 1), *z, ZL_ZLLEN_OFFSET)
 }
 
+func (z *ZipList) setZLLen(l int) {
+	util.UI16ToB(uint16(l), *z, ZL_ZLLEN_OFFSET)
+}
+
 func (z *ZipList) updateZLBytes() {
 	util.UI32ToB(uint32(len(*z)), *z, 0)
 }
@@ -192,6 +375,20 @@ func (z *ZipList) storePrevEntryLength(p, prevLen int) (reqLen int) {
 	return
 }
 
+// storePrevEntryLengthLarge stores prevLen using 5 bytes,
+// even if 1 byte would be enough.
+func (z *ZipList) storePrevEntryLengthLarge(p, prevLen int) {
+	(*z)[p] = ZL_BIG_PREVLEN
+	util.UI32ToB(uint32(prevLen), *z, p+1)
+}
+
+// prevLenByteDiff returns the difference in bytes between encoding prevLen
+// and the prevLen currently encoded in the entry at p.
+func (z *ZipList) prevLenByteDiff(p, prevLen int) int {
+	prevLenSize, _ := z.decodePrevLen(p)
+	return z.storePrevEntryLength(-1, prevLen) - int(prevLenSize)
+}
+
 func (z *ZipList) storeEntryStringEncoding(p int, s []byte) (encoding uint8, reqLen int) {
 	reqLen = 1
 	rawLen := len(s)
@@ -286,19 +483,24 @@ func (z *ZipList) loadString(p, len int) []byte {
 }
 
 // zipListInsert inserts the element at the tail.
-// This is different from Redis implementation as Redis supports insertion at given index other than the tail.
 func (z *ZipList) zipListInsert(e interface{}) error {
-	var curLen, reqLen, newLen, prevLen int
+	return z.insertAt(len(*z)-1, e)
+}
 
-	curLen = len(*z)
+// insertAt inserts the element, either []byte or int, before the entry at p.
+// If p points to ZL_END, the element is inserted at the tail.
+func (z *ZipList) insertAt(p int, e interface{}) error {
+	var reqLen, prevLen, nextDiff int
+	var forceLarge bool
 
-	// insertion position and tail position
-	p, pt := len(*z)-1, z.ZLTail()
+	curLen, tail := len(*z), z.ZLTail()
+	atEnd := (*z)[p] == ZL_END
 
-	// if the ziplist is not empty
-	if (*z)[pt] != ZL_END {
-		pte := z.newZipListEntry(pt)
-		prevLen = pte.Len + int(pte.HeaderSize)
+	// find out the length of the entry before the insertion position
+	if !atEnd {
+		prevLen = z.newZipListEntry(p).PrevRawLen
+	} else if (*z)[tail] != ZL_END {
+		prevLen = z.rawEntryLength(tail)
 	}
 
 	// calculate required length for this entry, and determine the encoding byte
@@ -314,6 +516,9 @@ func (z *ZipList) zipListInsert(e interface{}) error {
 		reqLen += intSizeByEncoding(encoding)
 		reqLen += add
 	}
+	if !ok1 && !ok2 {
+		return ErrZLInvalidInput
+	}
 
 	add1 := z.storePrevEntryLength(-1, prevLen)
 	reqLen += add1
@@ -322,12 +527,35 @@ func (z *ZipList) zipListInsert(e interface{}) error {
 		return ErrZLEntryExceedLimit
 	}
 
-	// resize and update ZLBytes, ZLTail
-	newLen = curLen + reqLen
-	*z = append(*z, make([]byte, reqLen)...)
-	(*z)[newLen-1] = ZL_END
-	z.updateZLBytes()
-	z.updateZLTail(p)
+	// the next entry has to be able to hold the length of this entry as its prevLen.
+	// We never shrink it, otherwise it may cause another cascade update.
+	if !atEnd {
+		nextDiff = z.prevLenByteDiff(p, reqLen)
+		if nextDiff < 0 {
+			nextDiff = 0
+			forceLarge = true
+		}
+	}
+
+	// resize and move the following entries (and ZL_END) backward,
+	// leaving nextDiff more bytes for the prevLen of the next entry.
+	*z = append(*z, make([]byte, reqLen+nextDiff)...)
+	copy((*z)[p+reqLen+nextDiff:], (*z)[p:curLen])
+
+	if !atEnd {
+		if forceLarge {
+			z.storePrevEntryLengthLarge(p+reqLen, reqLen)
+		} else {
+			z.storePrevEntryLength(p+reqLen, reqLen)
+		}
+		if p == tail {
+			tail = p + reqLen
+		} else {
+			tail += reqLen + nextDiff
+		}
+	} else {
+		tail = p
+	}
 
 	z.storePrevEntryLength(p, prevLen)
 	if ok1 {
@@ -339,11 +567,144 @@ func (z *ZipList) zipListInsert(e interface{}) error {
 		z.storeInteger(p+add1+add2, i)
 	}
 
-	// update ZLLen
+	// update ZLBytes, ZLTail and ZLLen
+	z.updateZLBytes()
+	z.updateZLTail(tail)
 	z.updateZLLen()
+
+	if nextDiff != 0 {
+		z.cascadeUpdate(p + reqLen)
+	}
 	return nil
 }
 
+// deleteAt deletes num entries starting from the entry at p,
+// and returns the number of deleted entries.
+func (z *ZipList) deleteAt(p, num int) int {
+	var deleted, nextDiff int
+	q := p
+	for deleted < num && (*z)[q] != ZL_END {
+		q += z.rawEntryLength(q)
+		deleted++
+	}
+	if deleted == 0 {
+		return 0
+	}
+
+	first := z.newZipListEntry(p)
+	tail := z.ZLTail()
+
+	if (*z)[q] != ZL_END {
+		// the next entry now follows the entry before the first deleted one.
+		// We never shrink it, otherwise it may cause another cascade update.
+		nextDiff = z.prevLenByteDiff(q, first.PrevRawLen)
+		if nextDiff < 0 {
+			nextDiff = 0
+			z.storePrevEntryLengthLarge(q, first.PrevRawLen)
+		} else {
+			q -= nextDiff
+			z.storePrevEntryLength(q, first.PrevRawLen)
+		}
+
+		if q+nextDiff == tail {
+			tail = p
+		} else {
+			tail -= q - p
+		}
+	} else {
+		// the entries are deleted till the end.
+		tail = p - first.PrevRawLen
+	}
+
+	copy((*z)[p:], (*z)[q:])
+	*z = (*z)[:len(*z)-(q-p)]
+
+	z.updateZLBytes()
+	z.updateZLTail(tail)
+	z.setZLLen(z.ZLLen() - deleted)
+
+	if nextDiff != 0 {
+		z.cascadeUpdate(p)
+	}
+	return deleted
+}
+
+// merge appends all the entries of other to the ziplist.
+func (z *ZipList) merge(other *ZipList) {
+	if other.ZLLen() == 0 {
+		return
+	}
+	if z.ZLLen() == 0 {
+		*z = append((*z)[:0], *other...)
+		return
+	}
+
+	// the first entry of other has a prevLen of 0,
+	// it is fixed by the cascade update once the entries are appended.
+	tail, curLen := z.ZLTail(), len(*z)
+	l := z.ZLLen() + other.ZLLen()
+	*z = append((*z)[:curLen-1], (*other)[ZL_HEADER_SIZE:]...)
+
+	z.updateZLBytes()
+	z.updateZLTail(curLen - 1 + other.ZLTail() - ZL_HEADER_SIZE)
+	z.setZLLen(l)
+	z.cascadeUpdate(tail)
+}
+
+// replaceAt replaces the entry at p with the element, either []byte or int.
+func (z *ZipList) replaceAt(p int, e interface{}) error {
+	z.deleteAt(p, 1)
+	return z.insertAt(p, e)
+}
+
+// cascadeUpdate updates the prevLen of the entries following the entry at p,
+// after the size of that entry has changed.
+// When an entry grows from less than 254 bytes to more than 254 bytes,
+// its prevLen grows from 1 byte to 5 bytes,
+// which may make the next entry grow the same way, and so on.
+func (z *ZipList) cascadeUpdate(p int) {
+	for (*z)[p] != ZL_END {
+		rawLen := z.rawEntryLength(p)
+		np := p + rawLen
+
+		// abort if there is no next entry
+		if (*z)[np] == ZL_END {
+			break
+		}
+
+		// abort when the prevLen of the next entry is up to date
+		next := z.newZipListEntry(np)
+		if next.PrevRawLen == rawLen {
+			break
+		}
+
+		rawLenSize := z.storePrevEntryLength(-1, rawLen)
+		if int(next.PrevRawLenSize) < rawLenSize {
+			// the prevLen of the next entry needs to grow.
+			curLen, tail := len(*z), z.ZLTail()
+			extra := rawLenSize - int(next.PrevRawLenSize)
+			*z = append(*z, make([]byte, extra)...)
+			copy((*z)[np+rawLenSize:], (*z)[np+int(next.PrevRawLenSize):curLen])
+			z.storePrevEntryLength(np, rawLen)
+
+			if np != tail {
+				tail += extra
+			}
+			z.updateZLBytes()
+			z.updateZLTail(tail)
+			p = np
+		} else {
+			// the prevLen of the next entry is large enough, and its size doesn't change.
+			if int(next.PrevRawLenSize) > rawLenSize {
+				z.storePrevEntryLengthLarge(np, rawLen)
+			} else {
+				z.storePrevEntryLength(np, rawLen)
+			}
+			break
+		}
+	}
+}
+
 // decodePrevLen returns prevLen and the size it takes (1 or 5).
 func (z *ZipList) decodePrevLen(p int) (prevLenSize uint8, prevLen int) {
 	if (*z)[p] < ZL_BIG_PREVLEN {
@@ -2,7 +2,11 @@ package datastructure
 
 import (
 	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestZipList_PushAndElementAt(t *testing.T) {
@@ -41,3 +45,177 @@ func TestZipList_PushAndElementAt(t *testing.T) {
 		}
 	}
 }
+
+// zipListCheck verifies the ziplist against the expected elements,
+// walking it both forward and backward.
+func zipListCheck(t *testing.T, z *ZipList, want []interface{}) {
+	t.Helper()
+	if z.ZLLen() != len(want) || z.ZLBytes() != len(*z) {
+		t.Fatalf("ZLLen() = %d, want %d, ZLBytes() = %d, len = %d", z.ZLLen(), len(want), z.ZLBytes(), len(*z))
+	}
+	var i int
+	for p := z.index(0); p != -1; p = z.next(p) {
+		if got := z.getAt(p); got != want[i] {
+			t.Fatalf("forward %d: got %v, want %v", i, got, want[i])
+		}
+		i++
+	}
+	if i != len(want) {
+		t.Fatalf("forward walked %d entries, want %d", i, len(want))
+	}
+	for p := z.prev(len(*z) - 1); p != -1; p = z.prev(p) {
+		i--
+		if got := z.getAt(p); got != want[i] {
+			t.Fatalf("backward %d: got %v, want %v", i, got, want[i])
+		}
+	}
+	if i != 0 {
+		t.Fatalf("backward stopped at %d", i)
+	}
+}
+
+func TestZipList_InsertAndDelete(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	z := NewZipList()
+	var want []interface{}
+
+	// strings around 250 bytes make prevLen switch between 1 and 5 bytes,
+	// which triggers cascade updates.
+	randomElement := func() interface{} {
+		switch rand.Intn(4) {
+		case 0:
+			return rand.Intn(13)
+		case 1:
+			return rand.Int() - rand.Int()
+		case 2:
+			return RandStringRunes(rand.Intn(10))
+		default:
+			return RandStringRunes(245 + rand.Intn(15))
+		}
+	}
+
+	for round := 0; round < 3000; round++ {
+		switch op := rand.Intn(5); {
+		case op < 3:
+			idx := rand.Intn(len(want) + 1)
+			e := randomElement()
+			var err error
+			if s, ok := e.(string); ok {
+				err = z.InsertString(idx, s)
+			} else {
+				err = z.InsertInt(idx, e.(int))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = append(want[:idx], append([]interface{}{e}, want[idx:]...)...)
+		case op == 3 && len(want) > 0:
+			idx := rand.Intn(len(want))
+			num := 1 + rand.Intn(3)
+			if err := z.DeleteRange(idx-len(want), num); err != nil {
+				t.Fatal(err)
+			}
+			if idx+num > len(want) {
+				num = len(want) - idx
+			}
+			want = append(want[:idx], want[idx+num:]...)
+		case op == 4 && len(want) > 0:
+			idx := rand.Intn(len(want))
+			e := randomElement()
+			if s, ok := e.(string); ok {
+				z.replaceAt(z.index(idx), []byte(s))
+			} else {
+				z.replaceAt(z.index(idx), e)
+			}
+			want[idx] = e
+		}
+		zipListCheck(t, z, want)
+	}
+
+	if err := z.Delete(len(want)); err != ErrInvalidIdx {
+		t.Errorf("Delete out of range err = %v", err)
+	}
+	if err := z.InsertInt(len(want)+1, 0); err != ErrInvalidIdx {
+		t.Errorf("InsertInt out of range err = %v", err)
+	}
+	z.DeleteRange(0, len(want))
+	zipListCheck(t, z, nil)
+	if z.ZLTail() != ZL_HEADER_SIZE {
+		t.Errorf("ZLTail() of an empty ziplist = %d", z.ZLTail())
+	}
+}
+
+func TestZipList_Merge(t *testing.T) {
+	for round := 0; round < 100; round++ {
+		a, b := NewZipList(), NewZipList()
+		var want []interface{}
+		for i := rand.Intn(5); i > 0; i-- {
+			s := RandStringRunes(rand.Intn(300))
+			a.AddString(s)
+			want = append(want, s)
+		}
+		for i := rand.Intn(5); i > 0; i-- {
+			if rand.Intn(2) == 0 {
+				s := RandStringRunes(rand.Intn(300))
+				b.AddString(s)
+				want = append(want, s)
+			} else {
+				n := rand.Int()
+				b.AddInt(n)
+				want = append(want, n)
+			}
+		}
+		a.merge(b)
+		zipListCheck(t, a, want)
+	}
+}
+
+func TestZipList_FindRangeAndReplace(t *testing.T) {
+	z := NewZipList()
+	for _, e := range []interface{}{"a", "b", "b", 12, "12", "a", -3, "x"} {
+		if i, ok := e.(int); ok {
+			z.AddInt(i)
+		} else {
+			z.AddString(e.(string))
+		}
+	}
+
+	tests := []struct {
+		s    string
+		skip int
+		want int
+	}{
+		{"a", 0, 0},
+		{"b", 0, 1},
+		{"b", 1, 2},
+		{"12", 0, 3},
+		{"12", 1, 4},
+		{"-3", 1, 6},
+		{"x", 1, -1},
+		{"012", 0, -1},
+		{"missing", 0, -1},
+	}
+	for _, tt := range tests {
+		if idx := z.Find(tt.s, tt.skip); idx != tt.want {
+			t.Errorf("Find(%q, %d) = %d, want %d", tt.s, tt.skip, idx, tt.want)
+		}
+	}
+
+	if got := z.Range(-3, 100); !reflect.DeepEqual(got, []interface{}{"a", -3, "x"}) {
+		t.Errorf("Range(-3, 100) = %v", got)
+	}
+	if got := z.Range(5, 2); got != nil {
+		t.Errorf("Range(5, 2) = %v", got)
+	}
+
+	z.ReplaceString(0, strings.Repeat("long", 100))
+	z.ReplaceInt(-1, 1<<40)
+	want := []interface{}{strings.Repeat("long", 100), "b", "b", 12, "12", "a", -3, 1 << 40}
+	if got := z.Range(0, -1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Range after Replace = %v", got)
+	}
+	if err := z.ReplaceInt(8, 0); err != ErrInvalidIdx {
+		t.Errorf("ReplaceInt out of range err = %v", err)
+	}
+	zipListCheck(t, z, want)
+}
@@ -0,0 +1,127 @@
+package datastructure
+
+import (
+	"math"
+
+	"github.com/viktorxhzj/mykv/util"
+)
+
+// ZSetSkipList pairs a skiplist with a dict from member to score,
+// which is how a large sorted set is stored.
+//
+// Time Complexity:
+// Score 	O(1);
+// Add 		O(logn);
+// Delete 	O(logn);
+// Rank 	O(logn);
+//
+// The skiplist keeps members ordered by (score, member),
+// while the dict answers lookups by member,
+// so that the score of a member never has to be known beforehand.
+type ZSetSkipList struct {
+	Dict *Dict
+	SL   SkipList
+}
+
+func NewZSetSkipList() *ZSetSkipList {
+	z := new(ZSetSkipList)
+	z.Dict = NewDict()
+	z.SL = NewSkipList()
+	return z
+}
+
+// Size returns the number of members.
+func (z *ZSetSkipList) Size() int {
+	return z.SL.Size()
+}
+
+// Add adds the member with the given score.
+// If the member already exists, its score is updated instead.
+// It returns true if the member is new.
+func (z *ZSetSkipList) Add(member string, score float64) bool {
+	if old, ok := z.Score(member); ok {
+		if old != score {
+			z.SL.UpdateScore(member, old, score)
+			z.Dict.Put(member, encodeScore(score))
+		}
+		return false
+	}
+	z.SL.Add(member, score)
+	z.Dict.Put(member, encodeScore(score))
+	return true
+}
+
+// Delete deletes the member.
+// It returns false if the member does not exist.
+func (z *ZSetSkipList) Delete(member string) bool {
+	score, ok := z.Score(member)
+	if !ok {
+		return false
+	}
+	z.SL.Delete(member, score)
+	z.Dict.Delete(member)
+	return true
+}
+
+// Score returns the score of the member,
+// and false if the member does not exist.
+func (z *ZSetSkipList) Score(member string) (float64, bool) {
+	s := z.Dict.Get(member)
+	if s == "" {
+		return 0, false
+	}
+	return decodeScore(s), true
+}
+
+// Contains reports whether the member exists.
+func (z *ZSetSkipList) Contains(member string) bool {
+	_, ok := z.Score(member)
+	return ok
+}
+
+// Rank returns the 0-based rank of the member.
+// If the member does not exist, it returns -1.
+func (z *ZSetSkipList) Rank(member string) int {
+	score, ok := z.Score(member)
+	if !ok {
+		return -1
+	}
+	return z.SL.GetRank(member, score)
+}
+
+// DeleteRangeByRank deletes the members from rank start to rank stop (both inclusive),
+// and returns the number of deleted members.
+func (z *ZSetSkipList) DeleteRangeByRank(start, stop int) int {
+	return z.deleteFromDict(z.SL.DeleteRangeByRank(start, stop))
+}
+
+// DeleteRangeByScore deletes the members whose scores are within the range,
+// and returns the number of deleted members.
+func (z *ZSetSkipList) DeleteRangeByScore(spec *RangeSpec) int {
+	return z.deleteFromDict(z.SL.DeleteRangeByScore(spec))
+}
+
+// DeleteRangeByLex deletes the members that are within the lexicographic range,
+// and returns the number of deleted members.
+func (z *ZSetSkipList) DeleteRangeByLex(spec *LexRangeSpec) int {
+	return z.deleteFromDict(z.SL.DeleteRangeByLex(spec))
+}
+
+func (z *ZSetSkipList) deleteFromDict(removed []*SkipListNode) int {
+	for _, node := range removed {
+		z.Dict.Delete(node.Key)
+	}
+	return len(removed)
+}
+
+// encodeScore encodes the score into the 8-byte string kept by the dict,
+// which never collides with "", the dict's "not found" value.
+func encodeScore(score float64) string {
+	b := make([]byte, 8)
+	util.UI64ToB(math.Float64bits(score), b, 0)
+	return string(b)
+}
+
+func decodeScore(s string) float64 {
+	return math.Float64frombits(util.BToUI64([]byte(s), 0))
+}
@@ -0,0 +1,66 @@
+package datastructure
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestZSetSkipList_Main(t *testing.T) {
+	z := NewZSetSkipList()
+
+	// members sharing a score, and the empty member, are all legitimate.
+	members := []string{"", "a", "b", "c", "d"}
+	for _, m := range members {
+		if !z.Add(m, 1) {
+			t.Errorf("Add(%q) should add a new member", m)
+		}
+	}
+	if z.Add("c", 1) {
+		t.Error("Add of an existing member should not add")
+	}
+	if z.Size() != len(members) {
+		t.Fatalf("Size() = %d", z.Size())
+	}
+	for i, m := range members {
+		if r := z.Rank(m); r != i {
+			t.Errorf("Rank(%q) = %d, want %d", m, r, i)
+		}
+	}
+
+	z.Add("a", 10)
+	z.Add("", 0.5)
+	if s, ok := z.Score("a"); !ok || s != 10 {
+		t.Errorf("Score(a) = %v, %v", s, ok)
+	}
+	want := []string{"", "b", "c", "d", "a"}
+	for i, node := range z.SL.RangeByRank(0, -1) {
+		if node.Key != want[i] {
+			t.Errorf("rank %d = %q, want %q", i, node.Key, want[i])
+		}
+	}
+
+	if !z.Delete("b") || z.Delete("b") || z.Contains("b") {
+		t.Error("Delete(b) failed")
+	}
+	if n := z.DeleteRangeByScore(&RangeSpec{Min: 1, Max: 10, MaxEx: true}); n != 2 {
+		t.Errorf("DeleteRangeByScore = %d", n)
+	}
+	if z.Contains("c") || z.Contains("d") || z.Size() != 2 || z.Dict.Size() != 2 {
+		t.Errorf("after DeleteRangeByScore, size = %d", z.Size())
+	}
+	if n := z.DeleteRangeByRank(0, -1); n != 2 || z.Dict.Size() != 0 {
+		t.Errorf("DeleteRangeByRank = %d", n)
+	}
+}
+
+func TestZSetSkipList_Rank(t *testing.T) {
+	z := NewZSetSkipList()
+	for i := 0; i < 1000; i++ {
+		z.Add(fmt.Sprintf("m%d", i), float64(i%10))
+	}
+	for rank, node := range z.SL.RangeByRank(0, -1) {
+		if r := z.Rank(node.Key); r != rank {
+			t.Fatalf("Rank(%s) = %d, want %d", node.Key, r, rank)
+		}
+	}
+}
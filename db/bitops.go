@@ -0,0 +1,480 @@
+package db
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/viktorxhzj/mykv/object"
+	"github.com/viktorxhzj/mykv/util"
+)
+
+const (
+	BITOP_AND = "AND"
+	BITOP_OR  = "OR"
+	BITOP_XOR = "XOR"
+	BITOP_NOT = "NOT"
+
+	BITFIELD_GET    = 0
+	BITFIELD_SET    = 1
+	BITFIELD_INCRBY = 2
+
+	BITFIELD_OVERFLOW_WRAP = 0
+	BITFIELD_OVERFLOW_SAT  = 1
+	BITFIELD_OVERFLOW_FAIL = 2
+
+	BIT_OFFSET_MAX = object.OBJ_STRING_MAX_SIZE * 8
+)
+
+var (
+	ErrBitOffset        = errors.New("ERR bit offset is not an integer or out of range")
+	ErrBitValue         = errors.New("ERR bit is not an integer or out of range")
+	ErrBitPosValue      = errors.New("ERR The bit argument must be 1 or 0.")
+	ErrBitOpNot         = errors.New("ERR BITOP NOT must be called with a single source key.")
+	ErrBitFieldType     = errors.New("ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	ErrBitFieldOverflow = errors.New("ERR Invalid OVERFLOW type specified")
+	ErrSyntax           = errors.New("ERR syntax error")
+)
+
+// SETBIT sets the bit at offset of the value of the key to bit,
+// growing the string with zero bytes as needed, and returns the old bit.
+func (db *DB) SETBIT(key string, offset int64, bit int) (int, error) {
+	if offset < 0 || offset >= BIT_OFFSET_MAX {
+		return 0, ErrBitOffset
+	}
+	if bit != 0 && bit != 1 {
+		return 0, ErrBitValue
+	}
+	o, err := db.lookupStringForBits(key, offset)
+	if err != nil {
+		return 0, err
+	}
+	return util.SetBit(o.StringBytes(), offset, bit), nil
+}
+
+// GETBIT returns the bit at offset of the value of the key,
+// where the bits past the end of the string are 0.
+func (db *DB) GETBIT(key string, offset int64) (int, error) {
+	if offset < 0 || offset >= BIT_OFFSET_MAX {
+		return 0, ErrBitOffset
+	}
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if o == nil {
+		return 0, err
+	}
+	return util.GetBit(o.StringBytes(), offset), nil
+}
+
+// BITCOUNT returns the number of set bits of the value of the key from start to end (both inclusive),
+// which are bit indices if isBit is true and byte indices otherwise.
+// Negative indices count from the end, so 0 and -1 count the whole string.
+func (db *DB) BITCOUNT(key string, start, end int64, isBit bool) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if o == nil {
+		return 0, err
+	}
+	b := o.StringBytes()
+	first, last, ok := bitRange(len(b), start, end, isBit)
+	if !ok {
+		return 0, nil
+	}
+
+	count := util.PopCount(b[first>>3 : last>>3+1])
+	for i := first &^ 7; i < first; i++ {
+		count -= util.GetBit(b, i)
+	}
+	for i := last + 1; i < last|7+1; i++ {
+		count -= util.GetBit(b, i)
+	}
+	return count, nil
+}
+
+// BITPOS returns the position of the first bit set to bit in the value of the key from start to end,
+// which are bit indices if isBit is true and byte indices otherwise,
+// or -1 if there is no such bit.
+//
+// If no end is given, the string is regarded as padded with infinite zero bits,
+// so a clear bit is always found.
+func (db *DB) BITPOS(key string, bit int, start, end int64, endGiven, isBit bool) (int64, error) {
+	if bit != 0 && bit != 1 {
+		return 0, ErrBitPosValue
+	}
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if err != nil {
+		return 0, err
+	}
+	if o == nil {
+		// a missing key is an infinite string of zero bits
+		if bit == 1 {
+			return -1, nil
+		}
+		return 0, nil
+	}
+
+	b := o.StringBytes()
+	if !endGiven {
+		end = -1
+	}
+	first, last, ok := bitRange(len(b), start, end, isBit)
+	if !ok {
+		return -1, nil
+	}
+
+	// skip the whole bytes that have no such bit
+	var skip byte
+	if bit == 0 {
+		skip = 0xFF
+	}
+	for pos := first; pos <= last; {
+		if pos&7 == 0 && pos+7 <= last && b[pos>>3] == skip {
+			pos += 8
+			continue
+		}
+		if util.GetBit(b, pos) == bit {
+			return pos, nil
+		}
+		pos++
+	}
+
+	if bit == 0 && !endGiven {
+		return last + 1, nil
+	}
+	return -1, nil
+}
+
+// bitRange converts the range from start to end, as given to BITCOUNT and BITPOS,
+// into the positions of the first and last bits of a string of n bytes.
+// It returns false if the range is empty.
+func bitRange(n int, start, end int64, isBit bool) (first, last int64, ok bool) {
+	total := int64(n)
+	if isBit {
+		total <<= 3
+	}
+	if start < 0 {
+		start += total
+	}
+	if end < 0 {
+		end += total
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 {
+		end = 0
+	}
+	if end >= total {
+		end = total - 1
+	}
+	if start > end {
+		return 0, 0, false
+	}
+	if isBit {
+		return start, end, true
+	}
+	return start << 3, end<<3 | 7, true
+}
+
+// BITOP performs the bitwise operation op (AND, OR, XOR or NOT) between the values of the keys,
+// stores the result at destKey, and returns its length.
+// Shorter and missing values are padded with zero bytes, and an empty result deletes destKey.
+func (db *DB) BITOP(op string, destKey string, keys ...string) (int, error) {
+	op = strings.ToUpper(op)
+	switch op {
+	case BITOP_AND, BITOP_OR, BITOP_XOR:
+	case BITOP_NOT:
+		if len(keys) != 1 {
+			return 0, ErrBitOpNot
+		}
+	default:
+		return 0, ErrSyntax
+	}
+
+	srcs := make([][]byte, len(keys))
+	maxLen := 0
+	for i, key := range keys {
+		o, err := db.lookupOfType(key, object.OBJ_STRING)
+		if err != nil {
+			return 0, err
+		}
+		if o != nil {
+			srcs[i] = o.StringBytes()
+		}
+		if len(srcs[i]) > maxLen {
+			maxLen = len(srcs[i])
+		}
+	}
+
+	if maxLen == 0 {
+		db.delete(destKey)
+		return 0, nil
+	}
+
+	res := make([]byte, maxLen)
+	for j := range res {
+		var c byte
+		for i, src := range srcs {
+			var s byte
+			if j < len(src) {
+				s = src[j]
+			}
+			switch {
+			case i == 0:
+				c = s
+			case op == BITOP_AND:
+				c &= s
+			case op == BITOP_OR:
+				c |= s
+			case op == BITOP_XOR:
+				c ^= s
+			}
+		}
+		if op == BITOP_NOT {
+			c = ^c
+		}
+		res[j] = c
+	}
+	db.set(destKey, object.NewRawStringObject(res))
+	return maxLen, nil
+}
+
+// BitFieldOp is a subcommand of BITFIELD.
+type BitFieldOp struct {
+	Op       uint8 // BITFIELD_GET, BITFIELD_SET or BITFIELD_INCRBY
+	Signed   bool
+	Bits     int
+	Offset   int64
+	Value    int64 // the value to set, or the increment
+	Overflow uint8 // the OVERFLOW behavior in effect for SET and INCRBY
+}
+
+// ParseBitField parses the arguments of BITFIELD after the key, such as
+// "GET u8 0", "SET i5 #1 -3", "INCRBY u2 100 1" and "OVERFLOW SAT".
+func ParseBitField(args []string) ([]BitFieldOp, error) {
+	var ops []BitFieldOp
+	overflow := uint8(BITFIELD_OVERFLOW_WRAP)
+
+	for i := 0; i < len(args); {
+		var op BitFieldOp
+		switch strings.ToUpper(args[i]) {
+		case "GET":
+			op.Op = BITFIELD_GET
+		case "SET":
+			op.Op = BITFIELD_SET
+		case "INCRBY":
+			op.Op = BITFIELD_INCRBY
+		case "OVERFLOW":
+			if i+1 >= len(args) {
+				return nil, ErrSyntax
+			}
+			switch strings.ToUpper(args[i+1]) {
+			case "WRAP":
+				overflow = BITFIELD_OVERFLOW_WRAP
+			case "SAT":
+				overflow = BITFIELD_OVERFLOW_SAT
+			case "FAIL":
+				overflow = BITFIELD_OVERFLOW_FAIL
+			default:
+				return nil, ErrBitFieldOverflow
+			}
+			i += 2
+			continue
+		default:
+			return nil, ErrSyntax
+		}
+
+		argc := 3
+		if op.Op != BITFIELD_GET {
+			argc = 4
+		}
+		if i+argc > len(args) {
+			return nil, ErrSyntax
+		}
+
+		var err error
+		if op.Signed, op.Bits, err = parseBitFieldType(args[i+1]); err != nil {
+			return nil, err
+		}
+		if op.Offset, err = parseBitFieldOffset(args[i+2], op.Bits); err != nil {
+			return nil, err
+		}
+		if op.Op != BITFIELD_GET {
+			if op.Value, err = strconv.ParseInt(args[i+3], 10, 64); err != nil {
+				return nil, ErrNotInteger
+			}
+		}
+		op.Overflow = overflow
+		ops = append(ops, op)
+		i += argc
+	}
+	return ops, nil
+}
+
+// parseBitFieldType parses a type such as "i64" or "u8".
+func parseBitFieldType(s string) (signed bool, bits int, err error) {
+	if len(s) < 2 || (s[0] != 'i' && s[0] != 'u') {
+		return false, 0, ErrBitFieldType
+	}
+	signed = s[0] == 'i'
+	bits, err = strconv.Atoi(s[1:])
+	if err != nil || bits < 1 || (signed && bits > 64) || (!signed && bits > 63) {
+		return false, 0, ErrBitFieldType
+	}
+	return signed, bits, nil
+}
+
+// parseBitFieldOffset parses a bit offset, or a multiple of the width if it is prefixed by "#".
+func parseBitFieldOffset(s string, bits int) (int64, error) {
+	multiple := strings.HasPrefix(s, "#")
+	if multiple {
+		s = s[1:]
+	}
+	offset, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, ErrBitOffset
+	}
+	if multiple {
+		if offset > (BIT_OFFSET_MAX-1)/int64(bits) {
+			return 0, ErrBitOffset
+		}
+		offset *= int64(bits)
+	}
+	if offset+int64(bits) > BIT_OFFSET_MAX {
+		return 0, ErrBitOffset
+	}
+	return offset, nil
+}
+
+// BITFIELD performs the subcommands on the value of the key in order,
+// and returns one result for each of them:
+// the value for GET, the old value for SET, and the new value for INCRBY.
+// The result is nil for a SET or INCRBY that fails on overflow.
+//
+// The string is created or grown only if there is a SET or INCRBY.
+func (db *DB) BITFIELD(key string, ops []BitFieldOp) ([]interface{}, error) {
+	maxBit := int64(-1)
+	for _, op := range ops {
+		if op.Op != BITFIELD_GET && op.Offset+int64(op.Bits)-1 > maxBit {
+			maxBit = op.Offset + int64(op.Bits) - 1
+		}
+	}
+
+	var o *object.ValueObject
+	var err error
+	if maxBit >= 0 {
+		o, err = db.lookupStringForBits(key, maxBit)
+	} else {
+		o, err = db.lookupOfType(key, object.OBJ_STRING)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	if o != nil {
+		b = o.StringBytes()
+	}
+	res := make([]interface{}, 0, len(ops))
+	for _, op := range ops {
+		old := bitFieldGet(b, op)
+		if op.Op == BITFIELD_GET {
+			res = append(res, old)
+			continue
+		}
+
+		v, incr := op.Value, int64(0)
+		if op.Op == BITFIELD_INCRBY {
+			v, incr = old, op.Value
+		}
+		v, ok := bitFieldAdd(v, incr, op)
+		if !ok {
+			res = append(res, nil)
+			continue
+		}
+		util.SetBits(b, op.Offset, op.Bits, uint64(v))
+		if op.Op == BITFIELD_SET {
+			res = append(res, old)
+		} else {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func bitFieldGet(b []byte, op BitFieldOp) int64 {
+	v := util.GetBits(b, op.Offset, op.Bits)
+	if op.Signed && op.Bits < 64 && v&(1<<uint(op.Bits-1)) != 0 {
+		// sign extension
+		v |= math.MaxUint64 << uint(op.Bits)
+	}
+	return int64(v)
+}
+
+// bitFieldAdd returns v+incr as a value of the type of op, handling overflow as op specifies.
+// It returns false if the operation fails on overflow.
+func bitFieldAdd(v, incr int64, op BitFieldOp) (int64, bool) {
+	var max, min int64
+	var overflow, underflow bool
+
+	if op.Signed {
+		max = math.MaxInt64
+		if op.Bits < 64 {
+			max = 1<<uint(op.Bits-1) - 1
+		}
+		min = -max - 1
+		// max-v and min-v never overflow, as the sign of v is checked for i64
+		overflow = v > max || (incr > 0 && (v >= 0 || op.Bits < 64) && incr > max-v)
+		underflow = v < min || (incr < 0 && (v < 0 || op.Bits < 64) && incr < min-v)
+	} else {
+		// an unsigned field is at most 63 bits, but SET may be given a negative value
+		max = 1<<uint(op.Bits) - 1
+		u := uint64(v)
+		overflow = u > uint64(max) || (incr > 0 && incr > max-v)
+		underflow = !overflow && incr < 0 && incr < -v
+	}
+
+	if !overflow && !underflow {
+		return v + incr, true
+	}
+	switch op.Overflow {
+	case BITFIELD_OVERFLOW_SAT:
+		if overflow {
+			return max, true
+		}
+		if op.Signed {
+			return min, true
+		}
+		return 0, true
+	case BITFIELD_OVERFLOW_WRAP:
+		// wrap around in two's complement
+		res := uint64(v) + uint64(incr)
+		if op.Bits < 64 {
+			mask := uint64(math.MaxUint64) << uint(op.Bits)
+			if op.Signed && res&(1<<uint(op.Bits-1)) != 0 {
+				res |= mask
+			} else {
+				res &^= mask
+			}
+		}
+		return int64(res), true
+	default:
+		return 0, false
+	}
+}
+
+// lookupStringForBits returns the string object of the key for modification,
+// which is created or grown with zero bytes so that it holds the bit at maxBit.
+func (db *DB) lookupStringForBits(key string, maxBit int64) (*object.ValueObject, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		o = object.NewRawStringObject(nil)
+		db.set(key, o)
+	} else {
+		o = db.unshareString(key, o)
+	}
+	o.GrowString(int(maxBit>>3 + 1))
+	return o, nil
+}
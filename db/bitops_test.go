@@ -0,0 +1,220 @@
+package db
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func TestDB_SetBitAndGetBit(t *testing.T) {
+	db := NewDB()
+
+	if old, err := db.SETBIT("b", 7, 1); old != 0 || err != nil {
+		t.Errorf("SETBIT = %d, %v", old, err)
+	}
+	if old, _ := db.SETBIT("b", 7, 0); old != 1 {
+		t.Errorf("SETBIT = %d", old)
+	}
+	db.SETBIT("b", 17, 1)
+	if v, _ := db.GET("b"); !bytes.Equal(v, []byte{0, 0, 0x40}) {
+		t.Errorf("GET = %q", v)
+	}
+	if bit, _ := db.GETBIT("b", 17); bit != 1 {
+		t.Errorf("GETBIT = %d", bit)
+	}
+	if bit, _ := db.GETBIT("b", 1000); bit != 0 {
+		t.Errorf("GETBIT past the end = %d", bit)
+	}
+
+	// "1" is shared and INT encoded, 0x31
+	db.SET("i", []byte("1"))
+	if old, _ := db.SETBIT("i", 6, 1); old != 0 || encodingOf(db, "i") != object.OBJ_ENCODING_RAW {
+		t.Errorf("SETBIT on INT = %d", old)
+	}
+	if v, _ := db.GET("i"); string(v) != "3" {
+		t.Errorf("GET = %q", v)
+	}
+	if v := object.NewStringObjectFromInt64(1).StringBytes(); string(v) != "1" {
+		t.Errorf("the shared integer is modified to %q", v)
+	}
+
+	if _, err := db.SETBIT("b", -1, 1); err != ErrBitOffset {
+		t.Errorf("SETBIT negative offset err = %v", err)
+	}
+	if _, err := db.SETBIT("b", BIT_OFFSET_MAX, 1); err != ErrBitOffset {
+		t.Errorf("SETBIT large offset err = %v", err)
+	}
+	if _, err := db.SETBIT("b", 0, 2); err != ErrBitValue {
+		t.Errorf("SETBIT 2 err = %v", err)
+	}
+}
+
+func TestDB_BitCount(t *testing.T) {
+	db := NewDB()
+	db.SET("b", []byte("foobar"))
+
+	tests := []struct {
+		start, end int64
+		isBit      bool
+		want       int
+	}{
+		{0, -1, false, 26},
+		{0, 0, false, 4},
+		{1, 1, false, 6},
+		{1, 1, true, 1},
+		{5, 30, true, 17},
+		{-100, 100, false, 26},
+		{3, 2, false, 0},
+		{-1, -1, true, 0},
+	}
+	for _, tt := range tests {
+		if n, _ := db.BITCOUNT("b", tt.start, tt.end, tt.isBit); n != tt.want {
+			t.Errorf("BITCOUNT(%d, %d, %v) = %d, want %d", tt.start, tt.end, tt.isBit, n, tt.want)
+		}
+	}
+	if n, _ := db.BITCOUNT("missing", 0, -1, false); n != 0 {
+		t.Errorf("BITCOUNT missing key = %d", n)
+	}
+}
+
+func TestDB_BitPos(t *testing.T) {
+	db := NewDB()
+	db.SET("b", []byte{0xFF, 0xF0, 0x00})
+
+	tests := []struct {
+		bit        int
+		start, end int64
+		endGiven   bool
+		isBit      bool
+		want       int64
+	}{
+		{0, 0, 0, false, false, 12},
+		{1, 2, 0, false, false, -1},
+		{1, 1, 0, false, false, 8},
+		{0, 0, 0, true, false, -1},
+		{1, 2, -1, true, false, -1},
+		{1, 7, 15, true, true, 7},
+		{0, 7, 11, true, true, -1},
+		{0, 7, 12, true, true, 12},
+	}
+	for _, tt := range tests {
+		if pos, _ := db.BITPOS("b", tt.bit, tt.start, tt.end, tt.endGiven, tt.isBit); pos != tt.want {
+			t.Errorf("BITPOS(%d, %d, %d, %v, %v) = %d, want %d", tt.bit, tt.start, tt.end, tt.endGiven, tt.isBit, pos, tt.want)
+		}
+	}
+
+	// no clear bit before the end, so the first bit after it
+	db.SET("ones", []byte{0xFF, 0xFF})
+	if pos, _ := db.BITPOS("ones", 0, 0, 0, false, false); pos != 16 {
+		t.Errorf("BITPOS all ones = %d", pos)
+	}
+	if pos, _ := db.BITPOS("missing", 0, 0, 0, false, false); pos != 0 {
+		t.Errorf("BITPOS 0 missing key = %d", pos)
+	}
+	if pos, _ := db.BITPOS("missing", 1, 0, 0, false, false); pos != -1 {
+		t.Errorf("BITPOS 1 missing key = %d", pos)
+	}
+	if _, err := db.BITPOS("b", 2, 0, 0, false, false); err != ErrBitPosValue {
+		t.Errorf("BITPOS 2 err = %v", err)
+	}
+}
+
+func TestDB_BitOp(t *testing.T) {
+	db := NewDB()
+	db.SET("a", []byte{0xF0, 0x0F})
+	db.SET("b", []byte{0x3C})
+
+	tests := []struct {
+		op   string
+		keys []string
+		want []byte
+	}{
+		{"and", []string{"a", "b"}, []byte{0x30, 0x00}},
+		{"OR", []string{"a", "b"}, []byte{0xFC, 0x0F}},
+		{"XOR", []string{"a", "b", "missing"}, []byte{0xCC, 0x0F}},
+		{"NOT", []string{"a"}, []byte{0x0F, 0xF0}},
+	}
+	for _, tt := range tests {
+		if n, err := db.BITOP(tt.op, "dest", tt.keys...); n != len(tt.want) || err != nil {
+			t.Errorf("BITOP %s = %d, %v", tt.op, n, err)
+		}
+		if v, _ := db.GET("dest"); !bytes.Equal(v, tt.want) {
+			t.Errorf("BITOP %s result = %x, want %x", tt.op, v, tt.want)
+		}
+	}
+
+	if n, _ := db.BITOP("AND", "dest", "missing"); n != 0 || db.lookup("dest") != nil {
+		t.Errorf("BITOP of empty strings does not delete the destination")
+	}
+	if _, err := db.BITOP("NOT", "dest", "a", "b"); err != ErrBitOpNot {
+		t.Errorf("BITOP NOT err = %v", err)
+	}
+	if _, err := db.BITOP("NAND", "dest", "a"); err != ErrSyntax {
+		t.Errorf("BITOP NAND err = %v", err)
+	}
+}
+
+func TestDB_BitField(t *testing.T) {
+	db := NewDB()
+
+	bitField := func(args ...string) []interface{} {
+		ops, err := ParseBitField(args)
+		if err != nil {
+			t.Fatalf("ParseBitField(%v) err = %v", args, err)
+		}
+		res, err := db.BITFIELD("f", ops)
+		if err != nil {
+			t.Fatalf("BITFIELD(%v) err = %v", args, err)
+		}
+		return res
+	}
+	check := func(res []interface{}, want ...interface{}) {
+		t.Helper()
+		if !reflect.DeepEqual(res, want) {
+			t.Errorf("BITFIELD = %v, want %v", res, want)
+		}
+	}
+
+	check(bitField("GET", "u8", "0"), int64(0))
+	if db.lookup("f") != nil {
+		t.Errorf("BITFIELD GET creates the key")
+	}
+
+	check(bitField("SET", "i5", "#1", "-3", "GET", "u10", "0", "GET", "i5", "5"), int64(0), int64(29), int64(-3))
+	if v, _ := db.GET("f"); !bytes.Equal(v, []byte{0x07, 0x40}) {
+		t.Errorf("GET = %x", v)
+	}
+
+	check(bitField("INCRBY", "u2", "100", "1", "INCRBY", "u2", "100", "1",
+		"INCRBY", "u2", "100", "1", "INCRBY", "u2", "100", "1"), int64(1), int64(2), int64(3), int64(0))
+	check(bitField("OVERFLOW", "SAT", "INCRBY", "u2", "102", "5", "INCRBY", "i8", "104", "-200"), int64(3), int64(-128))
+	check(bitField("OVERFLOW", "FAIL", "INCRBY", "u2", "102", "1", "SET", "i8", "104", "128", "GET", "i8", "104"),
+		nil, nil, int64(-128))
+	check(bitField("OVERFLOW", "WRAP", "SET", "i8", "104", "128", "INCRBY", "i8", "104", "-1"), int64(-128), int64(127))
+	check(bitField("SET", "u8", "112", "-1", "GET", "u8", "112"), int64(0), int64(255))
+
+	check(bitField("SET", "i64", "200", "-1", "INCRBY", "i64", "200", "1"), int64(0), int64(0))
+	check(bitField("SET", "i64", "200", "9223372036854775807", "INCRBY", "i64", "200", "1"),
+		int64(0), int64(math.MinInt64))
+	check(bitField("OVERFLOW", "SAT", "INCRBY", "i64", "200", "-1"), int64(math.MinInt64))
+	check(bitField("SET", "u63", "300", "9223372036854775807", "INCRBY", "u63", "300", "2"),
+		int64(0), int64(1))
+
+	for _, args := range [][]string{
+		{"GET", "u64", "0"},
+		{"GET", "i65", "0"},
+		{"GET", "x8", "0"},
+		{"GET", "u8", "-1"},
+		{"GET", "u8"},
+		{"SET", "u8", "0", "a"},
+		{"OVERFLOW", "BOUNCE"},
+		{"DEL", "u8", "0"},
+	} {
+		if _, err := ParseBitField(args); err == nil {
+			t.Errorf("ParseBitField(%v) should fail", args)
+		}
+	}
+}
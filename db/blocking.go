@@ -0,0 +1,253 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+//
+// Blocking commands, i.e. BLPOP, BRPOP, BLMOVE, BZPOPMIN, BZPOPMAX,
+// and XREAD and XREADGROUP with BLOCK,
+// serve a client at once if one of its keys holds a value,
+// otherwise block it on all its keys, in the blocking keys of the DB.
+//
+// Once a key is added by set, or an entry is added to a stream,
+// it is queued as ready, if any client is blocked on it.
+// HandleClientsBlockedOnKeys, which the command loop calls after each command,
+// serves the clients blocked on the ready keys, in the order they blocked,
+// while the key still holds a value for them.
+// HandleBlockedClientsTimeout unblocks the clients whose timeouts have passed.
+//
+// A list or a sorted set is deleted once emptied,
+// so such a key is only ready when it is added.
+//
+
+var (
+	ErrNegativeTimeout = errors.New("ERR timeout is negative")
+)
+
+// BlockedClient is a client of a blocking command.
+type BlockedClient struct {
+	keys  []string
+	ot    uint8 // the type of object it pops from
+	where int   // LIST_HEAD or LIST_TAIL, where the head of a sorted set is its lowest score
+
+	// BLMOVE only
+	move bool
+	dst  string
+	to   int
+
+	// XREAD and XREADGROUP only
+	xread     *XReadArgs
+	streamIDs map[string]object.StreamID // the IDs to read after, XREAD only
+
+	deadline time.Time // zero if it blocks forever
+	blocked  bool
+	done     chan struct{}
+	reply    *BlockedReply
+	err      error
+}
+
+// BlockedReply is the reply to a blocking command once it is served.
+type BlockedReply struct {
+	Key     string            // the key popped from
+	Element string            // the element popped from a list
+	Member  object.ZSetMember // the member popped from a sorted set
+
+	Entries []object.StreamEntry // the entries read from a stream
+}
+
+// newBlockedClient creates a client blocking on the keys,
+// each only once however many times it is given, in the order first given.
+func newBlockedClient(keys []string, ot uint8, where int) *BlockedClient {
+	c := new(BlockedClient)
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if !seen[key] {
+			c.keys = append(c.keys, key)
+			seen[key] = true
+		}
+	}
+	c.ot = ot
+	c.where = where
+	c.done = make(chan struct{})
+	return c
+}
+
+// Done returns a channel that is closed once the client is served or times out.
+func (c *BlockedClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Result returns the reply to the client and the error it was served with.
+// It returns nil and nil if the client is still blocked or has timed out.
+func (c *BlockedClient) Result() (*BlockedReply, error) {
+	return c.reply, c.err
+}
+
+// block serves the client at once from the first key that holds a value,
+// or blocks it on all its keys for the timeout, where 0 is forever.
+func (db *DB) block(c *BlockedClient, timeout time.Duration) (*BlockedClient, error) {
+	if timeout < 0 {
+		return nil, ErrNegativeTimeout
+	}
+	for _, key := range c.keys {
+		o, err := db.lookupOfType(key, c.ot)
+		if err != nil {
+			return nil, err
+		}
+		if o != nil {
+			db.serveBlockedClient(c, key)
+			if c.err != nil {
+				return nil, c.err
+			}
+			return c, nil
+		}
+	}
+	db.blockForKeys(c, timeout)
+	return c, nil
+}
+
+// blockForKeys blocks the client on all its keys for the timeout, where 0 is forever.
+func (db *DB) blockForKeys(c *BlockedClient, timeout time.Duration) {
+	if timeout > 0 {
+		c.deadline = time.Now().Add(timeout)
+	}
+	for _, key := range c.keys {
+		db.blockingKeys[key] = append(db.blockingKeys[key], c)
+	}
+	c.blocked = true
+}
+
+// unblock removes the client from the blocking keys and marks it done.
+func (db *DB) unblock(c *BlockedClient) {
+	c.blocked = false
+	for _, key := range c.keys {
+		clients := db.blockingKeys[key]
+		for i, other := range clients {
+			if other == c {
+				clients = append(clients[:i], clients[i+1:]...)
+				break
+			}
+		}
+		if len(clients) == 0 {
+			delete(db.blockingKeys, key)
+		} else {
+			db.blockingKeys[key] = clients
+		}
+	}
+	close(c.done)
+}
+
+// signalKeyAsReady queues the key as ready, if any client is blocked on it.
+func (db *DB) signalKeyAsReady(key string) {
+	if _, ok := db.blockingKeys[key]; !ok {
+		return
+	}
+	if _, ok := db.readyKeySet[key]; ok {
+		return
+	}
+	db.readyKeys = append(db.readyKeys, key)
+	db.readyKeySet[key] = struct{}{}
+}
+
+// HandleClientsBlockedOnKeys serves the clients blocked on the keys that are ready,
+// until no key is ready.
+func (db *DB) HandleClientsBlockedOnKeys() {
+	// serving BLMOVE may ready other keys
+	for len(db.readyKeys) > 0 {
+		keys := db.readyKeys
+		db.readyKeys = nil
+		db.readyKeySet = make(map[string]struct{})
+
+		for _, key := range keys {
+			clients := append([]*BlockedClient(nil), db.blockingKeys[key]...)
+			for _, c := range clients {
+				if db.lookup(key) == nil {
+					break
+				}
+				db.serveBlockedClient(c, key)
+			}
+		}
+	}
+}
+
+// HandleBlockedClientsTimeout unblocks the clients whose timeouts have passed at now.
+func (db *DB) HandleBlockedClientsTimeout(now time.Time) {
+	var expired []*BlockedClient
+	seen := make(map[*BlockedClient]bool)
+	for _, clients := range db.blockingKeys {
+		for _, c := range clients {
+			if !seen[c] && !c.deadline.IsZero() && !now.Before(c.deadline) {
+				expired = append(expired, c)
+			}
+			seen[c] = true
+		}
+	}
+	for _, c := range expired {
+		db.unblock(c)
+	}
+}
+
+// serveBlockedClient pops for the client from the key, if the key holds a value of the type it pops from,
+// or reads the entries of the stream at the key for it,
+// and returns false if there is nothing for it.
+func (db *DB) serveBlockedClient(c *BlockedClient, key string) bool {
+	o := db.lookup(key)
+	if o == nil {
+		return false
+	}
+	if t, _ := o.GetType(); t != c.ot {
+		return false
+	}
+
+	reply := &BlockedReply{Key: key}
+	switch {
+	case c.xread != nil:
+		s := object.Stream{ValueObject: o}
+		if c.xread.Group == "" {
+			start, _ := c.streamIDs[key].Incr()
+			reply.Entries = s.XRANGE(start, s.LastID(), c.xread.Count)
+		} else {
+			reply.Entries, c.err = s.XREADGROUP(c.xread.Group, c.xread.Consumer, object.StreamID{}, true, c.xread.Count, c.xread.NoAck)
+		}
+		if c.err == nil && len(reply.Entries) == 0 {
+			return false
+		}
+	case c.move:
+		// LMOVE pops nothing if dst is of the wrong type
+		reply.Element, _, c.err = db.LMOVE(key, c.dst, c.where, c.to)
+	case c.ot == object.OBJ_LIST:
+		l := object.List{ValueObject: o}
+		if c.where == object.LIST_HEAD {
+			reply.Element = l.LPOP(1)[0]
+		} else {
+			reply.Element = l.RPOP(1)[0]
+		}
+		if l.LLEN() == 0 {
+			db.delete(key)
+		}
+	default:
+		z := object.SortedSet{ValueObject: o}
+		if c.where == object.LIST_HEAD {
+			reply.Member, _ = z.ZPOPMIN()
+		} else {
+			reply.Member, _ = z.ZPOPMAX()
+		}
+		if z.ZCARD() == 0 {
+			db.delete(key)
+		}
+	}
+
+	if c.err == nil {
+		c.reply = reply
+	}
+	if c.blocked {
+		db.unblock(c)
+	} else {
+		close(c.done)
+	}
+	return true
+}
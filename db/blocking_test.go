@@ -0,0 +1,191 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func isDone(c *BlockedClient) bool {
+	select {
+	case <-c.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func checkServed(t *testing.T, c *BlockedClient, key, element string) {
+	t.Helper()
+	reply, err := c.Result()
+	if !isDone(c) || err != nil || reply == nil || reply.Key != key || reply.Element != element {
+		t.Errorf("reply = %+v, %v, want %s from %s", reply, err, element, key)
+	}
+}
+
+func TestDB_BlockingListPop(t *testing.T) {
+	db := NewDB()
+	db.RPUSH("l", "a", "b")
+
+	// served at once from the first non-empty list
+	c, err := db.BRPOP(0, "missing", "l")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkServed(t, c, "l", "b")
+
+	// woken in the order they blocked
+	c1, _ := db.BLPOP(0, "k1", "k2")
+	c2, _ := db.BLPOP(0, "k2")
+	if isDone(c1) || isDone(c2) {
+		t.Fatalf("clients on empty keys are done")
+	}
+	db.RPUSH("k2", "x")
+	if isDone(c1) {
+		t.Errorf("client served before the command loop handles the ready keys")
+	}
+	db.HandleClientsBlockedOnKeys()
+	checkServed(t, c1, "k2", "x")
+	if isDone(c2) || db.lookup("k2") != nil {
+		t.Errorf("second client served, or the emptied list kept")
+	}
+	if _, ok := db.blockingKeys["k1"]; ok {
+		t.Errorf("served client still blocked on its other keys")
+	}
+
+	db.RPUSH("k2", "y", "z")
+	db.HandleClientsBlockedOnKeys()
+	checkServed(t, c2, "k2", "y")
+	if got := listOf(db, "k2"); len(got) != 1 || got[0] != "z" {
+		t.Errorf("list after serving = %v", got)
+	}
+
+	// a list client ignores a sorted set
+	c3, _ := db.BLPOP(0, "z")
+	c4, _ := db.BZPOPMAX(0, "z")
+	db.ZADD("z", 0, object.ZSetMember{Key: "m1", Value: 1}, object.ZSetMember{Key: "m2", Value: 2})
+	db.HandleClientsBlockedOnKeys()
+	if isDone(c3) {
+		t.Errorf("list client served by a sorted set")
+	}
+	if reply, _ := c4.Result(); reply == nil || reply.Member != (object.ZSetMember{Key: "m2", Value: 2}) {
+		t.Errorf("BZPOPMAX reply = %+v", reply)
+	}
+}
+
+func TestDB_BlockingDuplicateKeys(t *testing.T) {
+	db := NewDB()
+
+	// a client blocks on a key once however many times it is given
+	c1, _ := db.BLPOP(0, "k", "k")
+	c2, _ := db.BLPOP(0, "k")
+	if n := len(db.blockingKeys["k"]); n != 2 {
+		t.Fatalf("clients blocked on k = %d", n)
+	}
+	db.RPUSH("k", "a", "b")
+	db.HandleClientsBlockedOnKeys()
+	checkServed(t, c1, "k", "a")
+	checkServed(t, c2, "k", "b")
+	if _, ok := db.blockingKeys["k"]; ok || db.lookup("k") != nil {
+		t.Errorf("served clients still blocked, or the emptied list kept")
+	}
+
+	_, c3, _ := db.XREAD(XReadArgs{Keys: []string{"s", "s"}, IDs: []string{"$", "$"}, Block: true})
+	db.XADD("s", false, nil, "*", "f", "v")
+	db.XADD("s", false, nil, "*", "f", "v")
+	db.HandleClientsBlockedOnKeys()
+	if reply, err := c3.Result(); err != nil || reply == nil || len(reply.Entries) != 2 {
+		t.Errorf("XREAD BLOCK reply = %+v, %v", reply, err)
+	}
+}
+
+func TestDB_BlockingZSetPop(t *testing.T) {
+	db := NewDB()
+	db.ZADD("z", 0, object.ZSetMember{Key: "a", Value: 1}, object.ZSetMember{Key: "b", Value: 2})
+
+	c, _ := db.BZPOPMIN(time.Second, "z")
+	if reply, _ := c.Result(); reply == nil || reply.Key != "z" || reply.Member.Key != "a" {
+		t.Errorf("BZPOPMIN reply = %+v", reply)
+	}
+	c, _ = db.BZPOPMIN(time.Second, "z")
+	if reply, _ := c.Result(); reply == nil || reply.Member.Key != "b" || db.lookup("z") != nil {
+		t.Errorf("BZPOPMIN reply = %+v", reply)
+	}
+
+	c, _ = db.BZPOPMIN(0, "z")
+	db.ZUNIONSTORE("z", []string{"missing"}, nil, object.ZAGGREGATE_SUM)
+	db.HandleClientsBlockedOnKeys()
+	if isDone(c) {
+		t.Errorf("client served by an empty result")
+	}
+	db.ZADD("other2", 0, object.ZSetMember{Key: "c", Value: 3})
+	db.ZUNIONSTORE("z", []string{"other2"}, nil, object.ZAGGREGATE_SUM)
+	db.HandleClientsBlockedOnKeys()
+	if reply, _ := c.Result(); reply == nil || reply.Member.Key != "c" {
+		t.Errorf("client woken by ZUNIONSTORE = %+v", reply)
+	}
+}
+
+func TestDB_BlockingMove(t *testing.T) {
+	db := NewDB()
+
+	// the element moved by BLMOVE wakes the client blocked on dst
+	c1, _ := db.BLMOVE("src", "dst", object.LIST_HEAD, object.LIST_TAIL, 0)
+	c2, _ := db.BRPOP(0, "dst")
+	db.LPUSH("src", "x")
+	db.HandleClientsBlockedOnKeys()
+	checkServed(t, c1, "src", "x")
+	checkServed(t, c2, "dst", "x")
+	if db.Size() != 0 {
+		t.Errorf("keys left = %d", db.Size())
+	}
+
+	// a dst of the wrong type fails the client, and keeps the element at src
+	c3, _ := db.BLMOVE("src", "str", object.LIST_HEAD, object.LIST_TAIL, 0)
+	db.SET("str", []byte("v"))
+	db.RPUSH("src", "y")
+	db.HandleClientsBlockedOnKeys()
+	if reply, err := c3.Result(); !isDone(c3) || reply != nil || err != ErrWrongType {
+		t.Errorf("BLMOVE to a string = %+v, %v", reply, err)
+	}
+	if got := listOf(db, "src"); len(got) != 1 {
+		t.Errorf("src = %v", got)
+	}
+	if _, err := db.BLMOVE("src", "str", object.LIST_HEAD, object.LIST_TAIL, 0); err != ErrWrongType {
+		t.Errorf("BLMOVE to a string at once = %v", err)
+	}
+}
+
+func TestDB_BlockingTimeoutAndErrors(t *testing.T) {
+	db := NewDB()
+	db.SET("str", []byte("v"))
+
+	if _, err := db.BLPOP(-time.Second, "l"); err != ErrNegativeTimeout {
+		t.Errorf("negative timeout = %v", err)
+	}
+	if _, err := db.BLPOP(0, "missing", "str"); err != ErrWrongType {
+		t.Errorf("BLPOP on a string = %v", err)
+	}
+	if _, err := db.BZPOPMIN(0, "str"); err != ErrWrongType {
+		t.Errorf("BZPOPMIN on a string = %v", err)
+	}
+
+	forever, _ := db.BLPOP(0, "l")
+	c, _ := db.BLPOP(time.Second, "l", "l2")
+	db.HandleBlockedClientsTimeout(time.Now())
+	if isDone(c) {
+		t.Errorf("client timed out early")
+	}
+	db.HandleBlockedClientsTimeout(time.Now().Add(2 * time.Second))
+	if reply, err := c.Result(); !isDone(c) || reply != nil || err != nil {
+		t.Errorf("timed out client = %+v, %v", reply, err)
+	}
+	if isDone(forever) || len(db.blockingKeys) != 1 {
+		t.Errorf("blocking keys after the timeout = %v", db.blockingKeys)
+	}
+
+	db.RPUSH("l", "a")
+	db.HandleClientsBlockedOnKeys()
+	checkServed(t, forever, "l", "a")
+}
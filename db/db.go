@@ -0,0 +1,67 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+var (
+	ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+)
+
+// DB is a keyspace, mapping keys to value objects.
+type DB struct {
+	dict map[string]*object.ValueObject
+
+	blockingKeys map[string][]*BlockedClient // clients blocked on each key, in the order they blocked
+	readyKeys    []string                    // keys that may serve blocked clients, in the order they got ready
+	readyKeySet  map[string]struct{}
+}
+
+func NewDB() *DB {
+	db := new(DB)
+	db.dict = make(map[string]*object.ValueObject)
+	db.blockingKeys = make(map[string][]*BlockedClient)
+	db.readyKeySet = make(map[string]struct{})
+	return db
+}
+
+// Size returns the number of keys.
+func (db *DB) Size() int {
+	return len(db.dict)
+}
+
+// lookup returns the object of the key, or nil if the key does not exist.
+func (db *DB) lookup(key string) *object.ValueObject {
+	return db.dict[key]
+}
+
+// lookupOfType returns the object of the key, or nil if the key does not exist.
+// If the object is not of type ot, it returns ErrWrongType.
+func (db *DB) lookupOfType(key string, ot uint8) (*object.ValueObject, error) {
+	o := db.dict[key]
+	if o == nil {
+		return nil, nil
+	}
+	if t, _ := o.GetType(); t != ot {
+		return nil, ErrWrongType
+	}
+	return o, nil
+}
+
+// set sets the object of the key, overwriting the old one of any type,
+// and signals the key as ready for the clients blocked on it.
+func (db *DB) set(key string, o *object.ValueObject) {
+	db.dict[key] = o
+	db.signalKeyAsReady(key)
+}
+
+// delete deletes the key, and returns false if the key does not exist.
+func (db *DB) delete(key string) bool {
+	if _, ok := db.dict[key]; !ok {
+		return false
+	}
+	delete(db.dict, key)
+	return true
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"github.com/viktorxhzj/mykv/object"
+)
+
+// GEOADD adds the members at their positions to the geospatial index at the key,
+// as object.SortedSet.GEOADD does, where the sorted set is created if it does not exist and a member is added.
+func (db *DB) GEOADD(key string, flags int, members ...object.GeoMember) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_ZSET)
+	if err != nil {
+		return 0, err
+	}
+	if o != nil {
+		return object.SortedSet{ValueObject: o}.GEOADD(flags, members...)
+	}
+
+	z := object.NewSortedSetObject()
+	n, err := z.GEOADD(flags, members...)
+	if z.ZCARD() > 0 {
+		db.set(key, z.ValueObject)
+	}
+	return n, err
+}
+
+// GEOPOS returns the positions of the members of the geospatial index at the key,
+// nil for a missing member.
+func (db *DB) GEOPOS(key string, members ...string) ([]*object.GeoPoint, error) {
+	z, err := db.lookupGeo(key)
+	if err != nil {
+		return nil, err
+	}
+	return z.GEOPOS(members...), nil
+}
+
+// GEODIST returns the distance between two members of the geospatial index at the key in the unit,
+// and false if either of them is missing.
+func (db *DB) GEODIST(key, member1, member2, unit string) (float64, bool, error) {
+	z, err := db.lookupGeo(key)
+	if err != nil {
+		return 0, false, err
+	}
+	return z.GEODIST(member1, member2, unit)
+}
+
+// GEOHASH returns the geohash strings of the members of the geospatial index at the key,
+// "" for a missing member.
+func (db *DB) GEOHASH(key string, members ...string) ([]string, error) {
+	z, err := db.lookupGeo(key)
+	if err != nil {
+		return nil, err
+	}
+	return z.GEOHASH(members...), nil
+}
+
+// GEOSEARCH returns the members of the geospatial index at the key within the shape,
+// as object.SortedSet.GEOSEARCH does, where a missing key has no members.
+func (db *DB) GEOSEARCH(key string, args object.GeoSearchArgs) ([]object.GeoResult, error) {
+	o, err := db.lookupOfType(key, object.OBJ_ZSET)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		// FROMMEMBER can't be missing from a missing key
+		return nil, args.Validate()
+	}
+	return object.SortedSet{ValueObject: o}.GEOSEARCH(args)
+}
+
+// GEOSEARCHSTORE stores the results of GEOSEARCH at dst as a geospatial index,
+// or as a sorted set scored by the distances if storeDist,
+// and returns the number of results, where dst is deleted if there are none.
+func (db *DB) GEOSEARCHSTORE(dst, src string, args object.GeoSearchArgs, storeDist bool) (int, error) {
+	results, err := db.GEOSEARCH(src, args)
+	if err != nil {
+		return 0, err
+	}
+	members := make([]object.ZSetMember, len(results))
+	for i, r := range results {
+		members[i] = object.ZSetMember{Key: r.Member, Value: float64(r.Hash)}
+		if storeDist {
+			members[i].Value = r.Dist
+		}
+	}
+	return db.storeZSet(dst, members), nil
+}
+
+// lookupGeo returns the sorted set at the key, where a missing key is an empty sorted set.
+func (db *DB) lookupGeo(key string) (object.SortedSet, error) {
+	o, err := db.lookupOfType(key, object.OBJ_ZSET)
+	if err != nil {
+		return object.SortedSet{}, err
+	}
+	if o == nil {
+		return object.NewSortedSetObject(), nil
+	}
+	return object.SortedSet{ValueObject: o}, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func TestDB_Geo(t *testing.T) {
+	db := NewDB()
+	db.SET("str", []byte("v"))
+
+	if n, err := db.GEOADD("Sicily", 0,
+		object.GeoMember{Member: "Palermo", GeoPoint: object.GeoPoint{Longitude: 13.361389, Latitude: 38.115556}},
+		object.GeoMember{Member: "Catania", GeoPoint: object.GeoPoint{Longitude: 15.087269, Latitude: 37.502669}},
+	); n != 2 || err != nil {
+		t.Fatalf("GEOADD = %d, %v", n, err)
+	}
+	if _, err := db.GEOADD("bad", 0, object.GeoMember{Member: "x", GeoPoint: object.GeoPoint{Longitude: 200}}); err == nil || db.lookup("bad") != nil {
+		t.Errorf("GEOADD of an invalid position err = %v", err)
+	}
+	if _, err := db.GEOADD("str", 0); err != ErrWrongType {
+		t.Errorf("GEOADD to a string err = %v", err)
+	}
+
+	if pos, err := db.GEOPOS("missing", "Palermo"); err != nil || len(pos) != 1 || pos[0] != nil {
+		t.Errorf("GEOPOS of a missing key = %v, %v", pos, err)
+	}
+	if _, ok, err := db.GEODIST("missing", "a", "b", "m"); ok || err != nil {
+		t.Errorf("GEODIST of a missing key = %v, %v", ok, err)
+	}
+	if h, err := db.GEOHASH("Sicily", "Catania"); err != nil || h[0] != "sqdtr74hyu0" {
+		t.Errorf("GEOHASH = %v, %v", h, err)
+	}
+	if _, err := db.GEOHASH("str", "x"); err != ErrWrongType {
+		t.Errorf("GEOHASH of a string err = %v", err)
+	}
+
+	args := object.GeoSearchArgs{
+		FromLonLat: &object.GeoPoint{Longitude: 15, Latitude: 37},
+		Shape:      object.GEO_SHAPE_RADIUS, Radius: 200, Unit: "km",
+	}
+	if n, err := db.GEOSEARCHSTORE("near", "Sicily", args, false); n != 2 || err != nil {
+		t.Fatalf("GEOSEARCHSTORE = %d, %v", n, err)
+	}
+	// the stored geospatial index has the same positions
+	d1, _, _ := db.GEODIST("Sicily", "Palermo", "Catania", "m")
+	if d2, ok, _ := db.GEODIST("near", "Palermo", "Catania", "m"); !ok || d1 != d2 {
+		t.Errorf("GEODIST of the stored index = %f, want %f", d2, d1)
+	}
+
+	if n, _ := db.GEOSEARCHSTORE("dists", "Sicily", args, true); n != 2 {
+		t.Errorf("GEOSEARCHSTORE STOREDIST = %d", n)
+	}
+	z, _ := db.lookupGeo("dists")
+	if got := fmt.Sprintf("%.4f", z.ZRANGEBYSCORE(0, 1000)[0].Value); got != "56.4413" {
+		t.Errorf("stored distance = %s", got)
+	}
+
+	// no results delete dst
+	args.FromLonLat = &object.GeoPoint{}
+	if n, err := db.GEOSEARCHSTORE("near", "Sicily", args, false); n != 0 || err != nil || db.lookup("near") != nil {
+		t.Errorf("GEOSEARCHSTORE of no results = %d, %v", n, err)
+	}
+
+	// a missing source has no members, even FROMMEMBER
+	palermo := "Palermo"
+	fromMember := object.GeoSearchArgs{FromMember: &palermo, Shape: object.GEO_SHAPE_RADIUS, Radius: 200, Unit: "km"}
+	if res, err := db.GEOSEARCH("missing", fromMember); res != nil || err != nil {
+		t.Errorf("GEOSEARCH of a missing key = %v, %v", res, err)
+	}
+	db.GEOSEARCHSTORE("near", "Sicily", fromMember, false)
+	if n, err := db.GEOSEARCHSTORE("near", "missing", fromMember, false); n != 0 || err != nil || db.lookup("near") != nil {
+		t.Errorf("GEOSEARCHSTORE of a missing key = %d, %v", n, err)
+	}
+	fromMember.Unit = "yd"
+	if _, err := db.GEOSEARCH("missing", fromMember); err != object.ErrGeoUnit {
+		t.Errorf("GEOSEARCH of a missing key with a bad unit err = %v", err)
+	}
+}
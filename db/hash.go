@@ -0,0 +1,86 @@
+package db
+
+import (
+	"github.com/viktorxhzj/mykv/object"
+)
+
+// HSET sets the fields of the hash at the key, which is created if it does not exist,
+// and returns the number of fields that are added rather than updated.
+func (db *DB) HSET(key string, fields ...object.HashField) (int, error) {
+	h, err := db.lookupOrCreateHash(key)
+	if err != nil {
+		return 0, err
+	}
+	n := h.HSET(fields...)
+	db.deleteIfEmptyHash(key, h)
+	return n, nil
+}
+
+// HSETNX sets the field of the hash at the key only if the field does not exist,
+// where the hash is created if it does not exist, and returns true if it is set.
+func (db *DB) HSETNX(key, field, value string) (bool, error) {
+	h, err := db.lookupOrCreateHash(key)
+	if err != nil {
+		return false, err
+	}
+	return h.HSETNX(field, value), nil
+}
+
+// HDEL deletes the fields from the hash at the key, which is deleted once empty,
+// and returns the number of deleted fields.
+func (db *DB) HDEL(key string, fields ...string) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_HASH)
+	if o == nil {
+		return 0, err
+	}
+	h := object.Hash{ValueObject: o}
+	n := h.HDEL(fields...)
+	db.deleteIfEmptyHash(key, h)
+	return n, nil
+}
+
+// HINCRBY increments the field of the hash at the key by incr, as object.Hash.HINCRBY does,
+// where the hash is created if it does not exist.
+func (db *DB) HINCRBY(key, field string, incr int64) (int64, error) {
+	h, err := db.lookupOrCreateHash(key)
+	if err != nil {
+		return 0, err
+	}
+	v, err := h.HINCRBY(field, incr)
+	db.deleteIfEmptyHash(key, h)
+	return v, err
+}
+
+// HINCRBYFLOAT increments the field of the hash at the key by incr, as object.Hash.HINCRBYFLOAT does,
+// where the hash is created if it does not exist.
+func (db *DB) HINCRBYFLOAT(key, field string, incr float64) (string, error) {
+	h, err := db.lookupOrCreateHash(key)
+	if err != nil {
+		return "", err
+	}
+	v, err := h.HINCRBYFLOAT(field, incr)
+	db.deleteIfEmptyHash(key, h)
+	return v, err
+}
+
+// lookupOrCreateHash returns the hash at the key, which is created if it does not exist.
+func (db *DB) lookupOrCreateHash(key string) (object.Hash, error) {
+	o, err := db.lookupOfType(key, object.OBJ_HASH)
+	if err != nil {
+		return object.Hash{}, err
+	}
+	if o != nil {
+		return object.Hash{ValueObject: o}, nil
+	}
+	h := object.NewHashObject()
+	db.set(key, h.ValueObject)
+	return h, nil
+}
+
+// deleteIfEmptyHash deletes the key if the hash at it is empty,
+// as a hash created for a command that adds no field is.
+func (db *DB) deleteIfEmptyHash(key string, h object.Hash) {
+	if h.HLEN() == 0 {
+		db.delete(key)
+	}
+}
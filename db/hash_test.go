@@ -0,0 +1,50 @@
+package db
+
+import (
+	"math"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func TestDB_Hash(t *testing.T) {
+	db := NewDB()
+	db.SET("str", []byte("v"))
+
+	if n, err := db.HSET("h", object.HashField{Field: "a", Value: "1"}, object.HashField{Field: "b", Value: "x"}); n != 2 || err != nil {
+		t.Errorf("HSET = %d, %v", n, err)
+	}
+	if ok, _ := db.HSETNX("h", "a", "2"); ok {
+		t.Errorf("HSETNX of an existing field")
+	}
+	if v, _ := db.HINCRBY("h", "a", 2); v != 3 {
+		t.Errorf("HINCRBY = %d", v)
+	}
+	if _, err := db.HINCRBY("h", "b", 1); err != object.ErrHashValueNotInteger {
+		t.Errorf("HINCRBY of a string err = %v", err)
+	}
+	if n, _ := db.HDEL("h", "a", "missing"); n != 1 {
+		t.Errorf("HDEL = %d", n)
+	}
+	if n, _ := db.HDEL("h", "b"); n != 1 || db.lookup("h") != nil {
+		t.Errorf("HDEL does not delete the emptied hash")
+	}
+	if n, err := db.HDEL("h", "b"); n != 0 || err != nil {
+		t.Errorf("HDEL of a missing key = %d, %v", n, err)
+	}
+
+	// a failed increment leaves no empty hash
+	if _, err := db.HINCRBYFLOAT("h", "f", math.Inf(1)); err != object.ErrIncrNaNOrInf || db.lookup("h") != nil {
+		t.Errorf("HINCRBYFLOAT to inf err = %v", err)
+	}
+	if v, _ := db.HINCRBYFLOAT("h", "f", 1.5); v != "1.5" {
+		t.Errorf("HINCRBYFLOAT = %s", v)
+	}
+
+	if _, err := db.HSET("str", object.HashField{Field: "a", Value: "1"}); err != ErrWrongType {
+		t.Errorf("HSET of a string err = %v", err)
+	}
+	if _, err := db.HDEL("str", "a"); err != ErrWrongType {
+		t.Errorf("HDEL of a string err = %v", err)
+	}
+}
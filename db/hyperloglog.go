@@ -0,0 +1,84 @@
+package db
+
+import (
+	"github.com/viktorxhzj/mykv/object"
+)
+
+// PFADD adds the elements to the HyperLogLog of the key, which is created if it does not exist,
+// and returns true if the key is created or its estimated cardinality may have changed.
+func (db *DB) PFADD(key string, elements ...[]byte) (bool, error) {
+	o, err := db.lookupHyperLogLog(key)
+	if err != nil {
+		return false, err
+	}
+	created := o == nil
+	if created {
+		o = object.NewHyperLogLogObject()
+		db.set(key, o)
+	} else {
+		o = db.unshareString(key, o)
+	}
+	updated, err := o.PFADD(elements...)
+	return created || updated, err
+}
+
+// PFCOUNT returns the estimated cardinality of the HyperLogLog of the key,
+// or of the union of the HyperLogLogs of the keys, where a missing key is empty.
+func (db *DB) PFCOUNT(keys ...string) (uint64, error) {
+	if len(keys) == 1 {
+		o, err := db.lookupHyperLogLog(keys[0])
+		if o == nil {
+			return 0, err
+		}
+		// the cardinality is cached in the value
+		return db.unshareString(keys[0], o).PFCOUNT()
+	}
+
+	objs, err := db.lookupHyperLogLogs(keys)
+	if err != nil {
+		return 0, err
+	}
+	return object.PFCOUNT(objs...)
+}
+
+// PFMERGE sets the HyperLogLog of dst to the union of the HyperLogLogs of dst and the source keys.
+func (db *DB) PFMERGE(dst string, keys ...string) error {
+	objs, err := db.lookupHyperLogLogs(append([]string{dst}, keys...))
+	if err != nil {
+		return err
+	}
+	o, err := object.PFMERGE(objs...)
+	if err != nil {
+		return err
+	}
+	db.set(dst, o)
+	return nil
+}
+
+// lookupHyperLogLog returns the string object of the key, or nil if the key does not exist.
+// If the value is not a HyperLogLog, it returns object.ErrNotHyperLogLog.
+func (db *DB) lookupHyperLogLog(key string) (*object.ValueObject, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if o == nil {
+		return nil, err
+	}
+	if !o.IsHyperLogLog() {
+		return nil, object.ErrNotHyperLogLog
+	}
+	return o, nil
+}
+
+// lookupHyperLogLogs returns the string objects of the keys that exist.
+func (db *DB) lookupHyperLogLogs(keys []string) ([]*object.ValueObject, error) {
+	var objs []*object.ValueObject
+	for _, key := range keys {
+		o, err := db.lookupHyperLogLog(key)
+		if err != nil {
+			return nil, err
+		}
+		if o != nil {
+			objs = append(objs, o)
+		}
+	}
+	return objs, nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func TestDB_HyperLogLog(t *testing.T) {
+	db := NewDB()
+	db.SET("str", []byte("v"))
+
+	if updated, err := db.PFADD("h1"); !updated || err != nil {
+		t.Errorf("PFADD creating the key = %v, %v", updated, err)
+	}
+	if updated, _ := db.PFADD("h1"); updated {
+		t.Errorf("PFADD without elements updated")
+	}
+	if updated, err := db.PFADD("h1", []byte("a"), []byte("b"), []byte("c")); !updated || err != nil {
+		t.Errorf("PFADD = %v, %v", updated, err)
+	}
+	if updated, _ := db.PFADD("h1", []byte("a")); updated {
+		t.Errorf("PFADD of an added element updated")
+	}
+	for i := 0; i < 100; i++ {
+		db.PFADD("h2", []byte(fmt.Sprint(i)))
+	}
+	db.PFADD("h2", []byte("a"))
+
+	if n, err := db.PFCOUNT("h1"); n != 3 || err != nil {
+		t.Errorf("PFCOUNT(h1) = %d, %v", n, err)
+	}
+	if n, err := db.PFCOUNT("h1", "h2", "missing"); n != 103 || err != nil {
+		t.Errorf("PFCOUNT(h1, h2) = %d, %v", n, err)
+	}
+	if n, err := db.PFCOUNT("missing"); n != 0 || err != nil {
+		t.Errorf("PFCOUNT of a missing key = %d, %v", n, err)
+	}
+
+	if err := db.PFMERGE("h3", "h1", "h2", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.PFCOUNT("h3"); n != 103 {
+		t.Errorf("PFCOUNT after PFMERGE = %d", n)
+	}
+	db.PFADD("h1", []byte("z"))
+	db.PFMERGE("h3", "h1")
+	if n, _ := db.PFCOUNT("h3"); n != 104 {
+		t.Errorf("PFCOUNT after PFMERGE into an existing key = %d", n)
+	}
+
+	// a HyperLogLog is a string, which round-trips through GET and SET
+	b, _ := db.GET("h1")
+	db.SET("copy", b)
+	db.PFADD("copy", []byte("y"))
+	if n, _ := db.PFCOUNT("copy"); n != 5 {
+		t.Errorf("PFCOUNT of a copy = %d", n)
+	}
+	if n, _ := db.PFCOUNT("h1"); n != 4 {
+		t.Errorf("PFCOUNT of the original after adding to its copy = %d", n)
+	}
+
+	if _, err := db.PFADD("str", []byte("a")); err != object.ErrNotHyperLogLog {
+		t.Errorf("PFADD to a string err = %v", err)
+	}
+	if _, err := db.PFCOUNT("h1", "str"); err != object.ErrNotHyperLogLog {
+		t.Errorf("PFCOUNT of a string err = %v", err)
+	}
+	db.addToSet("set", "a")
+	if err := db.PFMERGE("h1", "set"); err != ErrWrongType {
+		t.Errorf("PFMERGE of a set err = %v", err)
+	}
+
+	// the sparse opcodes cover fewer registers than there are
+	b, _ = db.GET("copy")
+	b = append(b[:len(b)-1:len(b)-1], 0)
+	b[15] |= 0x80
+	db.SET("bad", b)
+	if _, err := db.PFCOUNT("bad"); err != object.ErrHLLCorrupted {
+		t.Errorf("PFCOUNT of a corrupted HyperLogLog err = %v", err)
+	}
+}
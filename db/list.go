@@ -0,0 +1,186 @@
+package db
+
+import (
+	"time"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+// LPUSH inserts the elements at the head of the list at the key,
+// which is created if it does not exist, and returns the new length.
+func (db *DB) LPUSH(key string, elements ...string) (int, error) {
+	return db.pushList(key, elements, object.LIST_HEAD)
+}
+
+// RPUSH inserts the elements at the tail of the list at the key,
+// which is created if it does not exist, and returns the new length.
+func (db *DB) RPUSH(key string, elements ...string) (int, error) {
+	return db.pushList(key, elements, object.LIST_TAIL)
+}
+
+// LPUSHX inserts the elements at the head of the list at the key,
+// only if the key exists, and returns the new length.
+func (db *DB) LPUSHX(key string, elements ...string) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_LIST)
+	if o == nil {
+		return 0, err
+	}
+	return object.List{ValueObject: o}.LPUSH(elements...), nil
+}
+
+// RPUSHX inserts the elements at the tail of the list at the key,
+// only if the key exists, and returns the new length.
+func (db *DB) RPUSHX(key string, elements ...string) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_LIST)
+	if o == nil {
+		return 0, err
+	}
+	return object.List{ValueObject: o}.RPUSH(elements...), nil
+}
+
+// LPOP removes and returns at most count elements from the head of the list at the key,
+// which is deleted once empty.
+func (db *DB) LPOP(key string, count int) ([]string, error) {
+	return db.popList(key, count, object.LIST_HEAD)
+}
+
+// RPOP removes and returns at most count elements from the tail of the list at the key,
+// which is deleted once empty.
+func (db *DB) RPOP(key string, count int) ([]string, error) {
+	return db.popList(key, count, object.LIST_TAIL)
+}
+
+// LREM removes the occurrences of the element from the list at the key, as object.List.LREM does,
+// where the list is deleted once empty.
+func (db *DB) LREM(key string, count int, element string) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_LIST)
+	if o == nil {
+		return 0, err
+	}
+	l := object.List{ValueObject: o}
+	n := l.LREM(count, element)
+	if l.LLEN() == 0 {
+		db.delete(key)
+	}
+	return n, nil
+}
+
+// LTRIM keeps only the elements of the list at the key from index start to index stop (both inclusive),
+// as object.List.LTRIM does, where the list is deleted once empty.
+func (db *DB) LTRIM(key string, start, stop int) error {
+	o, err := db.lookupOfType(key, object.OBJ_LIST)
+	if o == nil {
+		return err
+	}
+	l := object.List{ValueObject: o}
+	l.LTRIM(start, stop)
+	if l.LLEN() == 0 {
+		db.delete(key)
+	}
+	return nil
+}
+
+// LSET sets the element at the index of the list at the key.
+// It returns ErrNoSuchKey if the key does not exist.
+func (db *DB) LSET(key string, idx int, element string) error {
+	o, err := db.lookupOfType(key, object.OBJ_LIST)
+	if err != nil {
+		return err
+	}
+	if o == nil {
+		return ErrNoSuchKey
+	}
+	return object.List{ValueObject: o}.LSET(idx, element)
+}
+
+// LMOVE pops an element from the head or the tail of the list at src,
+// pushes it to the head or the tail of the list at dst,
+// which is created if it does not exist, and returns it.
+// It returns false if src does not exist.
+func (db *DB) LMOVE(src, dst string, from, to int) (string, bool, error) {
+	srcObj, err := db.lookupOfType(src, object.OBJ_LIST)
+	if err != nil || srcObj == nil {
+		return "", false, err
+	}
+	dstObj, err := db.lookupOfType(dst, object.OBJ_LIST)
+	if err != nil {
+		return "", false, err
+	}
+
+	srcList := object.List{ValueObject: srcObj}
+	dstList := object.List{ValueObject: dstObj}
+	if dstObj == nil {
+		dstList = object.NewListObject()
+		db.set(dst, dstList.ValueObject)
+	}
+	e, ok := srcList.LMOVE(dstList, from, to)
+	if srcList.LLEN() == 0 {
+		db.delete(src)
+	}
+	return e, ok, nil
+}
+
+// BLPOP pops an element from the head of the first non-empty list at the keys,
+// or blocks the client until one of the keys gets a list, or the timeout passes.
+func (db *DB) BLPOP(timeout time.Duration, keys ...string) (*BlockedClient, error) {
+	return db.block(newBlockedClient(keys, object.OBJ_LIST, object.LIST_HEAD), timeout)
+}
+
+// BRPOP pops an element from the tail of the first non-empty list at the keys,
+// or blocks the client until one of the keys gets a list, or the timeout passes.
+func (db *DB) BRPOP(timeout time.Duration, keys ...string) (*BlockedClient, error) {
+	return db.block(newBlockedClient(keys, object.OBJ_LIST, object.LIST_TAIL), timeout)
+}
+
+// BLMOVE is LMOVE, or blocks the client until src gets a list, or the timeout passes.
+func (db *DB) BLMOVE(src, dst string, from, to int, timeout time.Duration) (*BlockedClient, error) {
+	c := newBlockedClient([]string{src}, object.OBJ_LIST, from)
+	c.move = true
+	c.dst = dst
+	c.to = to
+	return db.block(c, timeout)
+}
+
+// popList pops at most count elements from the head or the tail of the list at the key,
+// which is deleted once empty.
+func (db *DB) popList(key string, count int, where int) ([]string, error) {
+	if count < 0 {
+		return nil, object.ErrValueNotPositive
+	}
+	o, err := db.lookupOfType(key, object.OBJ_LIST)
+	if o == nil {
+		return nil, err
+	}
+	l := object.List{ValueObject: o}
+	var res []string
+	if where == object.LIST_HEAD {
+		res = l.LPOP(count)
+	} else {
+		res = l.RPOP(count)
+	}
+	if l.LLEN() == 0 {
+		db.delete(key)
+	}
+	return res, nil
+}
+
+// pushList inserts the elements at the head or the tail of the list at the key,
+// which is created if it does not exist, unless there are no elements to insert.
+func (db *DB) pushList(key string, elements []string, where int) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_LIST)
+	if err != nil {
+		return 0, err
+	}
+	if o == nil {
+		if len(elements) == 0 {
+			return 0, nil
+		}
+		o = object.NewListObject().ValueObject
+		db.set(key, o)
+	}
+	l := object.List{ValueObject: o}
+	if where == object.LIST_HEAD {
+		return l.LPUSH(elements...), nil
+	}
+	return l.RPUSH(elements...), nil
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func (db *DB) pushToList(key string, elements ...string) {
+	l := object.NewListObject()
+	l.RPUSH(elements...)
+	db.set(key, l.ValueObject)
+}
+
+func listOf(db *DB, key string) []string {
+	return object.List{ValueObject: db.lookup(key)}.LRANGE(0, -1)
+}
+
+func TestDB_Push(t *testing.T) {
+	db := NewDB()
+	if n, _ := db.RPUSH("l", "b", "c"); n != 2 {
+		t.Errorf("RPUSH = %d", n)
+	}
+	if n, _ := db.LPUSH("l", "a"); n != 3 {
+		t.Errorf("LPUSH = %d", n)
+	}
+	if got := listOf(db, "l"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("list = %v", got)
+	}
+
+	// no empty list is created
+	if n, err := db.RPUSH("empty"); n != 0 || err != nil || db.lookup("empty") != nil {
+		t.Errorf("RPUSH no elements = %d, %v", n, err)
+	}
+	if n, err := db.LPUSH("empty"); n != 0 || err != nil || db.lookup("empty") != nil {
+		t.Errorf("LPUSH no elements = %d, %v", n, err)
+	}
+	if n, _ := db.LPUSH("l"); n != 3 {
+		t.Errorf("LPUSH no elements = %d", n)
+	}
+
+	db.SET("str", []byte("v"))
+	if _, err := db.LPUSH("str", "a"); err != ErrWrongType {
+		t.Errorf("LPUSH string err = %v", err)
+	}
+}
+
+func TestDB_PushX(t *testing.T) {
+	db := NewDB()
+	db.SET("str", []byte("v"))
+
+	if n, err := db.LPUSHX("missing", "a"); n != 0 || err != nil || db.lookup("missing") != nil {
+		t.Errorf("LPUSHX missing key = %d, %v", n, err)
+	}
+	if _, err := db.RPUSHX("str", "a"); err != ErrWrongType {
+		t.Errorf("RPUSHX string err = %v", err)
+	}
+
+	db.pushToList("l", "b")
+	if n, _ := db.LPUSHX("l", "a"); n != 2 {
+		t.Errorf("LPUSHX = %d", n)
+	}
+	if n, _ := db.RPUSHX("l", "c", "d"); n != 4 {
+		t.Errorf("RPUSHX = %d", n)
+	}
+	if got := listOf(db, "l"); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("list = %v", got)
+	}
+}
+
+func TestDB_LMove(t *testing.T) {
+	db := NewDB()
+	db.pushToList("src", "a", "b")
+	db.SET("str", []byte("v"))
+
+	if e, ok, err := db.LMOVE("src", "dst", object.LIST_HEAD, object.LIST_TAIL); e != "a" || !ok || err != nil {
+		t.Errorf("LMOVE = %q, %v, %v", e, ok, err)
+	}
+	if _, _, err := db.LMOVE("src", "str", object.LIST_HEAD, object.LIST_TAIL); err != ErrWrongType {
+		t.Errorf("LMOVE to a string err = %v", err)
+	}
+	if e, ok, _ := db.LMOVE("src", "dst", object.LIST_TAIL, object.LIST_HEAD); e != "b" || !ok {
+		t.Errorf("LMOVE = %q, %v", e, ok)
+	}
+	if db.lookup("src") != nil {
+		t.Errorf("LMOVE does not delete the emptied source")
+	}
+	if got := listOf(db, "dst"); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("destination = %v", got)
+	}
+	if _, ok, err := db.LMOVE("src", "dst", object.LIST_TAIL, object.LIST_HEAD); ok || err != nil {
+		t.Errorf("LMOVE from a missing key = %v, %v", ok, err)
+	}
+	if _, _, err := db.LMOVE("str", "dst", object.LIST_TAIL, object.LIST_HEAD); err != ErrWrongType {
+		t.Errorf("LMOVE from a string err = %v", err)
+	}
+}
+
+func TestDB_ListRemoval(t *testing.T) {
+	db := NewDB()
+	db.SET("str", []byte("v"))
+	db.pushToList("l", "a", "b", "c", "b")
+
+	if got, err := db.LPOP("l", 1); !reflect.DeepEqual(got, []string{"a"}) || err != nil {
+		t.Errorf("LPOP = %v, %v", got, err)
+	}
+	if _, err := db.RPOP("l", -1); err != object.ErrValueNotPositive {
+		t.Errorf("RPOP -1 err = %v", err)
+	}
+	if err := db.LSET("l", 0, "x"); err != nil {
+		t.Errorf("LSET = %v", err)
+	}
+	if err := db.LSET("missing", 0, "x"); err != ErrNoSuchKey {
+		t.Errorf("LSET of a missing key err = %v", err)
+	}
+	if n, _ := db.LREM("l", 0, "b"); n != 1 {
+		t.Errorf("LREM = %d", n)
+	}
+	if got := listOf(db, "l"); !reflect.DeepEqual(got, []string{"x", "c"}) {
+		t.Errorf("list = %v", got)
+	}
+	if n, _ := db.LREM("l", 0, "x"); n != 1 {
+		t.Errorf("LREM = %d", n)
+	}
+	if got, _ := db.RPOP("l", 5); !reflect.DeepEqual(got, []string{"c"}) || db.lookup("l") != nil {
+		t.Errorf("RPOP does not delete the emptied list, popped %v", got)
+	}
+	if got, err := db.LPOP("l", 1); got != nil || err != nil {
+		t.Errorf("LPOP of a missing key = %v, %v", got, err)
+	}
+
+	db.pushToList("l", "a")
+	if n, _ := db.LREM("l", 1, "a"); n != 1 || db.lookup("l") != nil {
+		t.Errorf("LREM does not delete the emptied list")
+	}
+	db.pushToList("l", "a", "b")
+	if err := db.LTRIM("l", 2, -1); err != nil || db.lookup("l") != nil {
+		t.Errorf("LTRIM does not delete the emptied list, err = %v", err)
+	}
+
+	// a list emptied by LPOP never serves a blocked client
+	c, _ := db.BLPOP(0, "l")
+	db.pushToList("l", "a")
+	db.LPOP("l", 1)
+	db.HandleClientsBlockedOnKeys()
+	if isDone(c) {
+		t.Errorf("client served from an emptied list")
+	}
+
+	if _, err := db.LPOP("str", 1); err != ErrWrongType {
+		t.Errorf("LPOP from a string err = %v", err)
+	}
+	if _, err := db.LREM("str", 0, "a"); err != ErrWrongType {
+		t.Errorf("LREM from a string err = %v", err)
+	}
+	if err := db.LTRIM("str", 0, 1); err != ErrWrongType {
+		t.Errorf("LTRIM of a string err = %v", err)
+	}
+	if err := db.LSET("str", 0, "a"); err != ErrWrongType {
+		t.Errorf("LSET of a string err = %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+var (
+	ErrNoSuchKey       = errors.New("ERR no such key")
+	ErrUnknownEncoding = errors.New("ERR unknown encoding")
+)
+
+// OBJECTENCODING returns the name of the encoding of the object of the key,
+// and false if the key does not exist.
+func (db *DB) OBJECTENCODING(key string) (string, bool) {
+	o := db.lookup(key)
+	if o == nil {
+		return "", false
+	}
+	_, et := o.GetType()
+	return object.EncodingName(et), true
+}
+
+// OBJECTREFCOUNT returns the reference count of the object of the key,
+// and false if the key does not exist.
+func (db *DB) OBJECTREFCOUNT(key string) (int, bool) {
+	o := db.lookup(key)
+	if o == nil {
+		return 0, false
+	}
+	return o.RefCount(), true
+}
+
+// DEBUGCONVERT converts the object of the key to the encoding of the name,
+// as object.Convert does, regardless of the thresholds of its type.
+// A shared object is replaced with a converted copy of it.
+func (db *DB) DEBUGCONVERT(key, encoding string) error {
+	o := db.lookup(key)
+	if o == nil {
+		return ErrNoSuchKey
+	}
+	et, ok := object.EncodingByName(encoding)
+	if !ok {
+		return ErrUnknownEncoding
+	}
+
+	_, cur := o.GetType()
+	shared := o.IsShared() && cur != et
+	if shared {
+		o = object.UnshareString(o)
+	}
+	if err := object.Convert(o, et); err != nil {
+		return err
+	}
+	if shared {
+		db.set(key, o)
+	}
+	return nil
+}
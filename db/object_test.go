@@ -0,0 +1,87 @@
+package db
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func TestDB_ObjectEncoding(t *testing.T) {
+	db := NewDB()
+	db.SET("int", []byte("12"))
+	db.SET("embstr", []byte("v"))
+	db.SET("raw", []byte(strings.Repeat("v", object.OBJ_ENCODING_EMBSTR_SIZE_LIMIT+1)))
+	db.RPUSH("list", "a")
+	db.addToSet("intset", "1")
+	db.addToSet("hashtable", "a")
+	db.ZADD("ziplist", 0, object.ZSetMember{Key: "a", Value: 1})
+	db.ZADD("skiplist", 0, object.ZSetMember{Key: strings.Repeat("m", 65), Value: 1})
+
+	for _, key := range []string{"int", "embstr", "raw", "list", "intset", "hashtable", "ziplist", "skiplist"} {
+		want := key
+		if key == "list" {
+			want = "quicklist"
+		}
+		if got, ok := db.OBJECTENCODING(key); !ok || got != want {
+			t.Errorf("OBJECT ENCODING %s = %q, %v", key, got, ok)
+		}
+	}
+	if _, ok := db.OBJECTENCODING("missing"); ok {
+		t.Errorf("OBJECT ENCODING of a missing key")
+	}
+
+	if n, ok := db.OBJECTREFCOUNT("int"); !ok || n != math.MaxInt32 {
+		t.Errorf("OBJECT REFCOUNT of a shared integer = %d, %v", n, ok)
+	}
+	if n, ok := db.OBJECTREFCOUNT("embstr"); !ok || n != 1 {
+		t.Errorf("OBJECT REFCOUNT = %d, %v", n, ok)
+	}
+	if _, ok := db.OBJECTREFCOUNT("missing"); ok {
+		t.Errorf("OBJECT REFCOUNT of a missing key")
+	}
+}
+
+func TestDB_DebugConvert(t *testing.T) {
+	db := NewDB()
+	db.addToSet("s", "1", "2")
+	check := func(key, encoding string, want error) {
+		t.Helper()
+		if err := db.DEBUGCONVERT(key, encoding); err != want {
+			t.Errorf("DEBUG CONVERT %s %s = %v, want %v", key, encoding, err, want)
+		}
+	}
+
+	check("s", "hashtable", nil)
+	check("s", "intset", nil)
+	if got, _ := db.OBJECTENCODING("s"); got != "intset" {
+		t.Errorf("OBJECT ENCODING = %q", got)
+	}
+	check("s", "skiplist", object.ErrUnsupportedConversion)
+	check("s", "listpack", ErrUnknownEncoding)
+	check("missing", "raw", ErrNoSuchKey)
+
+	// a shared integer is replaced rather than converted
+	db.SET("n", []byte("7"))
+	check("n", "int", nil)
+	check("n", "raw", nil)
+	if got, _ := db.OBJECTENCODING("n"); got != "raw" {
+		t.Errorf("OBJECT ENCODING = %q", got)
+	}
+	if v, _ := db.GET("n"); string(v) != "7" {
+		t.Errorf("GET = %q", v)
+	}
+	if n, _ := db.OBJECTREFCOUNT("n"); n != 1 {
+		t.Errorf("OBJECT REFCOUNT = %d", n)
+	}
+	if o := object.NewStringObjectFromInt64(7); !o.IsShared() || o.RefCount() != math.MaxInt32 {
+		t.Errorf("the shared integer is converted")
+	}
+
+	db.SET("str", []byte("abc"))
+	check("str", "int", object.ErrNotEncodable)
+	if v, _ := db.INCR("n"); v != 8 {
+		t.Errorf("INCR after DEBUG CONVERT = %d", v)
+	}
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"github.com/viktorxhzj/mykv/object"
+)
+
+// SADD adds the members to the set at the key, which is created if it does not exist,
+// and returns the number of members that are new.
+func (db *DB) SADD(key string, members ...string) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_SET)
+	if err != nil {
+		return 0, err
+	}
+	if o != nil {
+		return object.Set{ValueObject: o}.SADD(members...), nil
+	}
+	s := object.NewSetObject()
+	n := s.SADD(members...)
+	if n > 0 {
+		db.set(key, s.ValueObject)
+	}
+	return n, nil
+}
+
+// SREM removes the members from the set at the key, which is deleted once empty,
+// and returns the number of members that are removed.
+func (db *DB) SREM(key string, members ...string) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_SET)
+	if o == nil {
+		return 0, err
+	}
+	s := object.Set{ValueObject: o}
+	n := s.SREM(members...)
+	if s.SCARD() == 0 {
+		db.delete(key)
+	}
+	return n, nil
+}
+
+// SPOP removes and returns count random members from the set at the key,
+// as object.Set.SPOP does, where the set is deleted once empty.
+func (db *DB) SPOP(key string, count int) ([]string, error) {
+	o, err := db.lookupOfType(key, object.OBJ_SET)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		if count < 0 {
+			return nil, object.ErrValueNotPositive
+		}
+		return nil, nil
+	}
+	s := object.Set{ValueObject: o}
+	res, err := s.SPOP(count)
+	if s.SCARD() == 0 {
+		db.delete(key)
+	}
+	return res, err
+}
+
+// SMOVE moves the member from the set at src to the set at dst,
+// which is created if it does not exist,
+// and returns false if the member is not in the set at src.
+func (db *DB) SMOVE(src, dst, member string) (bool, error) {
+	srcObj, err := db.lookupOfType(src, object.OBJ_SET)
+	if err != nil {
+		return false, err
+	}
+	dstObj, err := db.lookupOfType(dst, object.OBJ_SET)
+	if err != nil || srcObj == nil {
+		return false, err
+	}
+
+	srcSet := object.Set{ValueObject: srcObj}
+	if srcObj == dstObj {
+		return srcSet.SISMEMBER(member), nil
+	}
+	if !srcSet.SISMEMBER(member) {
+		return false, nil
+	}
+
+	dstSet := object.Set{ValueObject: dstObj}
+	if dstObj == nil {
+		dstSet = object.NewSetObject()
+		db.set(dst, dstSet.ValueObject)
+	}
+	srcSet.SMOVE(dstSet, member)
+	if srcSet.SCARD() == 0 {
+		db.delete(src)
+	}
+	return true, nil
+}
+
+// SINTER returns the members that are in all the sets at the keys.
+func (db *DB) SINTER(keys ...string) ([]string, error) {
+	sets, err := db.lookupSets(keys)
+	if err != nil {
+		return nil, err
+	}
+	return object.SINTER(sets...).SMEMBERS(), nil
+}
+
+// SINTERSTORE stores the members that are in all the sets at the keys at dst,
+// and returns the number of them.
+func (db *DB) SINTERSTORE(dst string, keys ...string) (int, error) {
+	sets, err := db.lookupSets(keys)
+	if err != nil {
+		return 0, err
+	}
+	return db.storeSet(dst, object.SINTER(sets...)), nil
+}
+
+// SINTERCARD returns the number of members that are in all the sets at the keys,
+// counting no further than limit if limit is positive.
+func (db *DB) SINTERCARD(limit int, keys ...string) (int, error) {
+	sets, err := db.lookupSets(keys)
+	if err != nil {
+		return 0, err
+	}
+	return object.SINTERCARD(limit, sets...)
+}
+
+// SUNION returns the members that are in any of the sets at the keys.
+func (db *DB) SUNION(keys ...string) ([]string, error) {
+	sets, err := db.lookupSets(keys)
+	if err != nil {
+		return nil, err
+	}
+	return object.SUNION(sets...).SMEMBERS(), nil
+}
+
+// SUNIONSTORE stores the members that are in any of the sets at the keys at dst,
+// and returns the number of them.
+func (db *DB) SUNIONSTORE(dst string, keys ...string) (int, error) {
+	sets, err := db.lookupSets(keys)
+	if err != nil {
+		return 0, err
+	}
+	return db.storeSet(dst, object.SUNION(sets...)), nil
+}
+
+// SDIFF returns the members of the set at the first key that are in none of the others.
+func (db *DB) SDIFF(keys ...string) ([]string, error) {
+	sets, err := db.lookupSets(keys)
+	if err != nil {
+		return nil, err
+	}
+	return object.SDIFF(sets...).SMEMBERS(), nil
+}
+
+// SDIFFSTORE stores the members of the set at the first key that are in none of the others at dst,
+// and returns the number of them.
+func (db *DB) SDIFFSTORE(dst string, keys ...string) (int, error) {
+	sets, err := db.lookupSets(keys)
+	if err != nil {
+		return 0, err
+	}
+	return db.storeSet(dst, object.SDIFF(sets...)), nil
+}
+
+// lookupSets returns the sets at the keys, where a missing key is an empty set.
+func (db *DB) lookupSets(keys []string) ([]object.Set, error) {
+	sets := make([]object.Set, len(keys))
+	for i, key := range keys {
+		o, err := db.lookupOfType(key, object.OBJ_SET)
+		if err != nil {
+			return nil, err
+		}
+		if o == nil {
+			sets[i] = object.NewSetObject()
+		} else {
+			sets[i] = object.Set{ValueObject: o}
+		}
+	}
+	return sets, nil
+}
+
+// storeSet stores the set at dst, overwriting the old value of any type,
+// or deletes dst if the set is empty, and returns the size of the set.
+func (db *DB) storeSet(dst string, s object.Set) int {
+	n := s.SCARD()
+	if n == 0 {
+		db.delete(dst)
+	} else {
+		db.set(dst, s.ValueObject)
+	}
+	return n
+}
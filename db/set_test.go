@@ -0,0 +1,137 @@
+package db
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func (db *DB) addToSet(key string, members ...string) {
+	s := object.NewSetObject()
+	s.SADD(members...)
+	db.set(key, s.ValueObject)
+}
+
+func TestDB_SetAlgebra(t *testing.T) {
+	db := NewDB()
+	db.addToSet("a", "1", "2", "x")
+	db.addToSet("b", "2", "x", "y")
+	db.SET("str", []byte("v"))
+
+	check := func(got []string, err error, want ...string) {
+		t.Helper()
+		sort.Strings(got)
+		if err != nil || len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Errorf("got %v, %v, want %v", got, err, want)
+		}
+	}
+
+	got, err := db.SINTER("a", "b")
+	check(got, err, "2", "x")
+	got, err = db.SINTER("a", "missing")
+	check(got, err)
+	got, err = db.SUNION("a", "missing", "b")
+	check(got, err, "1", "2", "x", "y")
+	got, err = db.SDIFF("a", "b")
+	check(got, err, "1")
+	got, err = db.SDIFF("missing", "a")
+	check(got, err)
+
+	if n, _ := db.SINTERSTORE("dst", "a", "b"); n != 2 {
+		t.Errorf("SINTERSTORE = %d", n)
+	}
+	got, err = db.SUNION("dst")
+	check(got, err, "2", "x")
+	if n, _ := db.SUNIONSTORE("str", "a", "b"); n != 4 {
+		t.Errorf("SUNIONSTORE over a string = %d", n)
+	}
+	if n, _ := db.SDIFFSTORE("dst", "a", "a"); n != 0 || db.lookup("dst") != nil {
+		t.Errorf("SDIFFSTORE of empty result = %d, key exists %v", n, db.lookup("dst") != nil)
+	}
+	if n, _ := db.SINTERCARD(0, "a", "b"); n != 2 {
+		t.Errorf("SINTERCARD = %d", n)
+	}
+
+	db.SET("str", []byte("v"))
+	if _, err := db.SINTER("a", "str"); err != ErrWrongType {
+		t.Errorf("SINTER with a string err = %v", err)
+	}
+	if _, err := db.SINTERSTORE("dst", "str"); err != ErrWrongType {
+		t.Errorf("SINTERSTORE with a string err = %v", err)
+	}
+}
+
+func TestDB_SMove(t *testing.T) {
+	db := NewDB()
+	db.addToSet("src", "a", "b")
+	db.SET("str", []byte("v"))
+
+	if ok, _ := db.SMOVE("src", "dst", "a"); !ok {
+		t.Errorf("SMOVE to a missing key")
+	}
+	if ok, _ := db.SMOVE("src", "dst", "missing"); ok {
+		t.Errorf("SMOVE a missing member")
+	}
+	if ok, _ := db.SMOVE("src", "src", "b"); !ok {
+		t.Errorf("SMOVE to the same key")
+	}
+	if ok, _ := db.SMOVE("src", "dst", "b"); !ok || db.lookup("src") != nil {
+		t.Errorf("SMOVE does not delete the emptied source")
+	}
+	got, _ := db.SUNION("dst")
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("destination = %v", got)
+	}
+
+	if ok, err := db.SMOVE("missing", "dst", "a"); ok || err != nil {
+		t.Errorf("SMOVE from a missing key = %v, %v", ok, err)
+	}
+	if _, err := db.SMOVE("dst", "str", "a"); err != ErrWrongType {
+		t.Errorf("SMOVE to a string err = %v", err)
+	}
+	if _, err := db.SMOVE("str", "dst", "a"); err != ErrWrongType {
+		t.Errorf("SMOVE from a string err = %v", err)
+	}
+}
+
+func TestDB_SetAddRemove(t *testing.T) {
+	db := NewDB()
+	db.SET("str", []byte("v"))
+
+	if n, err := db.SADD("s", "a", "b", "a"); n != 2 || err != nil {
+		t.Errorf("SADD = %d, %v", n, err)
+	}
+	if n, _ := db.SREM("s", "a", "x"); n != 1 {
+		t.Errorf("SREM = %d", n)
+	}
+	if n, _ := db.SREM("s", "b"); n != 1 || db.lookup("s") != nil {
+		t.Errorf("SREM does not delete the emptied set")
+	}
+
+	db.SADD("s", "a", "b", "c")
+	if _, err := db.SPOP("s", -1); err != object.ErrValueNotPositive || db.lookup("s") == nil {
+		t.Errorf("SPOP -1 err = %v", err)
+	}
+	if popped, _ := db.SPOP("s", 2); len(popped) != 2 || db.lookup("s") == nil {
+		t.Errorf("SPOP 2 = %v", popped)
+	}
+	if popped, _ := db.SPOP("s", 2); len(popped) != 1 || db.lookup("s") != nil {
+		t.Errorf("SPOP does not delete the emptied set, popped %v", popped)
+	}
+	if popped, err := db.SPOP("missing", 1); popped != nil || err != nil {
+		t.Errorf("SPOP of a missing key = %v, %v", popped, err)
+	}
+
+	if _, err := db.SADD("str", "a"); err != ErrWrongType {
+		t.Errorf("SADD to a string err = %v", err)
+	}
+	if _, err := db.SREM("str", "a"); err != ErrWrongType {
+		t.Errorf("SREM from a string err = %v", err)
+	}
+	if _, err := db.SPOP("str", 1); err != ErrWrongType {
+		t.Errorf("SPOP from a string err = %v", err)
+	}
+}
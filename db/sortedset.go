@@ -0,0 +1,142 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+var (
+	ErrZSetNoKeys = errors.New("ERR at least 1 input key is needed for this command")
+)
+
+// ZADD adds the members to the sorted set at the key, as object.SortedSet.ZADD does,
+// where the sorted set is created if it does not exist and a member is added.
+func (db *DB) ZADD(key string, flags int, members ...object.ZSetMember) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_ZSET)
+	if err != nil {
+		return 0, err
+	}
+	if o != nil {
+		return object.SortedSet{ValueObject: o}.ZADD(flags, members...)
+	}
+
+	z := object.NewSortedSetObject()
+	n, err := z.ZADD(flags, members...)
+	if z.ZCARD() > 0 {
+		db.set(key, z.ValueObject)
+	}
+	return n, err
+}
+
+// BZPOPMIN pops the member with the lowest score from the first non-empty sorted set at the keys,
+// or blocks the client until one of the keys gets a sorted set, or the timeout passes.
+func (db *DB) BZPOPMIN(timeout time.Duration, keys ...string) (*BlockedClient, error) {
+	return db.block(newBlockedClient(keys, object.OBJ_ZSET, object.LIST_HEAD), timeout)
+}
+
+// BZPOPMAX pops the member with the highest score from the first non-empty sorted set at the keys,
+// or blocks the client until one of the keys gets a sorted set, or the timeout passes.
+func (db *DB) BZPOPMAX(timeout time.Duration, keys ...string) (*BlockedClient, error) {
+	return db.block(newBlockedClient(keys, object.OBJ_ZSET, object.LIST_TAIL), timeout)
+}
+
+// ZUNION returns the members that are in any of the sorted sets or sets at the keys,
+// with their scores multiplied by the weights and then aggregated,
+// where nil weights are all 1.
+func (db *DB) ZUNION(keys []string, weights []float64, aggregate int) ([]object.ZSetMember, error) {
+	operands, err := db.lookupZSetOperands(keys, weights, aggregate)
+	if err != nil {
+		return nil, err
+	}
+	return object.ZUNION(weights, aggregate, operands...), nil
+}
+
+// ZUNIONSTORE stores the result of ZUNION at dst, and returns the number of members.
+func (db *DB) ZUNIONSTORE(dst string, keys []string, weights []float64, aggregate int) (int, error) {
+	members, err := db.ZUNION(keys, weights, aggregate)
+	if err != nil {
+		return 0, err
+	}
+	return db.storeZSet(dst, members), nil
+}
+
+// ZINTER returns the members that are in all the sorted sets or sets at the keys,
+// with their scores multiplied by the weights and then aggregated,
+// where nil weights are all 1.
+func (db *DB) ZINTER(keys []string, weights []float64, aggregate int) ([]object.ZSetMember, error) {
+	operands, err := db.lookupZSetOperands(keys, weights, aggregate)
+	if err != nil {
+		return nil, err
+	}
+	return object.ZINTER(weights, aggregate, operands...), nil
+}
+
+// ZINTERSTORE stores the result of ZINTER at dst, and returns the number of members.
+func (db *DB) ZINTERSTORE(dst string, keys []string, weights []float64, aggregate int) (int, error) {
+	members, err := db.ZINTER(keys, weights, aggregate)
+	if err != nil {
+		return 0, err
+	}
+	return db.storeZSet(dst, members), nil
+}
+
+// ZDIFF returns the members of the sorted set or set at the first key
+// that are in none of the others.
+func (db *DB) ZDIFF(keys ...string) ([]object.ZSetMember, error) {
+	operands, err := db.lookupZSetOperands(keys, nil, object.ZAGGREGATE_SUM)
+	if err != nil {
+		return nil, err
+	}
+	return object.ZDIFF(operands...), nil
+}
+
+// ZDIFFSTORE stores the result of ZDIFF at dst, and returns the number of members.
+func (db *DB) ZDIFFSTORE(dst string, keys ...string) (int, error) {
+	members, err := db.ZDIFF(keys...)
+	if err != nil {
+		return 0, err
+	}
+	return db.storeZSet(dst, members), nil
+}
+
+// lookupZSetOperands checks the options of sorted set algebra,
+// and returns the sorted sets or sets at the keys, where a missing key is an empty sorted set.
+func (db *DB) lookupZSetOperands(keys []string, weights []float64, aggregate int) ([]object.ZSetOperand, error) {
+	if len(keys) == 0 {
+		return nil, ErrZSetNoKeys
+	}
+	if (weights != nil && len(weights) != len(keys)) || aggregate < object.ZAGGREGATE_SUM || aggregate > object.ZAGGREGATE_MAX {
+		return nil, ErrSyntax
+	}
+
+	operands := make([]object.ZSetOperand, len(keys))
+	for i, key := range keys {
+		o := db.lookup(key)
+		if o == nil {
+			operands[i] = object.NewSortedSetObject()
+			continue
+		}
+		switch t, _ := o.GetType(); t {
+		case object.OBJ_ZSET:
+			operands[i] = object.SortedSet{ValueObject: o}
+		case object.OBJ_SET:
+			operands[i] = object.SetOperand{Set: object.Set{ValueObject: o}}
+		default:
+			return nil, ErrWrongType
+		}
+	}
+	return operands, nil
+}
+
+// storeZSet stores a sorted set of the members at dst, overwriting the old value of any type,
+// or deletes dst if there are no members, and returns the number of members.
+func (db *DB) storeZSet(dst string, members []object.ZSetMember) int {
+	if len(members) == 0 {
+		db.delete(dst)
+		return 0
+	}
+	db.set(dst, object.NewSortedSetObjectFromMembers(members).ValueObject)
+	return len(members)
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func (db *DB) addToZSet(key string, members ...object.ZSetMember) {
+	z := object.NewSortedSetObject()
+	z.ZADD(0, members...)
+	db.set(key, z.ValueObject)
+}
+
+func TestDB_ZSetAlgebra(t *testing.T) {
+	db := NewDB()
+	db.addToZSet("z1", object.ZSetMember{Key: "a", Value: 1}, object.ZSetMember{Key: "b", Value: 2})
+	db.addToZSet("z2", object.ZSetMember{Key: "b", Value: 3}, object.ZSetMember{Key: "c", Value: 5})
+	db.addToSet("s", "a", "c")
+	db.SET("str", []byte("v"))
+	db.SET("str2", []byte("v"))
+
+	got, err := db.ZUNION([]string{"z1", "z2", "s", "missing"}, []float64{2, 1, 1, 1}, object.ZAGGREGATE_SUM)
+	want := []object.ZSetMember{{Key: "a", Value: 3}, {Key: "c", Value: 6}, {Key: "b", Value: 7}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ZUNION = %v, %v", got, err)
+	}
+	got, err = db.ZINTER([]string{"z1", "missing"}, nil, object.ZAGGREGATE_SUM)
+	if err != nil || len(got) != 0 {
+		t.Errorf("ZINTER with a missing key = %v, %v", got, err)
+	}
+	got, err = db.ZDIFF("s", "z1")
+	if err != nil || !reflect.DeepEqual(got, []object.ZSetMember{{Key: "c", Value: 1}}) {
+		t.Errorf("ZDIFF = %v, %v", got, err)
+	}
+
+	if n, err := db.ZINTERSTORE("dst", []string{"z1", "z2"}, nil, object.ZAGGREGATE_MAX); n != 1 || err != nil {
+		t.Errorf("ZINTERSTORE = %d, %v", n, err)
+	}
+	if o, _ := db.lookupOfType("dst", object.OBJ_ZSET); o == nil {
+		t.Fatalf("ZINTERSTORE stored nothing")
+	} else if s, _ := (object.SortedSet{ValueObject: o}).ZSCORE("b"); s != 3 {
+		t.Errorf("stored score = %v", s)
+	}
+	// an empty result deletes dst, even if it is the source
+	if n, err := db.ZDIFFSTORE("dst", "dst", "z2"); n != 0 || err != nil || db.lookup("dst") != nil {
+		t.Errorf("ZDIFFSTORE = %d, %v", n, err)
+	}
+	if n, err := db.ZUNIONSTORE("str2", []string{"z1"}, nil, object.ZAGGREGATE_SUM); n != 2 || err != nil {
+		t.Errorf("ZUNIONSTORE over a string = %d, %v", n, err)
+	}
+
+	if _, err := db.ZUNION([]string{"z1", "str"}, nil, object.ZAGGREGATE_SUM); err != ErrWrongType {
+		t.Errorf("ZUNION with a string = %v", err)
+	}
+	if _, err := db.ZUNION(nil, nil, object.ZAGGREGATE_SUM); err != ErrZSetNoKeys {
+		t.Errorf("ZUNION without keys = %v", err)
+	}
+	if _, err := db.ZINTER([]string{"z1", "z2"}, []float64{1}, object.ZAGGREGATE_SUM); err != ErrSyntax {
+		t.Errorf("ZINTER with missing weights = %v", err)
+	}
+}
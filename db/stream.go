@@ -0,0 +1,325 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+var (
+	ErrStreamUnbalanced   = errors.New("ERR Unbalanced 'xread' list of streams: for each stream key an ID or '$' must be specified.")
+	ErrStreamGreaterID    = errors.New("ERR The > ID can be specified only when calling XREADGROUP using the GROUP <group> <consumer> option.")
+	ErrStreamGroupNoKey   = errors.New("ERR The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+	ErrStreamNoGroupOrKey = object.ErrStreamNoGroup
+)
+
+// XReadArgs are the arguments of XREAD and XREADGROUP.
+type XReadArgs struct {
+	Keys []string
+	IDs  []string // "$" for the entries added afterwards, or ">" for the entries never delivered to the group
+
+	Count   int           // the maximum number of entries read from each stream if positive
+	Block   bool          // block if there are no entries
+	Timeout time.Duration // Block only, 0 for forever
+
+	// XREADGROUP only
+	Group, Consumer string
+	NoAck           bool
+}
+
+// StreamRead is the entries read from the stream at the key.
+type StreamRead struct {
+	Key     string
+	Entries []object.StreamEntry
+}
+
+// XADD appends an entry of the fields and values to the stream at the key,
+// which is created if it does not exist unless noMkStream,
+// then trims the stream if trim is not nil.
+// It returns the ID of the entry, and false if the key does not exist and noMkStream.
+func (db *DB) XADD(key string, noMkStream bool, trim *object.StreamTrimArgs, id string, fields ...string) (object.StreamID, bool, error) {
+	if trim != nil {
+		if err := trim.Validate(); err != nil {
+			return object.StreamID{}, false, err
+		}
+	}
+	o, err := db.lookupOfType(key, object.OBJ_STREAM)
+	if err != nil || (o == nil && noMkStream) {
+		return object.StreamID{}, false, err
+	}
+
+	s := object.Stream{ValueObject: o}
+	if o == nil {
+		s = object.NewStreamObject()
+	}
+	newID, err := s.XADD(id, fields...)
+	if err != nil {
+		return object.StreamID{}, false, err
+	}
+	if o == nil {
+		db.set(key, s.ValueObject)
+	} else {
+		db.signalKeyAsReady(key)
+	}
+	if trim != nil {
+		s.XTRIM(*trim)
+	}
+	return newID, true, nil
+}
+
+// XLEN returns the number of entries of the stream at the key.
+func (db *DB) XLEN(key string) (int, error) {
+	s, ok, err := db.lookupStream(key)
+	if !ok {
+		return 0, err
+	}
+	return s.XLEN(), nil
+}
+
+// XRANGE returns the entries of the stream at the key whose IDs are within [start, end],
+// no more than count if count is positive.
+func (db *DB) XRANGE(key string, start, end object.StreamID, count int) ([]object.StreamEntry, error) {
+	s, ok, err := db.lookupStream(key)
+	if !ok {
+		return nil, err
+	}
+	return s.XRANGE(start, end, count), nil
+}
+
+// XREVRANGE is XRANGE in reverse order.
+func (db *DB) XREVRANGE(key string, end, start object.StreamID, count int) ([]object.StreamEntry, error) {
+	s, ok, err := db.lookupStream(key)
+	if !ok {
+		return nil, err
+	}
+	return s.XREVRANGE(end, start, count), nil
+}
+
+// XDEL deletes the entries of the stream at the key, and returns the number of deleted ones.
+func (db *DB) XDEL(key string, ids ...object.StreamID) (int, error) {
+	s, ok, err := db.lookupStream(key)
+	if !ok {
+		return 0, err
+	}
+	return s.XDEL(ids...), nil
+}
+
+// XTRIM trims the stream at the key, and returns the number of deleted entries.
+func (db *DB) XTRIM(key string, args object.StreamTrimArgs) (int, error) {
+	if err := args.Validate(); err != nil {
+		return 0, err
+	}
+	s, ok, err := db.lookupStream(key)
+	if !ok {
+		return 0, err
+	}
+	return s.XTRIM(args)
+}
+
+// XREAD reads the entries after the IDs from the streams at the keys.
+// If there are no entries and args.Block, it blocks the client
+// until an entry is added to one of the streams, or the timeout passes.
+// It returns the entries read at once, or the blocked client.
+func (db *DB) XREAD(args XReadArgs) ([]StreamRead, *BlockedClient, error) {
+	if len(args.Keys) != len(args.IDs) {
+		return nil, nil, ErrStreamUnbalanced
+	}
+
+	ids := make(map[string]object.StreamID, len(args.Keys))
+	var reads []StreamRead
+	for i, key := range args.Keys {
+		s, ok, err := db.lookupStream(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		var id object.StreamID
+		switch args.IDs[i] {
+		case "$":
+			if ok {
+				id = s.LastID()
+			}
+		case ">":
+			return nil, nil, ErrStreamGreaterID
+		default:
+			if id, err = object.ParseStreamID(args.IDs[i], 0); err != nil {
+				return nil, nil, err
+			}
+		}
+		ids[key] = id
+
+		start, more := id.Incr()
+		if !ok || !more {
+			continue
+		}
+		if entries := s.XRANGE(start, s.LastID(), args.Count); len(entries) > 0 {
+			reads = append(reads, StreamRead{key, entries})
+		}
+	}
+	if len(reads) > 0 || !args.Block {
+		return reads, nil, nil
+	}
+
+	c := newBlockedClient(args.Keys, object.OBJ_STREAM, 0)
+	c.xread = &args
+	c.streamIDs = ids
+	return db.blockStreamClient(c, args.Timeout)
+}
+
+// XREADGROUP reads the entries from the streams at the keys for the consumer of the group.
+// An ID of ">" delivers the entries never delivered to the group,
+// while another ID reads the entries pending for the consumer after it.
+// If there are no entries to deliver and args.Block, it blocks the client
+// until an entry is added to one of the streams, or the timeout passes.
+// It returns the entries read at once, or the blocked client.
+func (db *DB) XREADGROUP(args XReadArgs) ([]StreamRead, *BlockedClient, error) {
+	if len(args.Keys) != len(args.IDs) {
+		return nil, nil, ErrStreamUnbalanced
+	}
+
+	// every stream and its group are checked before any entry is delivered
+	streams := make([]object.Stream, len(args.Keys))
+	afters := make([]object.StreamID, len(args.Keys))
+	history := false
+	for i, key := range args.Keys {
+		s, ok, err := db.lookupStream(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok || !s.HasGroup(args.Group) {
+			return nil, nil, ErrStreamNoGroupOrKey
+		}
+		streams[i] = s
+		if args.IDs[i] != ">" {
+			if afters[i], err = object.ParseStreamID(args.IDs[i], 0); err != nil {
+				return nil, nil, err
+			}
+			history = true
+		}
+	}
+
+	var reads []StreamRead
+	for i, key := range args.Keys {
+		newEntries := args.IDs[i] == ">"
+		entries, err := streams[i].XREADGROUP(args.Group, args.Consumer, afters[i], newEntries, args.Count, args.NoAck)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the pending entries are replied even if there are none
+		if len(entries) > 0 || !newEntries {
+			reads = append(reads, StreamRead{key, entries})
+		}
+	}
+	if len(reads) > 0 || history || !args.Block {
+		return reads, nil, nil
+	}
+
+	c := newBlockedClient(args.Keys, object.OBJ_STREAM, 0)
+	c.xread = &args
+	return db.blockStreamClient(c, args.Timeout)
+}
+
+// XGROUPCREATE creates a group of the stream at the key, which reads the entries after id,
+// where "$" is the last ID of the stream.
+// The stream is created if it does not exist and mkStream.
+func (db *DB) XGROUPCREATE(key, group, id string, mkStream bool) error {
+	s, ok, err := db.lookupStream(key)
+	if err != nil {
+		return err
+	}
+	if !ok && !mkStream {
+		return ErrStreamGroupNoKey
+	}
+
+	var lastID object.StreamID
+	if id == "$" {
+		if ok {
+			lastID = s.LastID()
+		}
+	} else if lastID, err = object.ParseStreamID(id, 0); err != nil {
+		return err
+	}
+
+	if !ok {
+		s = object.NewStreamObject()
+		db.set(key, s.ValueObject)
+	}
+	return s.XGROUPCREATE(group, lastID)
+}
+
+// XACK acknowledges the pending entries of the group of the stream at the key,
+// and returns the number of them.
+func (db *DB) XACK(key, group string, ids ...object.StreamID) (int, error) {
+	s, ok, err := db.lookupStream(key)
+	if !ok {
+		return 0, err
+	}
+	return s.XACK(group, ids...), nil
+}
+
+// XPENDING returns the summary of the pending entries of the group of the stream at the key.
+func (db *DB) XPENDING(key, group string) (object.StreamPendingSummary, error) {
+	s, err := db.lookupStreamForGroup(key)
+	if err != nil {
+		return object.StreamPendingSummary{}, err
+	}
+	return s.XPENDING(group)
+}
+
+// XPENDINGRANGE returns the pending entries of the group of the stream at the key,
+// as object.Stream.XPENDINGRANGE does.
+func (db *DB) XPENDINGRANGE(key, group string, minIdle time.Duration, start, end object.StreamID, count int, consumer string) ([]object.StreamPendingEntry, error) {
+	s, err := db.lookupStreamForGroup(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.XPENDINGRANGE(group, minIdle, start, end, count, consumer)
+}
+
+// XCLAIM transfers the pending entries of the group of the stream at the key to the consumer,
+// as object.Stream.XCLAIM does.
+func (db *DB) XCLAIM(key, group, consumer string, minIdle time.Duration, ids []object.StreamID, args object.StreamClaimArgs) ([]object.StreamEntry, error) {
+	s, err := db.lookupStreamForGroup(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.XCLAIM(group, consumer, minIdle, ids, args)
+}
+
+// XAUTOCLAIM transfers the idle pending entries of the group of the stream at the key to the consumer,
+// as object.Stream.XAUTOCLAIM does.
+func (db *DB) XAUTOCLAIM(key, group, consumer string, minIdle time.Duration, start object.StreamID, count int, justID bool) (object.StreamID, []object.StreamEntry, []object.StreamID, error) {
+	s, err := db.lookupStreamForGroup(key)
+	if err != nil {
+		return object.StreamID{}, nil, nil, err
+	}
+	return s.XAUTOCLAIM(group, consumer, minIdle, start, count, justID)
+}
+
+// blockStreamClient blocks the client of XREAD or XREADGROUP.
+func (db *DB) blockStreamClient(c *BlockedClient, timeout time.Duration) ([]StreamRead, *BlockedClient, error) {
+	if timeout < 0 {
+		return nil, nil, ErrNegativeTimeout
+	}
+	db.blockForKeys(c, timeout)
+	return nil, c, nil
+}
+
+// lookupStream returns the stream at the key, and false if the key does not exist.
+func (db *DB) lookupStream(key string) (object.Stream, bool, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STREAM)
+	if o == nil {
+		return object.Stream{}, false, err
+	}
+	return object.Stream{ValueObject: o}, true, nil
+}
+
+// lookupStreamForGroup returns the stream at the key,
+// where a missing key is reported as a missing group.
+func (db *DB) lookupStreamForGroup(key string) (object.Stream, error) {
+	s, ok, err := db.lookupStream(key)
+	if err == nil && !ok {
+		err = ErrStreamNoGroupOrKey
+	}
+	return s, err
+}
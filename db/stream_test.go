@@ -0,0 +1,144 @@
+package db
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+var streamMax = object.StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+func TestDB_Stream(t *testing.T) {
+	db := NewDB()
+	db.SET("str", []byte("v"))
+
+	if _, ok, err := db.XADD("s", true, nil, "1-1", "f", "v"); ok || err != nil || db.lookup("s") != nil {
+		t.Errorf("XADD NOMKSTREAM created the key, err = %v", err)
+	}
+	if _, _, err := db.XADD("str", false, nil, "1-1", "f", "v"); err != ErrWrongType {
+		t.Errorf("XADD to a string err = %v", err)
+	}
+	trim := &object.StreamTrimArgs{Strategy: object.STREAM_TRIM_MAXLEN, MaxLen: 2}
+	for _, id := range []string{"1-1", "2-1", "3-1"} {
+		if _, ok, err := db.XADD("s", false, trim, id, "f", "v"); !ok || err != nil {
+			t.Fatalf("XADD(%s) = %v, %v", id, ok, err)
+		}
+	}
+	if n, _ := db.XLEN("s"); n != 2 {
+		t.Errorf("XLEN after XADD MAXLEN = %d", n)
+	}
+	if _, _, err := db.XADD("s", false, &object.StreamTrimArgs{Strategy: object.STREAM_TRIM_MAXLEN, MaxLen: -1}, "4-1", "f", "v"); err != object.ErrStreamNegativeTrim {
+		t.Errorf("XADD with a negative MAXLEN err = %v", err)
+	}
+
+	// an emptied stream is kept
+	db.XDEL("s", object.StreamID{Ms: 2, Seq: 1})
+	if n, err := db.XTRIM("s", object.StreamTrimArgs{Strategy: object.STREAM_TRIM_MAXLEN}); n != 1 || err != nil || db.lookup("s") == nil {
+		t.Errorf("XTRIM = %d, %v", n, err)
+	}
+	if n, err := db.XLEN("missing"); n != 0 || err != nil {
+		t.Errorf("XLEN of a missing key = %d, %v", n, err)
+	}
+
+	if err := db.XGROUPCREATE("missing", "g", "$", false); err != ErrStreamGroupNoKey {
+		t.Errorf("XGROUP CREATE without MKSTREAM err = %v", err)
+	}
+	if err := db.XGROUPCREATE("new", "g", "$", true); err != nil || db.lookup("new") == nil {
+		t.Errorf("XGROUP CREATE MKSTREAM err = %v", err)
+	}
+	if _, err := db.XPENDING("missing", "g"); err != ErrStreamNoGroupOrKey {
+		t.Errorf("XPENDING of a missing key err = %v", err)
+	}
+}
+
+func TestDB_XREAD(t *testing.T) {
+	db := NewDB()
+	db.XADD("s1", false, nil, "1-1", "f", "v")
+	db.XADD("s1", false, nil, "2-1", "f", "v")
+
+	if _, _, err := db.XREAD(XReadArgs{Keys: []string{"s1"}}); err != ErrStreamUnbalanced {
+		t.Errorf("unbalanced XREAD err = %v", err)
+	}
+	if _, _, err := db.XREAD(XReadArgs{Keys: []string{"s1"}, IDs: []string{">"}}); err != ErrStreamGreaterID {
+		t.Errorf("XREAD > err = %v", err)
+	}
+
+	reads, c, err := db.XREAD(XReadArgs{Keys: []string{"missing", "s1"}, IDs: []string{"0", "1-1"}, Block: true})
+	if err != nil || c != nil || len(reads) != 1 || reads[0].Key != "s1" || len(reads[0].Entries) != 1 {
+		t.Fatalf("XREAD = %+v, %v, %v", reads, c, err)
+	}
+
+	// $ waits for the entries added afterwards
+	_, c, err = db.XREAD(XReadArgs{Keys: []string{"s1", "s2"}, IDs: []string{"$", "$"}, Block: true})
+	if err != nil || c == nil || isDone(c) {
+		t.Fatalf("XREAD BLOCK = %v, %v", c, err)
+	}
+	db.XADD("s2", false, nil, "5-1", "f", "v")
+	db.XADD("s2", false, nil, "6-1", "f", "v")
+	db.HandleClientsBlockedOnKeys()
+	reply, _ := c.Result()
+	if !isDone(c) || reply == nil || reply.Key != "s2" || len(reply.Entries) != 2 {
+		t.Errorf("XREAD BLOCK reply = %+v", reply)
+	}
+
+	_, c, _ = db.XREAD(XReadArgs{Keys: []string{"s1"}, IDs: []string{"$"}, Block: true, Timeout: time.Second})
+	db.HandleBlockedClientsTimeout(time.Now().Add(2 * time.Second))
+	if reply, _ := c.Result(); !isDone(c) || reply != nil {
+		t.Errorf("XREAD BLOCK timed out with %+v", reply)
+	}
+	if _, _, err := db.XREAD(XReadArgs{Keys: []string{"s1"}, IDs: []string{"$"}, Block: true, Timeout: -1}); err != ErrNegativeTimeout {
+		t.Errorf("negative timeout err = %v", err)
+	}
+}
+
+func TestDB_XREADGROUP(t *testing.T) {
+	db := NewDB()
+	db.XADD("s", false, nil, "1-1", "f", "v")
+	db.XGROUPCREATE("s", "g", "0", false)
+
+	if _, _, err := db.XREADGROUP(XReadArgs{Keys: []string{"missing"}, IDs: []string{">"}, Group: "g", Consumer: "c"}); err != ErrStreamNoGroupOrKey {
+		t.Errorf("XREADGROUP of a missing key err = %v", err)
+	}
+	args := XReadArgs{Keys: []string{"s"}, IDs: []string{">"}, Group: "g", Consumer: "c", Block: true}
+	reads, c, err := db.XREADGROUP(args)
+	if err != nil || c != nil || len(reads) != 1 || len(reads[0].Entries) != 1 {
+		t.Fatalf("XREADGROUP = %+v, %v, %v", reads, c, err)
+	}
+
+	// the history is replied at once, even if empty
+	reads, c, _ = db.XREADGROUP(XReadArgs{Keys: []string{"s"}, IDs: []string{"1-1"}, Group: "g", Consumer: "c", Block: true})
+	if c != nil || len(reads) != 1 || len(reads[0].Entries) != 0 {
+		t.Errorf("XREADGROUP history = %+v, %v", reads, c)
+	}
+
+	_, c, _ = db.XREADGROUP(args)
+	if c == nil || isDone(c) {
+		t.Fatalf("XREADGROUP did not block")
+	}
+	db.XADD("s", false, nil, "2-1", "f", "v")
+	db.HandleClientsBlockedOnKeys()
+	if reply, _ := c.Result(); reply == nil || len(reply.Entries) != 1 || reply.Entries[0].ID != (object.StreamID{Ms: 2, Seq: 1}) {
+		t.Errorf("XREADGROUP BLOCK reply = %+v", reply)
+	}
+	if summary, _ := db.XPENDING("s", "g"); summary.Count != 2 {
+		t.Errorf("XPENDING = %+v", summary)
+	}
+	if n, _ := db.XACK("s", "g", object.StreamID{Ms: 1, Seq: 1}); n != 1 {
+		t.Errorf("XACK = %d", n)
+	}
+	if pending, _ := db.XPENDINGRANGE("s", "g", 0, object.StreamID{}, streamMax, 10, ""); len(pending) != 1 {
+		t.Errorf("XPENDINGRANGE = %+v", pending)
+	}
+	// nothing is delivered if a stream has no such group
+	db.XADD("s", false, nil, "3-1", "f", "v")
+	db.XADD("nogroup", false, nil, "1-1", "f", "v")
+	both := XReadArgs{Keys: []string{"s", "nogroup"}, IDs: []string{">", ">"}, Group: "g", Consumer: "c"}
+	if _, _, err := db.XREADGROUP(both); err != ErrStreamNoGroupOrKey {
+		t.Errorf("XREADGROUP of a stream without the group err = %v", err)
+	}
+	if summary, _ := db.XPENDING("s", "g"); summary.Count != 1 {
+		t.Errorf("XPENDING after a failed XREADGROUP = %+v", summary)
+	}
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/viktorxhzj/mykv/object"
+	"github.com/viktorxhzj/mykv/util"
+)
+
+var (
+	ErrStringTooLarge = errors.New("ERR string exceeds maximum allowed size (512MB)")
+	ErrOffsetRange    = errors.New("ERR offset is out of range")
+	ErrNotInteger     = errors.New("ERR value is not an integer or out of range")
+	ErrNotFloat       = errors.New("ERR value is not a valid float")
+	ErrDecrOverflow   = errors.New("ERR decrement would overflow")
+)
+
+// GET returns the value of the key, or nil if the key does not exist.
+func (db *DB) GET(key string) ([]byte, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if o == nil {
+		return nil, err
+	}
+	return o.StringBytes(), nil
+}
+
+// SET sets the key to hold the value, overwriting the old value of any type.
+func (db *DB) SET(key string, value []byte) {
+	db.set(key, object.NewEncodedStringObject(value))
+}
+
+// APPEND appends the value to the value of the key,
+// which is created as an empty string if it does not exist,
+// and returns the new length.
+func (db *DB) APPEND(key string, value []byte) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if err != nil {
+		return 0, err
+	}
+	if o == nil {
+		o = object.NewEncodedStringObject(value)
+		db.set(key, o)
+		return o.StringLen(), nil
+	}
+	if o.StringLen()+len(value) > object.OBJ_STRING_MAX_SIZE {
+		return 0, ErrStringTooLarge
+	}
+	return db.unshareString(key, o).AppendString(value), nil
+}
+
+// STRLEN returns the length of the value of the key, or 0 if the key does not exist.
+func (db *DB) STRLEN(key string) (int, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if o == nil {
+		return 0, err
+	}
+	return o.StringLen(), nil
+}
+
+// GETRANGE returns the substring of the value of the key from start to end (both inclusive),
+// where negative offsets count from the end of the string.
+func (db *DB) GETRANGE(key string, start, end int) ([]byte, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if o == nil {
+		return []byte{}, err
+	}
+	return o.StringRange(start, end), nil
+}
+
+// SETRANGE overwrites the value of the key with the value starting at offset,
+// padding the string with zero bytes if it is shorter than offset,
+// and returns the new length.
+func (db *DB) SETRANGE(key string, offset int, value []byte) (int, error) {
+	if offset < 0 {
+		return 0, ErrOffsetRange
+	}
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if err != nil {
+		return 0, err
+	}
+
+	// an empty value never creates nor grows the string
+	if len(value) == 0 {
+		if o == nil {
+			return 0, nil
+		}
+		return o.StringLen(), nil
+	}
+	if offset+len(value) > object.OBJ_STRING_MAX_SIZE {
+		return 0, ErrStringTooLarge
+	}
+
+	if o == nil {
+		o = object.NewRawStringObject(nil)
+		db.set(key, o)
+	} else {
+		o = db.unshareString(key, o)
+	}
+	return o.SetStringRange(offset, value), nil
+}
+
+// INCR increments the integer value of the key by one,
+// and returns the new value.
+func (db *DB) INCR(key string) (int64, error) {
+	return db.incrBy(key, 1)
+}
+
+// DECR decrements the integer value of the key by one,
+// and returns the new value.
+func (db *DB) DECR(key string) (int64, error) {
+	return db.incrBy(key, -1)
+}
+
+// INCRBY increments the integer value of the key by incr,
+// and returns the new value.
+func (db *DB) INCRBY(key string, incr int64) (int64, error) {
+	return db.incrBy(key, incr)
+}
+
+// DECRBY decrements the integer value of the key by decr,
+// and returns the new value.
+func (db *DB) DECRBY(key string, decr int64) (int64, error) {
+	if decr == math.MinInt64 {
+		return 0, ErrDecrOverflow
+	}
+	return db.incrBy(key, -decr)
+}
+
+// incrBy increments the integer value of the key by incr,
+// where a missing key counts as 0.
+func (db *DB) incrBy(key string, incr int64) (int64, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if err != nil {
+		return 0, err
+	}
+
+	var v int64
+	if o != nil {
+		var ok bool
+		if v, ok = o.StringInt64(); !ok {
+			return 0, ErrNotInteger
+		}
+	}
+	if (incr < 0 && v < 0 && incr < math.MinInt64-v) ||
+		(incr > 0 && v > 0 && incr > math.MaxInt64-v) {
+		return 0, object.ErrIncrOverflow
+	}
+	v += incr
+
+	// reuse the INT encoded object, unless either the old or the new one is shared
+	if o != nil && (v < 0 || v >= object.OBJ_SHARED_INTEGERS) && !o.IsShared() {
+		if _, et := o.GetType(); et == object.OBJ_ENCODING_INT {
+			o.Structure = v
+			return v, nil
+		}
+	}
+	db.set(key, object.NewStringObjectFromInt64(v))
+	return v, nil
+}
+
+// INCRBYFLOAT increments the float value of the key by incr,
+// where a missing key counts as 0, and returns the new value.
+// As in Redis, the addition is done in long double precision,
+// and the new value is stored as a string with no exponent and no trailing zeros.
+func (db *DB) INCRBYFLOAT(key string, incr float64) ([]byte, error) {
+	o, err := db.lookupOfType(key, object.OBJ_STRING)
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(big.Float)
+	if o != nil {
+		var ok bool
+		if v, ok = o.StringLongDouble(); !ok {
+			return nil, ErrNotFloat
+		}
+	}
+	d, ok := util.Float64ToLongDouble(incr)
+	if ok {
+		v, ok = util.AddLongDouble(v, d)
+	}
+	if !ok {
+		return nil, object.ErrIncrNaNOrInf
+	}
+
+	b := []byte(util.LongDoubleToString(v))
+	db.set(key, object.NewStringObject(b))
+	return b, nil
+}
+
+// unshareString makes the string object of the key modifiable in place,
+// replacing it with a RAW encoded copy if needed.
+func (db *DB) unshareString(key string, o *object.ValueObject) *object.ValueObject {
+	if u := object.UnshareString(o); u != o {
+		db.set(key, u)
+		return u
+	}
+	return o
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/object"
+)
+
+func encodingOf(db *DB, key string) uint8 {
+	_, et := db.lookup(key).GetType()
+	return et
+}
+
+func TestDB_String(t *testing.T) {
+	db := NewDB()
+
+	if v, err := db.GET("k"); v != nil || err != nil {
+		t.Errorf("GET missing key = %q, %v", v, err)
+	}
+
+	db.SET("k", []byte("100"))
+	if encodingOf(db, "k") != object.OBJ_ENCODING_INT {
+		t.Errorf("100 is not INT encoded")
+	}
+	if n, _ := db.APPEND("k", []byte("\x00x")); n != 5 {
+		t.Errorf("APPEND = %d", n)
+	}
+	if encodingOf(db, "k") != object.OBJ_ENCODING_RAW {
+		t.Errorf("appended string is not RAW encoded")
+	}
+	if v, _ := db.GET("k"); !bytes.Equal(v, []byte("100\x00x")) {
+		t.Errorf("GET = %q", v)
+	}
+	if object.NewStringObjectFromInt64(100).Structure.(int64) != 100 {
+		t.Errorf("the shared integer is modified")
+	}
+
+	if n, _ := db.APPEND("new", []byte("7")); n != 1 || encodingOf(db, "new") != object.OBJ_ENCODING_INT {
+		t.Errorf("APPEND to a missing key = %d", n)
+	}
+
+	if n, _ := db.STRLEN("k"); n != 5 {
+		t.Errorf("STRLEN = %d", n)
+	}
+	if n, _ := db.STRLEN("missing"); n != 0 {
+		t.Errorf("STRLEN missing key = %d", n)
+	}
+
+	if v, _ := db.GETRANGE("k", 1, -2); !bytes.Equal(v, []byte("00\x00")) {
+		t.Errorf("GETRANGE = %q", v)
+	}
+
+	if n, _ := db.SETRANGE("s", 3, []byte("ab")); n != 5 {
+		t.Errorf("SETRANGE missing key = %d", n)
+	}
+	if v, _ := db.GET("s"); !bytes.Equal(v, []byte("\x00\x00\x00ab")) {
+		t.Errorf("GET after SETRANGE = %q", v)
+	}
+	if n, _ := db.SETRANGE("s", 1, []byte("z")); n != 5 {
+		t.Errorf("SETRANGE = %d", n)
+	}
+	if n, _ := db.SETRANGE("empty", 10, nil); n != 0 || db.lookup("empty") != nil {
+		t.Errorf("SETRANGE with empty value creates the key")
+	}
+	if _, err := db.SETRANGE("s", -1, []byte("a")); err != ErrOffsetRange {
+		t.Errorf("SETRANGE negative offset err = %v", err)
+	}
+	if _, err := db.SETRANGE("s", object.OBJ_STRING_MAX_SIZE, []byte("a")); err != ErrStringTooLarge {
+		t.Errorf("SETRANGE too large err = %v", err)
+	}
+
+	o := new(object.ValueObject)
+	o.SetType(object.OBJ_LIST, object.OBJ_ENCODING_QUICKLIST)
+	db.set("list", o)
+	if _, err := db.GET("list"); err != ErrWrongType {
+		t.Errorf("GET list err = %v", err)
+	}
+	if _, err := db.APPEND("list", []byte("a")); err != ErrWrongType {
+		t.Errorf("APPEND list err = %v", err)
+	}
+	db.SET("list", []byte("a"))
+	if v, _ := db.GET("list"); string(v) != "a" {
+		t.Errorf("SET does not overwrite a list")
+	}
+}
+
+func TestDB_Incr(t *testing.T) {
+	db := NewDB()
+
+	if v, err := db.INCR("n"); v != 1 || err != nil {
+		t.Errorf("INCR missing key = %d, %v", v, err)
+	}
+	if v, _ := db.INCRBY("n", 9998); v != 9999 || !db.lookup("n").IsShared() {
+		t.Errorf("INCRBY = %d, shared %v", v, db.lookup("n").IsShared())
+	}
+	if v, _ := db.INCR("n"); v != 10000 || db.lookup("n").IsShared() {
+		t.Errorf("INCR = %d, shared %v", v, db.lookup("n").IsShared())
+	}
+	o := db.lookup("n")
+	if v, _ := db.DECRBY("n", 20000); v != -10000 || db.lookup("n") != o {
+		t.Errorf("DECRBY = %d, reused %v", v, db.lookup("n") == o)
+	}
+	if v, _ := db.DECR("n"); v != -10001 {
+		t.Errorf("DECR = %d", v)
+	}
+	if object.NewStringObjectFromInt64(9999).Structure.(int64) != 9999 {
+		t.Errorf("the shared integer is modified")
+	}
+
+	db.SET("n", []byte("9223372036854775806"))
+	if v, _ := db.INCR("n"); v != math.MaxInt64 {
+		t.Errorf("INCR = %d", v)
+	}
+	if _, err := db.INCR("n"); err != object.ErrIncrOverflow {
+		t.Errorf("INCR overflow err = %v", err)
+	}
+	if _, err := db.DECRBY("n", math.MinInt64); err != ErrDecrOverflow {
+		t.Errorf("DECRBY overflow err = %v", err)
+	}
+	db.SET("n", []byte("-9223372036854775808"))
+	if _, err := db.DECR("n"); err != object.ErrIncrOverflow {
+		t.Errorf("DECR overflow err = %v", err)
+	}
+
+	for _, bad := range []string{"abc", "1.5", " 1", "01", "+1", "9223372036854775808", ""} {
+		db.SET("bad", []byte(bad))
+		if _, err := db.INCR("bad"); err != ErrNotInteger {
+			t.Errorf("INCR %q err = %v", bad, err)
+		}
+	}
+
+	// an appended value is RAW encoded, but still an integer
+	db.SET("raw", []byte("1"))
+	db.APPEND("raw", []byte("2"))
+	if v, _ := db.INCRBY("raw", 30); v != 42 || encodingOf(db, "raw") != object.OBJ_ENCODING_INT {
+		t.Errorf("INCRBY RAW = %d", v)
+	}
+}
+
+func TestDB_IncrByFloat(t *testing.T) {
+	db := NewDB()
+
+	db.SET("f", []byte("10.50"))
+	if v, err := db.INCRBYFLOAT("f", 0.1); string(v) != "10.6" || err != nil {
+		t.Errorf("INCRBYFLOAT = %s, %v", v, err)
+	}
+	if v, _ := db.INCRBYFLOAT("f", -5); string(v) != "5.6" {
+		t.Errorf("INCRBYFLOAT = %s", v)
+	}
+	db.SET("f", []byte("5.0e3"))
+	if v, _ := db.INCRBYFLOAT("f", 2.0e2); string(v) != "5200" {
+		t.Errorf("INCRBYFLOAT = %s", v)
+	}
+	if v, _ := db.INCR("f"); v != 5201 {
+		t.Errorf("INCR after INCRBYFLOAT = %d", v)
+	}
+	if v, _ := db.INCRBYFLOAT("missing", 3); string(v) != "3" {
+		t.Errorf("INCRBYFLOAT missing key = %s", v)
+	}
+	if v, _ := db.INCRBYFLOAT("large", 1e21); string(v) != "1000000000000000000000" {
+		t.Errorf("INCRBYFLOAT large = %s", v)
+	}
+
+	// long double precision, where float64 gives 0.30000000000000004
+	db.SET("k", []byte("0.1"))
+	if v, _ := db.INCRBYFLOAT("k", 0.2); string(v) != "0.3" {
+		t.Errorf("INCRBYFLOAT 0.1 by 0.2 = %s", v)
+	}
+	// and the range of a long double
+	db.SET("k", []byte("1e400"))
+	if v, err := db.INCRBYFLOAT("k", 1); len(v) != 401 || err != nil {
+		t.Errorf("INCRBYFLOAT 1e400 = %d digits, %v", len(v), err)
+	}
+
+	for _, bad := range []string{"abc", " 1.5", "nan", "1e5000"} {
+		db.SET("bad", []byte(bad))
+		if _, err := db.INCRBYFLOAT("bad", 1); err != ErrNotFloat {
+			t.Errorf("INCRBYFLOAT %q err = %v", bad, err)
+		}
+	}
+	db.SET("f", []byte("1"))
+	if _, err := db.INCRBYFLOAT("f", math.Inf(1)); err != object.ErrIncrNaNOrInf {
+		t.Errorf("INCRBYFLOAT inf err = %v", err)
+	}
+	if v, _ := db.GET("f"); string(v) != "1" {
+		t.Errorf("failed INCRBYFLOAT changes the value to %s", v)
+	}
+}
@@ -0,0 +1,282 @@
+package object
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/viktorxhzj/mykv/config"
+	"github.com/viktorxhzj/mykv/datastructure"
+	"github.com/viktorxhzj/mykv/util"
+)
+
+//
+// Conversions between the encodings of an object.
+//
+// An object upgrades to its general encoding as it grows, by the thresholds of its type,
+// which the commands apply as they modify it. It never downgrades by itself:
+// Compact downgrades an object that fits the thresholds again,
+// and Convert forces any encoding that can hold the value, regardless of the thresholds.
+//
+
+// OBJ_SHARED_REFCOUNT is the reference count of a shared object.
+const OBJ_SHARED_REFCOUNT = math.MaxInt32
+
+var (
+	ErrUnsupportedConversion = errors.New("ERR unsupported encoding conversion")
+	ErrNotEncodable          = errors.New("ERR the value can't be held in the encoding")
+	ErrSharedObject          = errors.New("ERR shared objects can't be converted")
+)
+
+var encodingNames = map[uint8]string{
+	OBJ_ENCODING_RAW:       "raw",
+	OBJ_ENCODING_INT:       "int",
+	OBJ_ENCODING_HT:        "hashtable",
+	OBJ_ENCODING_ZIPLIST:   "ziplist",
+	OBJ_ENCODING_INTSET:    "intset",
+	OBJ_ENCODING_SKIPLIST:  "skiplist",
+	OBJ_ENCODING_QUICKLIST: "quicklist",
+	OBJ_ENCODING_EMBSTR:    "embstr",
+	OBJ_ENCODING_STREAM:    "stream",
+}
+
+// EncodingName returns the name of the encoding, as OBJECT ENCODING reports it.
+func EncodingName(et uint8) string {
+	if name, ok := encodingNames[et]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// EncodingByName returns the encoding of the name, and false if there is no such encoding.
+func EncodingByName(name string) (uint8, bool) {
+	for et, n := range encodingNames {
+		if n == name {
+			return et, true
+		}
+	}
+	return 0, false
+}
+
+// RefCount returns the reference count of the object, as OBJECT REFCOUNT reports it.
+// Only the shared integers have more than one reference.
+func (o *ValueObject) RefCount() int {
+	if o.IsShared() {
+		return OBJ_SHARED_REFCOUNT
+	}
+	return 1
+}
+
+// Convert converts the object to the encoding et in place.
+// It returns ErrUnsupportedConversion if the type of the object has no such encoding,
+// and ErrNotEncodable if the value can't be held in it.
+func Convert(o *ValueObject, et uint8) error {
+	ot, cur := o.GetType()
+	if cur == et {
+		return nil
+	}
+
+	switch {
+	case ot == OBJ_STRING && (et == OBJ_ENCODING_RAW || et == OBJ_ENCODING_INT || et == OBJ_ENCODING_EMBSTR):
+		if o.IsShared() {
+			return ErrSharedObject
+		}
+		return convertString(o, et)
+	case ot == OBJ_HASH && (et == OBJ_ENCODING_HT || et == OBJ_ENCODING_ZIPLIST):
+		return Hash{o}.convert()
+	case ot == OBJ_SET && (et == OBJ_ENCODING_HT || et == OBJ_ENCODING_INTSET):
+		return Set{o}.convert()
+	case ot == OBJ_ZSET && (et == OBJ_ENCODING_SKIPLIST || et == OBJ_ENCODING_ZIPLIST):
+		return SortedSet{o}.convert()
+	}
+	return ErrUnsupportedConversion
+}
+
+// Compact converts the object to its compact encoding in place
+// if it fits the thresholds of the type, and returns true if it is converted.
+// A string is converted to INT or EMBSTR, though never to a shared object.
+func Compact(o *ValueObject) bool {
+	ot, et := o.GetType()
+	switch {
+	case ot == OBJ_STRING && et != OBJ_ENCODING_INT:
+		if b := o.StringBytes(); len(b) <= OBJ_STRING_INT_MAX_LENGTH {
+			if _, ok := util.StringToInt64(b); ok {
+				return convertString(o, OBJ_ENCODING_INT) == nil
+			}
+		}
+		return et == OBJ_ENCODING_RAW && convertString(o, OBJ_ENCODING_EMBSTR) == nil
+	case ot == OBJ_HASH && et == OBJ_ENCODING_HT:
+		h := Hash{o}
+		if !hashZipListEntriesFit(h.HLEN()) {
+			return false
+		}
+		for _, f := range h.HGETALL() {
+			if !hashZipListValuesFit(f.Field, f.Value) {
+				return false
+			}
+		}
+		return h.convert() == nil
+	case ot == OBJ_SET && et == OBJ_ENCODING_HT:
+		s := Set{o}
+		return setIntSetEntriesFit(s.SCARD()) && s.convert() == nil
+	case ot == OBJ_ZSET && et == OBJ_ENCODING_SKIPLIST:
+		z := SortedSet{o}
+		if !zsetZipListEntriesFit(z.ZCARD()) {
+			return false
+		}
+		for _, m := range z.members() {
+			if !zsetZipListValuesFit(m.Key) {
+				return false
+			}
+		}
+		return z.convert() == nil
+	}
+	return false
+}
+
+//
+// The thresholds of the compact encodings, given by the config,
+// which the commands check to upgrade an object as it grows, and Compact checks to downgrade it.
+//
+
+// hashZipListEntriesFit reports whether a hash of n fields may be a ziplist.
+func hashZipListEntriesFit(n int) bool {
+	return n <= config.HashMaxZiplistEntries
+}
+
+// hashZipListValuesFit reports whether the fields or values are short enough for a ziplist.
+func hashZipListValuesFit(values ...string) bool {
+	for _, v := range values {
+		if len(v) > config.HashMaxZiplistValue {
+			return false
+		}
+	}
+	return true
+}
+
+// setIntSetEntriesFit reports whether a set of n integers may be an intset.
+func setIntSetEntriesFit(n int) bool {
+	return n <= config.SetMaxIntsetEntries
+}
+
+// zsetZipListEntriesFit reports whether a sorted set of n members may be a ziplist.
+func zsetZipListEntriesFit(n int) bool {
+	return n <= config.ZSetMaxZiplistEntries
+}
+
+// zsetZipListValuesFit reports whether the members are short enough for a ziplist.
+func zsetZipListValuesFit(members ...string) bool {
+	for _, m := range members {
+		if len(m) > config.ZSetMaxZiplistValue {
+			return false
+		}
+	}
+	return true
+}
+
+// convertString converts a string object, which must not be shared, to the encoding et.
+func convertString(o *ValueObject, et uint8) error {
+	b := o.StringBytes()
+	switch et {
+	case OBJ_ENCODING_INT:
+		v, ok := util.StringToInt64(b)
+		if !ok || len(b) > OBJ_STRING_INT_MAX_LENGTH {
+			return ErrNotEncodable
+		}
+		o.Structure = v
+	case OBJ_ENCODING_EMBSTR:
+		if len(b) > OBJ_ENCODING_EMBSTR_SIZE_LIMIT {
+			return ErrNotEncodable
+		}
+		o.Structure = string(b)
+	default:
+		o.Structure = append(make([]byte, 0, len(b)), b...)
+	}
+	o.SetType(OBJ_STRING, et)
+	return nil
+}
+
+// convert converts a ziplist encoded hash to a dict, and a dict encoded one back to a ziplist,
+// which returns ErrNotEncodable, leaving the hash unchanged, if the fields do not fit in a ziplist.
+func (h Hash) convert() error {
+	fields := h.HGETALL()
+	if _, et := h.GetType(); et == OBJ_ENCODING_HT {
+		if len(fields)*2 > datastructure.ZL_MAX_LEN {
+			return ErrNotEncodable
+		}
+		zl := datastructure.NewZipList()
+		for _, f := range fields {
+			if zipListInsert(zl, zl.ZLLen(), f.Field) != nil || zipListInsert(zl, zl.ZLLen(), f.Value) != nil {
+				return ErrNotEncodable
+			}
+		}
+		h.SetType(OBJ_HASH, OBJ_ENCODING_ZIPLIST)
+		h.Structure = zl
+		return nil
+	}
+
+	d := datastructure.NewDict()
+	for _, f := range fields {
+		d.Put(f.Field, f.Value)
+	}
+	h.SetType(OBJ_HASH, OBJ_ENCODING_HT)
+	h.Structure = d
+	return nil
+}
+
+// convert converts an intset encoded set to a dict, and a dict encoded one back to an intset,
+// which returns ErrNotEncodable if a member is not an integer.
+func (s Set) convert() error {
+	members := s.SMEMBERS()
+	if _, et := s.GetType(); et == OBJ_ENCODING_HT {
+		is := datastructure.NewIntSet()
+		for _, m := range members {
+			i, ok := util.StringToInt64([]byte(m))
+			if !ok {
+				return ErrNotEncodable
+			}
+			is.Add(int(i))
+		}
+		s.SetType(OBJ_SET, OBJ_ENCODING_INTSET)
+		s.Structure = is
+		return nil
+	}
+
+	d := datastructure.NewDict()
+	for _, m := range members {
+		d.Put(m, "")
+	}
+	s.SetType(OBJ_SET, OBJ_ENCODING_HT)
+	s.Structure = d
+	return nil
+}
+
+// convert converts a ziplist encoded sorted set to a skiplist, and a skiplist encoded one back to a ziplist,
+// which returns ErrNotEncodable, leaving the sorted set unchanged, if the members do not fit in a ziplist.
+func (z SortedSet) convert() error {
+	members := z.members()
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		if len(members)*2 > datastructure.ZL_MAX_LEN {
+			return ErrNotEncodable
+		}
+		// the members are in order already
+		zl := datastructure.NewZipList()
+		for _, m := range members {
+			if zipListInsert(zl, zl.ZLLen(), m.Key) != nil ||
+				zipListInsert(zl, zl.ZLLen(), strconv.FormatFloat(m.Value, 'g', -1, 64)) != nil {
+				return ErrNotEncodable
+			}
+		}
+		z.SetType(OBJ_ZSET, OBJ_ENCODING_ZIPLIST)
+		z.Structure = zl
+		return nil
+	}
+
+	zs := datastructure.NewZSetSkipList()
+	for _, m := range members {
+		zs.Add(m.Key, m.Value)
+	}
+	z.SetType(OBJ_ZSET, OBJ_ENCODING_SKIPLIST)
+	z.Structure = zs
+	return nil
+}
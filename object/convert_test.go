@@ -0,0 +1,210 @@
+package object
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/config"
+	"github.com/viktorxhzj/mykv/datastructure"
+)
+
+func encodingOf(o *ValueObject) uint8 {
+	_, et := o.GetType()
+	return et
+}
+
+func TestEncodingName(t *testing.T) {
+	for et := uint8(OBJ_ENCODING_RAW); et <= OBJ_ENCODING_STREAM; et++ {
+		name := EncodingName(et)
+		if got, ok := EncodingByName(name); !ok || got != et {
+			t.Errorf("EncodingByName(%q) = %d, %v, want %d", name, got, ok, et)
+		}
+	}
+	if EncodingName(encodingOf(NewHashObject().ValueObject)) != "ziplist" || EncodingName(encodingOf(NewListObject().ValueObject)) != "quicklist" {
+		t.Errorf("EncodingName of a new hash or list")
+	}
+	if _, ok := EncodingByName("listpack"); ok {
+		t.Errorf("EncodingByName(listpack)")
+	}
+}
+
+func TestRefCount(t *testing.T) {
+	if n := NewStringObjectFromInt64(7).RefCount(); n != OBJ_SHARED_REFCOUNT {
+		t.Errorf("RefCount of a shared integer = %d", n)
+	}
+	if n := NewStringObjectFromInt64(OBJ_SHARED_INTEGERS).RefCount(); n != 1 {
+		t.Errorf("RefCount of an integer = %d", n)
+	}
+	if n := NewStringObject([]byte("v")).RefCount(); n != 1 {
+		t.Errorf("RefCount of a string = %d", n)
+	}
+}
+
+func TestConvert_String(t *testing.T) {
+	o := NewRawStringObject([]byte("12345"))
+	for _, et := range []uint8{OBJ_ENCODING_INT, OBJ_ENCODING_EMBSTR, OBJ_ENCODING_RAW, OBJ_ENCODING_INT} {
+		if err := Convert(o, et); err != nil || encodingOf(o) != et {
+			t.Fatalf("Convert to %s = %v", EncodingName(et), err)
+		}
+		if string(o.StringBytes()) != "12345" {
+			t.Errorf("%s value = %q", EncodingName(et), o.StringBytes())
+		}
+	}
+
+	long := NewStringObject([]byte(strings.Repeat("x", OBJ_ENCODING_EMBSTR_SIZE_LIMIT+1)))
+	if err := Convert(long, OBJ_ENCODING_EMBSTR); err != ErrNotEncodable || encodingOf(long) != OBJ_ENCODING_RAW {
+		t.Errorf("Convert a long string to embstr = %v", err)
+	}
+	if err := Convert(long, OBJ_ENCODING_INT); err != ErrNotEncodable {
+		t.Errorf("Convert a long string to int = %v", err)
+	}
+	if err := Convert(long, OBJ_ENCODING_HT); err != ErrUnsupportedConversion {
+		t.Errorf("Convert a string to hashtable = %v", err)
+	}
+	if err := Convert(NewStringObjectFromInt64(1), OBJ_ENCODING_RAW); err != ErrSharedObject {
+		t.Errorf("Convert a shared integer = %v", err)
+	}
+	if _, et := sharedIntegers[1].GetType(); et != OBJ_ENCODING_INT {
+		t.Errorf("a shared integer is converted")
+	}
+}
+
+func TestConvert_Hash(t *testing.T) {
+	h := NewHashObject()
+	h.HSET(HashField{"a", "1"}, HashField{"b", "x"}, HashField{"c", "3"})
+	want := h.HGETALL()
+
+	if err := Convert(h.ValueObject, OBJ_ENCODING_HT); err != nil || encodingOf(h.ValueObject) != OBJ_ENCODING_HT {
+		t.Fatalf("Convert to hashtable = %v", err)
+	}
+	if err := Convert(h.ValueObject, OBJ_ENCODING_ZIPLIST); err != nil || encodingOf(h.ValueObject) != OBJ_ENCODING_ZIPLIST {
+		t.Fatalf("Convert to ziplist = %v", err)
+	}
+	got := h.HGETALL()
+	sort.Slice(got, func(i, j int) bool { return got[i].Field < got[j].Field })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HGETALL = %v, want %v", got, want)
+	}
+	if err := Convert(h.ValueObject, OBJ_ENCODING_SKIPLIST); err != ErrUnsupportedConversion {
+		t.Errorf("Convert a hash to skiplist = %v", err)
+	}
+
+	// a ziplist holds a field and its value as two entries
+	big := NewHashObject()
+	Convert(big.ValueObject, OBJ_ENCODING_HT)
+	n := datastructure.ZL_MAX_LEN/2 + 1
+	for i := 0; i < n; i++ {
+		big.HSET(HashField{strconv.Itoa(i), "v"})
+	}
+	if err := Convert(big.ValueObject, OBJ_ENCODING_ZIPLIST); err != ErrNotEncodable ||
+		encodingOf(big.ValueObject) != OBJ_ENCODING_HT || big.HLEN() != n {
+		t.Errorf("Convert a large hash to ziplist = %v, HLEN = %d", err, big.HLEN())
+	}
+}
+
+func TestConvert_Set(t *testing.T) {
+	s := newSet("3", "1", "2")
+	if err := Convert(s.ValueObject, OBJ_ENCODING_HT); err != nil || setEncoding(s) != OBJ_ENCODING_HT {
+		t.Fatalf("Convert to hashtable = %v", err)
+	}
+	if err := Convert(s.ValueObject, OBJ_ENCODING_INTSET); err != nil || setEncoding(s) != OBJ_ENCODING_INTSET {
+		t.Fatalf("Convert to intset = %v", err)
+	}
+	if got := s.SMEMBERS(); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf("SMEMBERS = %v", got)
+	}
+
+	s.SADD("a")
+	if err := Convert(s.ValueObject, OBJ_ENCODING_INTSET); err != ErrNotEncodable || setEncoding(s) != OBJ_ENCODING_HT {
+		t.Errorf("Convert a set of strings to intset = %v", err)
+	}
+	if got := sortedMembers(s); !reflect.DeepEqual(got, []string{"1", "2", "3", "a"}) {
+		t.Errorf("SMEMBERS after a failed conversion = %v", got)
+	}
+}
+
+func TestConvert_SortedSet(t *testing.T) {
+	members := []ZSetMember{{"c", 1}, {"a", 2}, {"b", 2}, {"d", -1.5}}
+	z := NewSortedSetObject()
+	z.ZADD(0, members...)
+	want := z.members()
+
+	for _, et := range []uint8{OBJ_ENCODING_SKIPLIST, OBJ_ENCODING_ZIPLIST} {
+		if err := Convert(z.ValueObject, et); err != nil || encodingOf(z.ValueObject) != et {
+			t.Fatalf("Convert to %s = %v", EncodingName(et), err)
+		}
+		if got := z.members(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s members = %v, want %v", EncodingName(et), got, want)
+		}
+	}
+	if score, ok := z.ZSCORE("d"); !ok || score != -1.5 {
+		t.Errorf("ZSCORE = %v, %v", score, ok)
+	}
+
+	big := NewSortedSetObject()
+	Convert(big.ValueObject, OBJ_ENCODING_SKIPLIST)
+	n := datastructure.ZL_MAX_LEN/2 + 1
+	for i := 0; i < n; i++ {
+		big.ZADD(0, ZSetMember{strconv.Itoa(i), float64(i)})
+	}
+	if err := Convert(big.ValueObject, OBJ_ENCODING_ZIPLIST); err != ErrNotEncodable ||
+		encodingOf(big.ValueObject) != OBJ_ENCODING_SKIPLIST || big.ZCARD() != n {
+		t.Errorf("Convert a large sorted set to ziplist = %v, ZCARD = %d", err, big.ZCARD())
+	}
+}
+
+func TestCompact(t *testing.T) {
+	defer func(entries int) { config.SetMaxIntsetEntries = entries }(config.SetMaxIntsetEntries)
+	config.SetMaxIntsetEntries = 4
+
+	s := newSet("1", "2", "3", "4", "5")
+	if setEncoding(s) != OBJ_ENCODING_HT || Compact(s.ValueObject) {
+		t.Fatalf("Compact a set over the threshold")
+	}
+	s.SREM("5")
+	if !Compact(s.ValueObject) || setEncoding(s) != OBJ_ENCODING_INTSET {
+		t.Errorf("Compact a set within the threshold")
+	}
+	if Compact(s.ValueObject) {
+		t.Errorf("Compact a compact set")
+	}
+
+	h := NewHashObject()
+	h.HSET(HashField{"f", strings.Repeat("v", config.HashMaxZiplistValue+1)})
+	if Compact(h.ValueObject) {
+		t.Errorf("Compact a hash with a long value")
+	}
+	h.HSET(HashField{"f", "v"})
+	if !Compact(h.ValueObject) || encodingOf(h.ValueObject) != OBJ_ENCODING_ZIPLIST {
+		t.Errorf("Compact a small hash")
+	}
+
+	z := NewSortedSetObject()
+	z.ZADD(0, ZSetMember{strings.Repeat("m", config.ZSetMaxZiplistValue+1), 1})
+	z.remove(strings.Repeat("m", config.ZSetMaxZiplistValue+1))
+	z.ZADD(0, ZSetMember{"m", 1})
+	if !Compact(z.ValueObject) || encodingOf(z.ValueObject) != OBJ_ENCODING_ZIPLIST {
+		t.Errorf("Compact a small sorted set")
+	}
+
+	for _, c := range []struct {
+		value string
+		et    uint8
+	}{
+		{"123", OBJ_ENCODING_INT},
+		{"abc", OBJ_ENCODING_EMBSTR},
+		{strings.Repeat("x", OBJ_ENCODING_EMBSTR_SIZE_LIMIT+1), OBJ_ENCODING_RAW},
+	} {
+		o := NewRawStringObject([]byte(c.value))
+		Compact(o)
+		if encodingOf(o) != c.et || string(o.StringBytes()) != c.value || o.IsShared() {
+			t.Errorf("Compact(%q) = %s", c.value, EncodingName(encodingOf(o)))
+		}
+	}
+	if l := NewListObject(); Compact(l.ValueObject) {
+		t.Errorf("Compact a list")
+	}
+}
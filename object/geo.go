@@ -0,0 +1,341 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/viktorxhzj/mykv/datastructure"
+	"github.com/viktorxhzj/mykv/util"
+)
+
+//
+// A geospatial index is a sorted set whose scores are the 52-bit geohashes of the positions of the members.
+// A search covers the area with the cell of the center and its 8 neighbors,
+// whose step is estimated by the radius, and scans the score range of each cell.
+//
+
+// GEOSEARCH shapes
+const (
+	GEO_SHAPE_RADIUS = iota + 1
+	GEO_SHAPE_BOX
+)
+
+// GEOSEARCH orders
+const (
+	GEO_SORT_NONE = iota
+	GEO_SORT_ASC
+	GEO_SORT_DESC
+)
+
+var (
+	ErrGeoUnit          = errors.New("ERR unsupported unit provided. please use M, KM, FT, MI")
+	ErrGeoMemberMissing = errors.New("ERR could not decode requested zset member")
+	ErrGeoSearchFrom    = errors.New("ERR exactly one of FROMMEMBER or FROMLONLAT can be specified for GEOSEARCH")
+	ErrGeoSearchBy      = errors.New("ERR exactly one of BYRADIUS and BYBOX can be specified for GEOSEARCH")
+	ErrGeoNegative      = errors.New("ERR radius cannot be negative")
+	ErrGeoCount         = errors.New("ERR COUNT must be > 0")
+	ErrGeoAnyNoCount    = errors.New("ERR the ANY argument requires COUNT argument")
+	ErrGeoAddFlags      = errors.New("ERR GT, LT, and INCR options are not supported by GEOADD")
+)
+
+// GeoPoint is a position.
+type GeoPoint struct {
+	Longitude, Latitude float64
+}
+
+// GeoMember is a member of a geospatial index at its position.
+type GeoMember struct {
+	Member string
+	GeoPoint
+}
+
+// GeoSearchArgs are the arguments of GEOSEARCH.
+type GeoSearchArgs struct {
+	FromMember *string   // the center is the position of the member, or
+	FromLonLat *GeoPoint // the center is the position
+
+	Shape         int     // GEO_SHAPE_RADIUS or GEO_SHAPE_BOX
+	Radius        float64 // GEO_SHAPE_RADIUS only
+	Width, Height float64 // GEO_SHAPE_BOX only
+	Unit          string  // of Radius, Width, Height and the distances replied: "m", "km", "mi" or "ft"
+
+	Sort  int  // GEO_SORT_NONE, GEO_SORT_ASC or GEO_SORT_DESC by distance
+	Count int  // the maximum number of results if positive
+	Any   bool // Count only, stop once Count results are found instead of returning the nearest
+}
+
+// Validate checks the options, as GEOSEARCH does before it searches.
+func (args GeoSearchArgs) Validate() error {
+	_, err := newGeoShape(args)
+	return err
+}
+
+// GeoResult is a result of GEOSEARCH, holding everything that WITHDIST, WITHCOORD and WITHHASH reply.
+type GeoResult struct {
+	Member string
+	Dist   float64 // in the unit of the search
+	Hash   uint64  // the score
+	GeoPoint
+}
+
+// GeoUnitConversion returns the meters of the unit.
+func GeoUnitConversion(unit string) (float64, error) {
+	switch strings.ToLower(unit) {
+	case "m":
+		return 1, nil
+	case "km":
+		return 1000, nil
+	case "ft":
+		return 0.3048, nil
+	case "mi":
+		return 1609.34, nil
+	}
+	return 0, ErrGeoUnit
+}
+
+// GEOADD adds the members at their positions, as ZADD does with ZADD_NX, ZADD_XX and ZADD_CH.
+// It validates all the positions before adding any of them.
+func (z SortedSet) GEOADD(flags int, members ...GeoMember) (int, error) {
+	if flags&(ZADD_GT|ZADD_LT|ZADD_INCR) != 0 {
+		return 0, ErrGeoAddFlags
+	}
+	zmembers := make([]ZSetMember, len(members))
+	for i, m := range members {
+		hash, ok := util.GeoHashEncodeWGS84(m.Longitude, m.Latitude, util.GEO_STEP_MAX)
+		if !ok {
+			return 0, fmt.Errorf("ERR invalid longitude,latitude pair %f,%f", m.Longitude, m.Latitude)
+		}
+		zmembers[i] = ZSetMember{m.Member, float64(hash.Align52Bits())}
+	}
+	return z.ZADD(flags, zmembers...)
+}
+
+// GEOPOS returns the positions of the members, nil for a missing member.
+func (z SortedSet) GEOPOS(members ...string) []*GeoPoint {
+	res := make([]*GeoPoint, len(members))
+	for i, m := range members {
+		if score, ok := z.ZSCORE(m); ok {
+			p := geoDecode(score)
+			res[i] = &p
+		}
+	}
+	return res
+}
+
+// GEODIST returns the distance between two members in the unit,
+// and false if either of them is missing.
+func (z SortedSet) GEODIST(member1, member2, unit string) (float64, bool, error) {
+	conversion, err := GeoUnitConversion(unit)
+	if err != nil {
+		return 0, false, err
+	}
+	score1, ok1 := z.ZSCORE(member1)
+	score2, ok2 := z.ZSCORE(member2)
+	if !ok1 || !ok2 {
+		return 0, false, nil
+	}
+	p1, p2 := geoDecode(score1), geoDecode(score2)
+	return util.GeoHashDistance(p1.Longitude, p1.Latitude, p2.Longitude, p2.Latitude) / conversion, true, nil
+}
+
+// GEOHASH returns the standard geohash strings of the members, "" for a missing member.
+func (z SortedSet) GEOHASH(members ...string) []string {
+	res := make([]string, len(members))
+	for i, m := range members {
+		if score, ok := z.ZSCORE(m); ok {
+			p := geoDecode(score)
+			res[i] = util.GeoHashString(p.Longitude, p.Latitude)
+		}
+	}
+	return res
+}
+
+// GEOSEARCH returns the members within the circle or the box around the center.
+// With Count and no Sort, the nearest are returned in ascending order.
+func (z SortedSet) GEOSEARCH(args GeoSearchArgs) ([]GeoResult, error) {
+	shape, err := newGeoShape(args)
+	if err != nil {
+		return nil, err
+	}
+	if args.FromLonLat != nil {
+		shape.center = *args.FromLonLat
+		if _, ok := util.GeoHashEncodeWGS84(shape.center.Longitude, shape.center.Latitude, util.GEO_STEP_MAX); !ok {
+			return nil, fmt.Errorf("ERR invalid longitude,latitude pair %f,%f", shape.center.Longitude, shape.center.Latitude)
+		}
+	} else {
+		score, ok := z.ZSCORE(*args.FromMember)
+		if !ok {
+			return nil, ErrGeoMemberMissing
+		}
+		shape.center = geoDecode(score)
+	}
+
+	limit := 0
+	if args.Any {
+		limit = args.Count
+	}
+	var res []GeoResult
+	for _, cell := range shape.cells() {
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+		spec := &datastructure.RangeSpec{
+			Min:   float64(cell.Align52Bits()),
+			Max:   float64(util.GeoHashBits{Bits: cell.Bits + 1, Step: cell.Step}.Align52Bits()),
+			MaxEx: true,
+		}
+		for _, m := range z.rangeByScore(spec) {
+			p := geoDecode(m.Value)
+			if dist, ok := shape.contains(p); ok {
+				res = append(res, GeoResult{m.Key, dist / shape.conversion, uint64(m.Value), p})
+				if limit > 0 && len(res) >= limit {
+					break
+				}
+			}
+		}
+	}
+
+	order := args.Sort
+	if args.Count > 0 && order == GEO_SORT_NONE && !args.Any {
+		order = GEO_SORT_ASC
+	}
+	switch order {
+	case GEO_SORT_ASC:
+		sort.SliceStable(res, func(i, j int) bool { return res[i].Dist < res[j].Dist })
+	case GEO_SORT_DESC:
+		sort.SliceStable(res, func(i, j int) bool { return res[i].Dist > res[j].Dist })
+	}
+	if args.Count > 0 && len(res) > args.Count {
+		res = res[:args.Count]
+	}
+	return res, nil
+}
+
+// geoShape is the area of a search, in meters.
+type geoShape struct {
+	center        GeoPoint
+	box           bool
+	radius        float64
+	width, height float64
+	conversion    float64
+}
+
+func newGeoShape(args GeoSearchArgs) (*geoShape, error) {
+	if (args.FromMember == nil) == (args.FromLonLat == nil) {
+		return nil, ErrGeoSearchFrom
+	}
+	if args.Shape != GEO_SHAPE_RADIUS && args.Shape != GEO_SHAPE_BOX {
+		return nil, ErrGeoSearchBy
+	}
+	if args.Count < 0 {
+		return nil, ErrGeoCount
+	}
+	if args.Any && args.Count == 0 {
+		return nil, ErrGeoAnyNoCount
+	}
+	conversion, err := GeoUnitConversion(args.Unit)
+	if err != nil {
+		return nil, err
+	}
+	if args.Radius < 0 || args.Width < 0 || args.Height < 0 {
+		return nil, ErrGeoNegative
+	}
+	return &geoShape{
+		box:        args.Shape == GEO_SHAPE_BOX,
+		radius:     args.Radius * conversion,
+		width:      args.Width * conversion,
+		height:     args.Height * conversion,
+		conversion: conversion,
+	}, nil
+}
+
+// contains returns the distance of the position from the center in meters,
+// and false if the position is out of the shape.
+func (s *geoShape) contains(p GeoPoint) (float64, bool) {
+	c := s.center
+	if !s.box {
+		dist := util.GeoHashDistance(c.Longitude, c.Latitude, p.Longitude, p.Latitude)
+		return dist, dist <= s.radius
+	}
+	if util.GeoHashLatDistance(p.Latitude, c.Latitude) > s.height/2 ||
+		util.GeoHashDistance(p.Longitude, p.Latitude, c.Longitude, p.Latitude) > s.width/2 {
+		return 0, false
+	}
+	return util.GeoHashDistance(c.Longitude, c.Latitude, p.Longitude, p.Latitude), true
+}
+
+// cells returns the cell of the center and its neighbors that may intersect the shape.
+func (s *geoShape) cells() []util.GeoHashBits {
+	c := s.center
+	var bounds [4]float64
+	radius := s.radius
+	if s.box {
+		bounds = util.GeoHashBoundingBox(c.Longitude, c.Latitude, s.width/2, s.height/2)
+		radius = math.Sqrt(s.width/2*s.width/2 + s.height/2*s.height/2)
+	} else {
+		bounds = util.GeoHashBoundingBox(c.Longitude, c.Latitude, s.radius, s.radius)
+	}
+
+	step := util.GeoHashEstimateStepsByRadius(radius, c.Latitude)
+	hash, _ := util.GeoHashEncodeWGS84(c.Longitude, c.Latitude, step)
+	neighbors := hash.Neighbors()
+	area := util.GeoHashDecodeWGS84(hash)
+
+	// a step is too large if a neighbor does not reach as far as the radius
+	north := util.GeoHashDecodeWGS84(neighbors.North)
+	south := util.GeoHashDecodeWGS84(neighbors.South)
+	east := util.GeoHashDecodeWGS84(neighbors.East)
+	west := util.GeoHashDecodeWGS84(neighbors.West)
+	if step > 1 && (util.GeoHashDistance(c.Longitude, c.Latitude, c.Longitude, north.Latitude.Max) < radius ||
+		util.GeoHashDistance(c.Longitude, c.Latitude, c.Longitude, south.Latitude.Min) < radius ||
+		util.GeoHashDistance(c.Longitude, c.Latitude, east.Longitude.Max, c.Latitude) < radius ||
+		util.GeoHashDistance(c.Longitude, c.Latitude, west.Longitude.Min, c.Latitude) < radius) {
+		step--
+		hash, _ = util.GeoHashEncodeWGS84(c.Longitude, c.Latitude, step)
+		neighbors = hash.Neighbors()
+		area = util.GeoHashDecodeWGS84(hash)
+	}
+
+	// exclude the neighbors out of the bounds
+	var zero util.GeoHashBits
+	if step >= 2 {
+		if area.Latitude.Min < bounds[1] {
+			neighbors.South, neighbors.SouthWest, neighbors.SouthEast = zero, zero, zero
+		}
+		if area.Latitude.Max > bounds[3] {
+			neighbors.North, neighbors.NorthEast, neighbors.NorthWest = zero, zero, zero
+		}
+		if area.Longitude.Min < bounds[0] {
+			neighbors.West, neighbors.SouthWest, neighbors.NorthWest = zero, zero, zero
+		}
+		if area.Longitude.Max > bounds[2] {
+			neighbors.East, neighbors.SouthEast, neighbors.NorthEast = zero, zero, zero
+		}
+	}
+
+	all := []util.GeoHashBits{
+		hash,
+		neighbors.North, neighbors.South, neighbors.East, neighbors.West,
+		neighbors.NorthEast, neighbors.NorthWest, neighbors.SouthEast, neighbors.SouthWest,
+	}
+	// the neighbors are the same cell when the step is small enough to wrap around
+	var res []util.GeoHashBits
+	seen := make(map[util.GeoHashBits]bool)
+	for _, cell := range all {
+		if !cell.IsZero() && !seen[cell] {
+			seen[cell] = true
+			res = append(res, cell)
+		}
+	}
+	return res
+}
+
+// geoDecode returns the position of a score, the center of its cell.
+func geoDecode(score float64) GeoPoint {
+	area := util.GeoHashDecodeWGS84(util.GeoHashBits{Bits: uint64(score), Step: util.GEO_STEP_MAX})
+	long, lat := area.Center()
+	return GeoPoint{long, lat}
+}
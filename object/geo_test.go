@@ -0,0 +1,162 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/util"
+)
+
+func newSicily(t *testing.T) SortedSet {
+	z := NewSortedSetObject()
+	_, err := z.GEOADD(0,
+		GeoMember{"Palermo", GeoPoint{13.361389, 38.115556}},
+		GeoMember{"Catania", GeoPoint{15.087269, 37.502669}},
+		GeoMember{"edge1", GeoPoint{12.758489, 38.788135}},
+		GeoMember{"edge2", GeoPoint{17.241510, 38.788135}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return z
+}
+
+func resultsOf(results []GeoResult) string {
+	s := ""
+	for _, r := range results {
+		s += fmt.Sprintf("%s:%.4f ", r.Member, r.Dist)
+	}
+	return s
+}
+
+func fromMember(member string) *string {
+	return &member
+}
+
+// the expectations are the replies of Redis
+func TestSortedSet_Geo(t *testing.T) {
+	z := newSicily(t)
+
+	if score, _ := z.ZSCORE("Palermo"); score != 3479099956230698 {
+		t.Errorf("score of Palermo = %f", score)
+	}
+	if pos := z.GEOPOS("Palermo", "missing"); pos[1] != nil ||
+		fmt.Sprintf("%.6f,%.6f", pos[0].Longitude, pos[0].Latitude) != "13.361389,38.115556" {
+		t.Errorf("GEOPOS = %v, %v", pos[0], pos[1])
+	}
+	if dist, ok, err := z.GEODIST("Palermo", "Catania", "KM"); !ok || err != nil || fmt.Sprintf("%.4f", dist) != "166.2742" {
+		t.Errorf("GEODIST = %f, %v, %v", dist, ok, err)
+	}
+	if _, ok, _ := z.GEODIST("Palermo", "missing", "m"); ok {
+		t.Errorf("GEODIST of a missing member ok")
+	}
+	if _, _, err := z.GEODIST("Palermo", "Catania", "yd"); err != ErrGeoUnit {
+		t.Errorf("GEODIST unit err = %v", err)
+	}
+	if got := fmt.Sprint(z.GEOHASH("Palermo", "Catania", "missing")); got != "[sqc8b49rny0 sqdtr74hyu0 ]" {
+		t.Errorf("GEOHASH = %v", got)
+	}
+
+	tests := []struct {
+		args GeoSearchArgs
+		want string
+	}{
+		{GeoSearchArgs{FromLonLat: &GeoPoint{15, 37}, Shape: GEO_SHAPE_RADIUS, Radius: 200, Unit: "km", Sort: GEO_SORT_ASC},
+			"Catania:56.4413 Palermo:190.4424 "},
+		{GeoSearchArgs{FromLonLat: &GeoPoint{15, 37}, Shape: GEO_SHAPE_BOX, Width: 400, Height: 400, Unit: "km", Sort: GEO_SORT_ASC},
+			"Catania:56.4413 Palermo:190.4424 edge2:279.7403 edge1:279.7405 "},
+		{GeoSearchArgs{FromLonLat: &GeoPoint{15, 37}, Shape: GEO_SHAPE_BOX, Width: 400, Height: 400, Unit: "km", Sort: GEO_SORT_DESC, Count: 2},
+			"edge1:279.7405 edge2:279.7403 "},
+		{GeoSearchArgs{FromMember: fromMember("Palermo"), Shape: GEO_SHAPE_RADIUS, Radius: 200, Unit: "km", Count: 1},
+			"Palermo:0.0000 "},
+		{GeoSearchArgs{FromMember: fromMember("Catania"), Shape: GEO_SHAPE_RADIUS, Radius: 100, Unit: "mi"},
+			"Catania:0.0000 "},
+	}
+	for _, tt := range tests {
+		got, err := z.GEOSEARCH(tt.args)
+		if err != nil || resultsOf(got) != tt.want {
+			t.Errorf("GEOSEARCH(%+v) = %v, %v, want %v", tt.args, resultsOf(got), err, tt.want)
+		}
+	}
+
+	got, _ := z.GEOSEARCH(GeoSearchArgs{FromMember: fromMember("Catania"), Shape: GEO_SHAPE_RADIUS, Radius: 500, Unit: "km", Count: 2, Any: true})
+	if len(got) != 2 {
+		t.Errorf("GEOSEARCH ANY = %v", resultsOf(got))
+	}
+
+	errs := []struct {
+		args GeoSearchArgs
+		err  error
+	}{
+		{GeoSearchArgs{Shape: GEO_SHAPE_RADIUS, Unit: "m"}, ErrGeoSearchFrom},
+		{GeoSearchArgs{FromMember: fromMember("Palermo"), FromLonLat: &GeoPoint{}, Shape: GEO_SHAPE_RADIUS, Unit: "m"}, ErrGeoSearchFrom},
+		{GeoSearchArgs{FromMember: fromMember("Palermo"), Unit: "m"}, ErrGeoSearchBy},
+		{GeoSearchArgs{FromMember: fromMember("missing"), Shape: GEO_SHAPE_RADIUS, Unit: "m"}, ErrGeoMemberMissing},
+		{GeoSearchArgs{FromMember: fromMember("Palermo"), Shape: GEO_SHAPE_RADIUS, Unit: "m", Any: true}, ErrGeoAnyNoCount},
+		{GeoSearchArgs{FromMember: fromMember("Palermo"), Shape: GEO_SHAPE_RADIUS, Unit: "m", Radius: -1}, ErrGeoNegative},
+		{GeoSearchArgs{FromMember: fromMember("Palermo"), Shape: GEO_SHAPE_RADIUS, Unit: "x"}, ErrGeoUnit},
+	}
+	for _, e := range errs {
+		if _, err := z.GEOSEARCH(e.args); err != e.err {
+			t.Errorf("GEOSEARCH(%+v) err = %v, want %v", e.args, err, e.err)
+		}
+	}
+
+	if _, err := z.GEOADD(0, GeoMember{"pole", GeoPoint{0, 86}}); err == nil {
+		t.Errorf("GEOADD of an invalid position succeeded")
+	}
+	if _, err := z.GEOADD(ZADD_GT, GeoMember{"x", GeoPoint{}}); err != ErrGeoAddFlags {
+		t.Errorf("GEOADD GT err = %v", err)
+	}
+	if n, _ := z.GEOADD(ZADD_XX|ZADD_CH, GeoMember{"Palermo", GeoPoint{13, 38}}, GeoMember{"new", GeoPoint{}}); n != 1 || z.ZCARD() != 4 {
+		t.Errorf("GEOADD XX CH = %d, ZCARD() = %d", n, z.ZCARD())
+	}
+
+	// the empty member is a member to search from
+	z.GEOADD(0, GeoMember{"", GeoPoint{15, 37}})
+	got, err := z.GEOSEARCH(GeoSearchArgs{FromMember: fromMember(""), Shape: GEO_SHAPE_RADIUS, Radius: 60, Unit: "km", Sort: GEO_SORT_ASC})
+	if err != nil || resultsOf(got) != ":0.0000 Catania:56.4412 " {
+		t.Errorf("GEOSEARCH FROMMEMBER \"\" = %v, %v", resultsOf(got), err)
+	}
+}
+
+// GEOSEARCH finds the same members as checking every member, wherever the center is.
+func TestSortedSet_GeoSearchCoverage(t *testing.T) {
+	withZSetEncodings(t, func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		for round := 0; round < 30; round++ {
+			center := GeoPoint{r.Float64()*360 - 180, r.Float64()*160 - 80}
+			radius := math.Pow(10, 2+r.Float64()*4) // 100m to 1000km
+
+			z := NewSortedSetObject()
+			for i := 0; i < 100; i++ {
+				// around the center, up to twice the radius away
+				dLat := (r.Float64()*4 - 2) * radius / util.EarthRadiusInMeters * 180 / math.Pi
+				dLong := dLat / math.Max(math.Cos(center.Latitude*math.Pi/180), 0.1) * (r.Float64()*2 - 1)
+				p := GeoPoint{math.Max(-180, math.Min(180, center.Longitude+dLong)), math.Max(-85, math.Min(85, center.Latitude+dLat))}
+				z.GEOADD(0, GeoMember{fmt.Sprint(i), p})
+			}
+
+			var want []string
+			for _, m := range z.members() {
+				p := geoDecode(m.Value)
+				if util.GeoHashDistance(center.Longitude, center.Latitude, p.Longitude, p.Latitude) <= radius {
+					want = append(want, m.Key)
+				}
+			}
+			results, err := z.GEOSEARCH(GeoSearchArgs{FromLonLat: &center, Shape: GEO_SHAPE_RADIUS, Radius: radius, Unit: "m"})
+			var got []string
+			for _, res := range results {
+				got = append(got, res.Member)
+			}
+			sort.Strings(want)
+			sort.Strings(got)
+			if err != nil || fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("GEOSEARCH around %v within %.0fm = %v, %v, want %v", center, radius, got, err, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package object
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+
+	"github.com/viktorxhzj/mykv/datastructure"
+	"github.com/viktorxhzj/mykv/util"
+)
+
+//
+// A hash object is encoded as:
+// OBJ_ENCODING_ZIPLIST: a ziplist of fields each followed by its value, for small hashes;
+// OBJ_ENCODING_HT:      a dict from field to value.
+//
+// A hash converts from ziplist to dict once it has more fields than hash-max-ziplist-entries,
+// or a field or value longer than hash-max-ziplist-value.
+// It converts back only by Compact or Convert.
+//
+
+var (
+	ErrHashValueNotInteger = errors.New("ERR hash value is not an integer")
+	ErrHashValueNotFloat   = errors.New("ERR hash value is not a float")
+	ErrIncrOverflow        = errors.New("ERR increment or decrement would overflow")
+	ErrIncrNaNOrInf        = errors.New("ERR increment would produce NaN or Infinity")
+)
+
+// Hash is a hash object.
+type Hash struct {
+	*ValueObject
+}
+
+// HashField is a field and its value.
+type HashField struct {
+	Field string
+	Value string
+}
+
+// NewHashObject creates an empty hash object encoded as a ziplist.
+func NewHashObject() Hash {
+	o := new(ValueObject)
+	o.SetType(OBJ_HASH, OBJ_ENCODING_ZIPLIST)
+	o.Structure = datastructure.NewZipList()
+	return Hash{o}
+}
+
+// HSET sets the fields to their values,
+// and returns the number of fields that are added rather than updated.
+func (h Hash) HSET(fields ...HashField) int {
+	added := 0
+	for _, f := range fields {
+		if h.set(f.Field, f.Value) {
+			added++
+		}
+	}
+	return added
+}
+
+// HSETNX sets the field to the value only if the field does not exist,
+// and returns true if it is set.
+func (h Hash) HSETNX(field, value string) bool {
+	if h.HEXISTS(field) {
+		return false
+	}
+	h.HSET(HashField{field, value})
+	return true
+}
+
+// HGET returns the value of the field, and false if the field does not exist.
+func (h Hash) HGET(field string) (string, bool) {
+	if _, et := h.GetType(); et == OBJ_ENCODING_HT {
+		return h.Structure.(*datastructure.Dict).Find(field)
+	}
+	zl := h.Structure.(*datastructure.ZipList)
+	idx := zl.Find(field, 1)
+	if idx == -1 {
+		return "", false
+	}
+	return zipListGet(zl, idx+1), true
+}
+
+// HDEL deletes the fields, and returns the number of fields that existed.
+func (h Hash) HDEL(fields ...string) int {
+	deleted := 0
+	for _, field := range fields {
+		if _, et := h.GetType(); et == OBJ_ENCODING_HT {
+			d := h.Structure.(*datastructure.Dict)
+			if _, ok := d.Find(field); ok {
+				d.Delete(field)
+				deleted++
+			}
+		} else {
+			zl := h.Structure.(*datastructure.ZipList)
+			if idx := zl.Find(field, 1); idx != -1 {
+				zl.DeleteRange(idx, 2)
+				deleted++
+			}
+		}
+	}
+	return deleted
+}
+
+// HEXISTS reports whether the field exists.
+func (h Hash) HEXISTS(field string) bool {
+	_, ok := h.HGET(field)
+	return ok
+}
+
+// HLEN returns the number of fields.
+func (h Hash) HLEN() int {
+	if _, et := h.GetType(); et == OBJ_ENCODING_HT {
+		return h.Structure.(*datastructure.Dict).Size()
+	}
+	return h.Structure.(*datastructure.ZipList).ZLLen() / 2
+}
+
+// HSTRLEN returns the length of the value of the field, or 0 if the field does not exist.
+func (h Hash) HSTRLEN(field string) int {
+	v, _ := h.HGET(field)
+	return len(v)
+}
+
+// HGETALL returns all the fields with their values.
+func (h Hash) HGETALL() []HashField {
+	res := make([]HashField, 0, h.HLEN())
+	if _, et := h.GetType(); et == OBJ_ENCODING_HT {
+		it := h.Structure.(*datastructure.Dict).Iterator()
+		for e := it.Next(); e != nil; e = it.Next() {
+			de := e.(datastructure.DictEntry)
+			res = append(res, HashField{de.Key, de.Val})
+		}
+		return res
+	}
+	entries := h.Structure.(*datastructure.ZipList).Range(0, -1)
+	for i := 0; i < len(entries); i += 2 {
+		res = append(res, HashField{zipListString(entries[i]), zipListString(entries[i+1])})
+	}
+	return res
+}
+
+// HKEYS returns all the fields.
+func (h Hash) HKEYS() []string {
+	all := h.HGETALL()
+	res := make([]string, len(all))
+	for i, f := range all {
+		res[i] = f.Field
+	}
+	return res
+}
+
+// HVALS returns all the values.
+func (h Hash) HVALS() []string {
+	all := h.HGETALL()
+	res := make([]string, len(all))
+	for i, f := range all {
+		res[i] = f.Value
+	}
+	return res
+}
+
+// HINCRBY increments the integer value of the field by incr,
+// where a missing field counts as 0, and returns the new value.
+func (h Hash) HINCRBY(field string, incr int64) (int64, error) {
+	var v int64
+	if s, ok := h.HGET(field); ok {
+		if v, ok = util.StringToInt64([]byte(s)); !ok {
+			return 0, ErrHashValueNotInteger
+		}
+	}
+	if (incr < 0 && v < 0 && incr < math.MinInt64-v) ||
+		(incr > 0 && v > 0 && incr > math.MaxInt64-v) {
+		return 0, ErrIncrOverflow
+	}
+	v += incr
+	h.set(field, strconv.FormatInt(v, 10))
+	return v, nil
+}
+
+// HINCRBYFLOAT increments the float value of the field by incr in long double precision,
+// where a missing field counts as 0, and returns the new value.
+func (h Hash) HINCRBYFLOAT(field string, incr float64) (string, error) {
+	v := new(big.Float)
+	if s, ok := h.HGET(field); ok {
+		if v, ok = util.StringToLongDouble([]byte(s)); !ok {
+			return "", ErrHashValueNotFloat
+		}
+	}
+	d, ok := util.Float64ToLongDouble(incr)
+	if ok {
+		v, ok = util.AddLongDouble(v, d)
+	}
+	if !ok {
+		return "", ErrIncrNaNOrInf
+	}
+	s := util.LongDoubleToString(v)
+	h.set(field, s)
+	return s, nil
+}
+
+// HRANDFIELD returns count random fields with their values.
+// If count is positive, the fields are distinct, and at most all the fields are returned.
+// If count is negative, -count fields are returned, which may repeat.
+func (h Hash) HRANDFIELD(count int) []HashField {
+	size := h.HLEN()
+	if count == 0 || size == 0 {
+		return nil
+	}
+
+	if count < 0 {
+		res := make([]HashField, -count)
+		for i := range res {
+			res[i] = h.random()
+		}
+		return res
+	}
+
+	all := h.HGETALL()
+	if count >= size {
+		return all
+	}
+	// partial Fisher-Yates shuffle
+	for i := 0; i < count; i++ {
+		j := i + rand.Intn(size-i)
+		all[i], all[j] = all[j], all[i]
+	}
+	return all[:count]
+}
+
+// random returns a random field with its value, the hash must not be empty.
+func (h Hash) random() HashField {
+	if _, et := h.GetType(); et == OBJ_ENCODING_HT {
+		e, _ := h.Structure.(*datastructure.Dict).RandomEntry()
+		return HashField{e.Key, e.Val}
+	}
+	zl := h.Structure.(*datastructure.ZipList)
+	idx := rand.Intn(zl.ZLLen()/2) * 2
+	return HashField{zipListGet(zl, idx), zipListGet(zl, idx+1)}
+}
+
+// set sets the field to the value, and returns true if the field is added.
+func (h Hash) set(field, value string) bool {
+	if _, et := h.GetType(); et == OBJ_ENCODING_ZIPLIST && !hashZipListValuesFit(field, value) {
+		h.convert()
+	}
+
+	if _, et := h.GetType(); et == OBJ_ENCODING_HT {
+		d := h.Structure.(*datastructure.Dict)
+		_, ok := d.Find(field)
+		d.Put(field, value)
+		return !ok
+	}
+
+	zl := h.Structure.(*datastructure.ZipList)
+	if idx := zl.Find(field, 1); idx != -1 {
+		zipListReplace(zl, idx+1, value)
+		return false
+	}
+	if hashZipListEntriesFit(h.HLEN() + 1) {
+		n := zl.ZLLen()
+		if zipListInsert(zl, n, field) == nil {
+			if zipListInsert(zl, n+1, value) == nil {
+				return true
+			}
+			zl.Delete(n)
+		}
+	}
+	// the ziplist cannot take another field
+	h.convert()
+	h.Structure.(*datastructure.Dict).Put(field, value)
+	return true
+}
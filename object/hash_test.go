@@ -0,0 +1,198 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/config"
+	"github.com/viktorxhzj/mykv/datastructure"
+)
+
+func hashEncoding(h Hash) uint8 {
+	_, et := h.GetType()
+	return et
+}
+
+func TestHash_Commands(t *testing.T) {
+	defer func(entries int) { config.HashMaxZiplistEntries = entries }(config.HashMaxZiplistEntries)
+
+	for _, entries := range []int{128, 0} {
+		config.HashMaxZiplistEntries = entries
+		h := NewHashObject()
+
+		if n := h.HSET(HashField{"a", "1"}, HashField{"b", "x"}, HashField{"a", "2"}); n != 2 {
+			t.Errorf("HSET = %d", n)
+		}
+		if v, ok := h.HGET("a"); v != "2" || !ok {
+			t.Errorf("HGET = %q, %v", v, ok)
+		}
+		if _, ok := h.HGET("missing"); ok {
+			t.Errorf("HGET missing field")
+		}
+		// a value that equals a field is not a field
+		h.HSET(HashField{"c", "a"}, HashField{"empty", ""})
+		if v, ok := h.HGET("empty"); v != "" || !ok {
+			t.Errorf("HGET empty value = %q, %v", v, ok)
+		}
+		if h.HEXISTS("x") || !h.HEXISTS("c") {
+			t.Errorf("HEXISTS")
+		}
+		if h.HSETNX("a", "3") || !h.HSETNX("d", "4") {
+			t.Errorf("HSETNX")
+		}
+		if n := h.HLEN(); n != 5 {
+			t.Errorf("HLEN = %d", n)
+		}
+		if n := h.HSTRLEN("b"); n != 1 {
+			t.Errorf("HSTRLEN = %d", n)
+		}
+
+		keys := h.HKEYS()
+		sort.Strings(keys)
+		if strings.Join(keys, ",") != "a,b,c,d,empty" {
+			t.Errorf("HKEYS = %v", keys)
+		}
+		vals := h.HVALS()
+		sort.Strings(vals)
+		if strings.Join(vals, ",") != ",2,4,a,x" {
+			t.Errorf("HVALS = %v", vals)
+		}
+
+		if n := h.HDEL("a", "missing", "c"); n != 2 || h.HLEN() != 3 {
+			t.Errorf("HDEL = %d, HLEN = %d", n, h.HLEN())
+		}
+
+		if v, err := h.HINCRBY("d", -10); v != -6 || err != nil {
+			t.Errorf("HINCRBY = %d, %v", v, err)
+		}
+		if v, _ := h.HINCRBY("new", 5); v != 5 {
+			t.Errorf("HINCRBY missing field = %d", v)
+		}
+		if _, err := h.HINCRBY("b", 1); err != ErrHashValueNotInteger {
+			t.Errorf("HINCRBY err = %v", err)
+		}
+		h.HSET(HashField{"max", "9223372036854775807"})
+		if _, err := h.HINCRBY("max", 1); err != ErrIncrOverflow {
+			t.Errorf("HINCRBY overflow err = %v", err)
+		}
+		if v, err := h.HINCRBYFLOAT("d", 0.5); v != "-5.5" || err != nil {
+			t.Errorf("HINCRBYFLOAT = %s, %v", v, err)
+		}
+		h.HSET(HashField{"tenth", "0.1"})
+		if v, _ := h.HINCRBYFLOAT("tenth", 0.2); v != "0.3" {
+			t.Errorf("HINCRBYFLOAT 0.1 by 0.2 = %s", v)
+		}
+		if _, err := h.HINCRBYFLOAT("b", 1); err != ErrHashValueNotFloat {
+			t.Errorf("HINCRBYFLOAT err = %v", err)
+		}
+
+		wantEncoding := uint8(OBJ_ENCODING_ZIPLIST)
+		if entries == 0 {
+			wantEncoding = OBJ_ENCODING_HT
+		}
+		if et := hashEncoding(h); et != wantEncoding {
+			t.Errorf("encoding = %d, want %d", et, wantEncoding)
+		}
+	}
+}
+
+func TestHash_Conversion(t *testing.T) {
+	h := NewHashObject()
+	for i := 0; i < config.HashMaxZiplistEntries; i++ {
+		h.HSET(HashField{fmt.Sprint("f", i), "v"})
+	}
+	if hashEncoding(h) != OBJ_ENCODING_ZIPLIST {
+		t.Fatalf("converted before reaching hash-max-ziplist-entries")
+	}
+	h.HSET(HashField{"one more", "v"})
+	if hashEncoding(h) != OBJ_ENCODING_HT || h.HLEN() != config.HashMaxZiplistEntries+1 {
+		t.Errorf("not converted after passing hash-max-ziplist-entries")
+	}
+	if v, _ := h.HGET("f10"); v != "v" {
+		t.Errorf("HGET after conversion = %q", v)
+	}
+
+	h = NewHashObject()
+	h.HSET(HashField{"f", strings.Repeat("v", config.HashMaxZiplistValue)})
+	if hashEncoding(h) != OBJ_ENCODING_ZIPLIST {
+		t.Fatalf("converted before passing hash-max-ziplist-value")
+	}
+	h.HSET(HashField{"f", strings.Repeat("v", config.HashMaxZiplistValue+1)})
+	if hashEncoding(h) != OBJ_ENCODING_HT || h.HSTRLEN("f") != config.HashMaxZiplistValue+1 {
+		t.Errorf("not converted after passing hash-max-ziplist-value")
+	}
+}
+
+func TestHash_ZipListMaxLen(t *testing.T) {
+	defer func(entries int) { config.HashMaxZiplistEntries = entries }(config.HashMaxZiplistEntries)
+
+	// a full ziplist of the most fields it holds, as HSET would build it
+	full := func() Hash {
+		h := NewHashObject()
+		zl := h.Structure.(*datastructure.ZipList)
+		for i := 0; i < datastructure.ZL_MAX_LEN/2; i++ {
+			zl.AddString(fmt.Sprint("f", i))
+			zl.AddString("v")
+		}
+		return h
+	}
+
+	// one more than config allows, so that the insert itself fails
+	for _, entries := range []int{datastructure.ZL_MAX_LEN / 2, datastructure.ZL_MAX_LEN/2 + 1} {
+		config.HashMaxZiplistEntries = entries
+		h := full()
+		if n := h.HSET(HashField{"one more", "x"}); n != 1 {
+			t.Errorf("HSET = %d", n)
+		}
+		if hashEncoding(h) != OBJ_ENCODING_HT || h.HLEN() != datastructure.ZL_MAX_LEN/2+1 {
+			t.Errorf("hash-max-ziplist-entries %d: not converted at ZL_MAX_LEN", entries)
+		}
+		if v, ok := h.HGET("one more"); v != "x" || !ok {
+			t.Errorf("HGET = %q, %v", v, ok)
+		}
+		if v, ok := h.HGET("f0"); v != "v" || !ok {
+			t.Errorf("HGET after conversion = %q, %v", v, ok)
+		}
+	}
+}
+
+func TestHash_RandField(t *testing.T) {
+	defer func(entries int) { config.HashMaxZiplistEntries = entries }(config.HashMaxZiplistEntries)
+
+	for _, entries := range []int{128, 0} {
+		config.HashMaxZiplistEntries = entries
+		h := NewHashObject()
+		if res := h.HRANDFIELD(3); len(res) != 0 {
+			t.Errorf("HRANDFIELD of empty hash = %v", res)
+		}
+		for _, f := range []string{"a", "b", "c", "d", "e"} {
+			h.HSET(HashField{f, f + f})
+		}
+
+		res := h.HRANDFIELD(3)
+		seen := make(map[string]bool)
+		for _, f := range res {
+			if seen[f.Field] || f.Value != f.Field+f.Field {
+				t.Errorf("HRANDFIELD 3 = %v", res)
+			}
+			seen[f.Field] = true
+		}
+		if len(res) != 3 {
+			t.Errorf("HRANDFIELD 3 returns %d fields", len(res))
+		}
+		if res := h.HRANDFIELD(10); len(res) != 5 {
+			t.Errorf("HRANDFIELD 10 returns %d fields", len(res))
+		}
+		res = h.HRANDFIELD(-20)
+		if len(res) != 20 {
+			t.Errorf("HRANDFIELD -20 returns %d fields", len(res))
+		}
+		for _, f := range res {
+			if f.Value != f.Field+f.Field {
+				t.Errorf("HRANDFIELD -20 = %v", res)
+			}
+		}
+	}
+}
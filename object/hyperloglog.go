@@ -0,0 +1,93 @@
+package object
+
+import (
+	"errors"
+
+	"github.com/viktorxhzj/mykv/config"
+	"github.com/viktorxhzj/mykv/datastructure"
+)
+
+//
+// A HyperLogLog is a RAW encoded string object holding a datastructure.HyperLogLog,
+// so that it can be read with GET and written back with SET as any string.
+//
+
+var (
+	ErrNotHyperLogLog = errors.New("WRONGTYPE Key is not a valid HyperLogLog string value.")
+	ErrHLLCorrupted   = errors.New("INVALIDOBJ Corrupted HLL object detected")
+)
+
+// NewHyperLogLogObject creates a string object holding an empty HyperLogLog.
+func NewHyperLogLogObject() *ValueObject {
+	o := new(ValueObject)
+	o.SetType(OBJ_STRING, OBJ_ENCODING_RAW)
+	o.Structure = []byte(*datastructure.NewHyperLogLog())
+	return o
+}
+
+// IsHyperLogLog reports whether the value of a string object is a HyperLogLog.
+func (o *ValueObject) IsHyperLogLog() bool {
+	return datastructure.IsHyperLogLog(o.StringBytes())
+}
+
+// PFADD adds the elements to the HyperLogLog of a RAW encoded string object,
+// and returns true if its estimated cardinality may have changed.
+func (o *ValueObject) PFADD(elements ...[]byte) (bool, error) {
+	hll := datastructure.HyperLogLog(o.Structure.([]byte))
+	defer func() { o.Structure = []byte(hll) }()
+
+	updated := false
+	for _, e := range elements {
+		changed, err := hll.Add(e, config.HllSparseMaxBytes)
+		if err != nil {
+			return updated, ErrHLLCorrupted
+		}
+		updated = updated || changed
+	}
+	return updated, nil
+}
+
+// PFCOUNT returns the estimated cardinality of the HyperLogLog of a RAW encoded string object,
+// which is cached in the object.
+func (o *ValueObject) PFCOUNT() (uint64, error) {
+	hll := datastructure.HyperLogLog(o.Structure.([]byte))
+	n, err := hll.Count()
+	if err != nil {
+		return 0, ErrHLLCorrupted
+	}
+	return n, nil
+}
+
+// PFCOUNT returns the estimated cardinality of the union of the HyperLogLogs of the string objects.
+func PFCOUNT(objs ...*ValueObject) (uint64, error) {
+	registers, err := mergeHyperLogLogs(objs)
+	if err != nil {
+		return 0, err
+	}
+	return datastructure.CountRegisters(registers), nil
+}
+
+// PFMERGE creates a string object holding the union of the HyperLogLogs of the string objects,
+// which is dense.
+func PFMERGE(objs ...*ValueObject) (*ValueObject, error) {
+	registers, err := mergeHyperLogLogs(objs)
+	if err != nil {
+		return nil, err
+	}
+	o := new(ValueObject)
+	o.SetType(OBJ_STRING, OBJ_ENCODING_RAW)
+	o.Structure = []byte(*datastructure.NewDenseHyperLogLog(registers))
+	return o, nil
+}
+
+// mergeHyperLogLogs returns the maximum of each register of the HyperLogLogs.
+func mergeHyperLogLogs(objs []*ValueObject) ([]uint8, error) {
+	registers := make([]uint8, datastructure.HLL_REGISTERS)
+	for _, o := range objs {
+		hll := datastructure.HyperLogLog(o.StringBytes())
+		if err := hll.Merge(registers); err != nil {
+			return nil, ErrHLLCorrupted
+		}
+	}
+	return registers, nil
+}
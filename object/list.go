@@ -0,0 +1,278 @@
+package object
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/viktorxhzj/mykv/config"
+	"github.com/viktorxhzj/mykv/datastructure"
+)
+
+//
+// A list object is encoded as OBJ_ENCODING_QUICKLIST,
+// with the fill and compress depth given by list-max-ziplist-size and list-compress-depth
+// at the time the list is created.
+//
+
+const (
+	LIST_HEAD = 0
+	LIST_TAIL = 1
+)
+
+var (
+	ErrIndexOutOfRange = errors.New("ERR index out of range")
+	ErrRankZero        = errors.New("ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the last match")
+	ErrRankOutOfRange  = errors.New("ERR value is out of range, value must between -9223372036854775807 and 9223372036854775807")
+	ErrNegativeCount   = errors.New("ERR COUNT can't be negative")
+	ErrNegativeMaxLen  = errors.New("ERR MAXLEN can't be negative")
+)
+
+// List is a list object.
+type List struct {
+	*ValueObject
+}
+
+// NewListObject creates an empty list object.
+func NewListObject() List {
+	// the options are validated when they are set
+	q, _ := datastructure.NewQuickListWithOptions(config.ListMaxZiplistSize, config.ListCompressDepth)
+	o := new(ValueObject)
+	o.SetType(OBJ_LIST, OBJ_ENCODING_QUICKLIST)
+	o.Structure = q
+	return List{o}
+}
+
+func (l List) quickList() *datastructure.QuickList {
+	return l.Structure.(*datastructure.QuickList)
+}
+
+// LPUSH inserts the elements at the head one after another,
+// and returns the new length.
+func (l List) LPUSH(elements ...string) int {
+	for _, e := range elements {
+		l.push(e, LIST_HEAD)
+	}
+	return l.LLEN()
+}
+
+// RPUSH inserts the elements at the tail one after another,
+// and returns the new length.
+func (l List) RPUSH(elements ...string) int {
+	for _, e := range elements {
+		l.push(e, LIST_TAIL)
+	}
+	return l.LLEN()
+}
+
+// LPOP removes and returns at most count elements from the head.
+func (l List) LPOP(count int) []string {
+	return l.pop(count, LIST_HEAD)
+}
+
+// RPOP removes and returns at most count elements from the tail.
+func (l List) RPOP(count int) []string {
+	return l.pop(count, LIST_TAIL)
+}
+
+// LLEN returns the number of elements.
+func (l List) LLEN() int {
+	return l.quickList().Size()
+}
+
+// LINDEX returns the element at the index, and false if the index is out of range.
+// A negative index counts from the tail, -1 being the last element.
+func (l List) LINDEX(idx int) (string, bool) {
+	entry, err := l.quickList().Get(idx)
+	if err != nil {
+		return "", false
+	}
+	return quickListEntryString(entry), true
+}
+
+// LSET sets the element at the index.
+// A negative index counts from the tail, -1 being the last element.
+func (l List) LSET(idx int, element string) error {
+	if l.quickList().ReplaceAtIndex(idx, quickListValue(element)) != nil {
+		return ErrIndexOutOfRange
+	}
+	return nil
+}
+
+// LRANGE returns the elements from index start to index stop (both inclusive).
+// Negative indices count from the tail, and out-of-range indices are clamped.
+func (l List) LRANGE(start, stop int) []string {
+	elements := l.quickList().Range(start, stop)
+	res := make([]string, len(elements))
+	for i, e := range elements {
+		res[i] = zipListString(e)
+	}
+	return res
+}
+
+// LINSERT inserts the element before or after the first occurrence of pivot,
+// and returns the new length, or -1 if pivot is not found.
+func (l List) LINSERT(after bool, pivot, element string) int {
+	q := l.quickList()
+	it := q.Iterator(false)
+	for e := it.Next(); e != nil; e = it.Next() {
+		entry := e.(datastructure.QuickListEntry)
+		if quickListEntryString(entry) != pivot {
+			continue
+		}
+		it.Release()
+		if after {
+			q.InsertAfter(entry, quickListValue(element))
+		} else {
+			q.InsertBefore(entry, quickListValue(element))
+		}
+		return q.Size()
+	}
+	return -1
+}
+
+// LREM removes the first count occurrences of the element from the head,
+// or the last -count occurrences from the tail if count is negative,
+// or all the occurrences if count is 0,
+// and returns the number of removed elements.
+func (l List) LREM(count int, element string) int {
+	it := l.quickList().Iterator(count < 0)
+	if count < 0 {
+		count = -count
+	}
+
+	removed := 0
+	for e := it.Next(); e != nil; e = it.Next() {
+		entry := e.(datastructure.QuickListEntry)
+		if quickListEntryString(entry) != element {
+			continue
+		}
+		it.DelEntry(entry)
+		removed++
+		if removed == count {
+			it.Release()
+			break
+		}
+	}
+	return removed
+}
+
+// LTRIM keeps only the elements from index start to index stop (both inclusive).
+// Negative indices count from the tail, and out-of-range indices are clamped.
+func (l List) LTRIM(start, stop int) {
+	l.quickList().Trim(start, stop)
+}
+
+// LPOS returns the indices of the matches of the element.
+//
+// The search starts from the rank-th match from the head,
+// or from the -rank-th match from the tail if rank is negative.
+// It returns at most count indices, or all of them if count is 0,
+// and compares at most maxLen elements, or all of them if maxLen is 0.
+// LPOS without COUNT is a count of 1.
+func (l List) LPOS(element string, rank, count, maxLen int) ([]int, error) {
+	switch {
+	case rank == 0:
+		return nil, ErrRankZero
+	case rank < 0 && -rank < 0:
+		// the smallest int, which has no opposite
+		return nil, ErrRankOutOfRange
+	case count < 0:
+		return nil, ErrNegativeCount
+	case maxLen < 0:
+		return nil, ErrNegativeMaxLen
+	}
+
+	q := l.quickList()
+	reverse := rank < 0
+	if reverse {
+		rank = -rank
+	}
+
+	var res []int
+	it := q.Iterator(reverse)
+	defer it.Release()
+	matches := 0
+	for i, e := 0, it.Next(); e != nil && (maxLen == 0 || i < maxLen); i, e = i+1, it.Next() {
+		if quickListEntryString(e.(datastructure.QuickListEntry)) != element {
+			continue
+		}
+		matches++
+		if matches < rank {
+			continue
+		}
+		if reverse {
+			res = append(res, q.Size()-1-i)
+		} else {
+			res = append(res, i)
+		}
+		if len(res) == count {
+			break
+		}
+	}
+	return res, nil
+}
+
+// LMOVE pops an element from the head or the tail of the list,
+// pushes it to the head or the tail of dst, and returns it.
+// It returns false if the list is empty.
+// The list and dst may be the same, which rotates it.
+func (l List) LMOVE(dst List, from, to int) (string, bool) {
+	if dst.ValueObject == l.ValueObject && (from == LIST_TAIL || to == LIST_HEAD) {
+		if from == to {
+			// the element is popped and pushed back where it was
+			if from == LIST_HEAD {
+				return l.LINDEX(0)
+			}
+			return l.LINDEX(-1)
+		}
+		// the tail moves to the head in place
+		e, ok := l.LINDEX(-1)
+		l.quickList().Rotate()
+		return e, ok
+	}
+	res := l.pop(1, from)
+	if len(res) == 0 {
+		return "", false
+	}
+	dst.push(res[0], to)
+	return res[0], true
+}
+
+func (l List) push(element string, where int) {
+	if where == LIST_HEAD {
+		l.quickList().PushHead(quickListValue(element))
+	} else {
+		l.quickList().PushTail(quickListValue(element))
+	}
+}
+
+func (l List) pop(count int, where int) []string {
+	var res []string
+	q := l.quickList()
+	for len(res) < count && q.Size() > 0 {
+		var e interface{}
+		if where == LIST_HEAD {
+			e, _ = q.PopHead()
+		} else {
+			e, _ = q.PopTail()
+		}
+		res = append(res, zipListString(e))
+	}
+	return res
+}
+
+// quickListValue converts an element to what a quicklist stores,
+// an int if it is the canonical form of an integer, or else the string.
+func quickListValue(element string) interface{} {
+	if i, ok := zipListTryInt(element); ok {
+		return i
+	}
+	return element
+}
+
+func quickListEntryString(entry datastructure.QuickListEntry) string {
+	if entry.IsString {
+		return entry.String
+	}
+	return strconv.Itoa(entry.Integer)
+}
@@ -0,0 +1,220 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/config"
+)
+
+func newList(elements ...string) List {
+	l := NewListObject()
+	l.RPUSH(elements...)
+	return l
+}
+
+func checkList(t *testing.T, l List, want ...string) {
+	t.Helper()
+	if got := l.LRANGE(0, -1); l.LLEN() != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+		t.Errorf("list = %v, LLEN = %d, want %v", got, l.LLEN(), want)
+	}
+}
+
+// withListOptions runs f with the default list options,
+// and with tiny compressed nodes, so that the commands cross node boundaries.
+func withListOptions(t *testing.T, f func(t *testing.T)) {
+	defer func(fill, depth int) {
+		config.ListMaxZiplistSize, config.ListCompressDepth = fill, depth
+	}(config.ListMaxZiplistSize, config.ListCompressDepth)
+
+	for _, opts := range [][2]int{{-2, 0}, {2, 1}} {
+		config.ListMaxZiplistSize, config.ListCompressDepth = opts[0], opts[1]
+		t.Run(fmt.Sprintf("fill %d depth %d", opts[0], opts[1]), f)
+	}
+}
+
+func TestList_PushAndPop(t *testing.T) {
+	withListOptions(t, func(t *testing.T) {
+		l := NewListObject()
+		if n := l.RPUSH("a", "1", "b"); n != 3 {
+			t.Errorf("RPUSH = %d", n)
+		}
+		if n := l.LPUSH("x", "-5", "y"); n != 6 {
+			t.Errorf("LPUSH = %d", n)
+		}
+		checkList(t, l, "y", "-5", "x", "a", "1", "b")
+
+		if got := l.LPOP(2); !reflect.DeepEqual(got, []string{"y", "-5"}) {
+			t.Errorf("LPOP 2 = %v", got)
+		}
+		if got := l.RPOP(1); !reflect.DeepEqual(got, []string{"b"}) {
+			t.Errorf("RPOP 1 = %v", got)
+		}
+		if got := l.LPOP(0); len(got) != 0 {
+			t.Errorf("LPOP 0 = %v", got)
+		}
+		if got := l.RPOP(10); !reflect.DeepEqual(got, []string{"1", "a", "x"}) {
+			t.Errorf("RPOP 10 = %v", got)
+		}
+		if got := l.LPOP(1); len(got) != 0 {
+			t.Errorf("LPOP of empty list = %v", got)
+		}
+	})
+}
+
+func TestList_IndexAndRange(t *testing.T) {
+	withListOptions(t, func(t *testing.T) {
+		l := newList("a", "b", "c", "d", "e")
+
+		for idx, want := range map[int]string{0: "a", 4: "e", -1: "e", -5: "a"} {
+			if v, ok := l.LINDEX(idx); v != want || !ok {
+				t.Errorf("LINDEX(%d) = %q, %v", idx, v, ok)
+			}
+		}
+		for _, idx := range []int{5, -6} {
+			if _, ok := l.LINDEX(idx); ok {
+				t.Errorf("LINDEX(%d) is in range", idx)
+			}
+		}
+
+		if err := l.LSET(-2, "D"); err != nil {
+			t.Errorf("LSET err = %v", err)
+		}
+		if err := l.LSET(5, "x"); err != ErrIndexOutOfRange {
+			t.Errorf("LSET out of range err = %v", err)
+		}
+
+		tests := []struct {
+			start, stop int
+			want        []string
+		}{
+			{0, -1, []string{"a", "b", "c", "D", "e"}},
+			{-3, 2, []string{"c"}},
+			{-100, 1, []string{"a", "b"}},
+			{3, 100, []string{"D", "e"}},
+			{4, 3, nil},
+			{5, 10, nil},
+			{-1, -2, nil},
+		}
+		for _, tt := range tests {
+			if got := l.LRANGE(tt.start, tt.stop); len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("LRANGE(%d, %d) = %v, want %v", tt.start, tt.stop, got, tt.want)
+			}
+		}
+
+		l.LTRIM(1, -2)
+		checkList(t, l, "b", "c", "D")
+		l.LTRIM(-100, 100)
+		checkList(t, l, "b", "c", "D")
+		l.LTRIM(2, 1)
+		checkList(t, l)
+	})
+}
+
+func TestList_InsertAndRem(t *testing.T) {
+	withListOptions(t, func(t *testing.T) {
+		l := newList("a", "1", "b", "1", "c", "1")
+
+		if n := l.LINSERT(false, "1", "x"); n != 7 {
+			t.Errorf("LINSERT BEFORE = %d", n)
+		}
+		if n := l.LINSERT(true, "c", "y"); n != 8 {
+			t.Errorf("LINSERT AFTER = %d", n)
+		}
+		if n := l.LINSERT(true, "missing", "z"); n != -1 {
+			t.Errorf("LINSERT missing pivot = %d", n)
+		}
+		checkList(t, l, "a", "x", "1", "b", "1", "c", "y", "1")
+
+		if n := l.LREM(-1, "1"); n != 1 {
+			t.Errorf("LREM -1 = %d", n)
+		}
+		checkList(t, l, "a", "x", "1", "b", "1", "c", "y")
+		if n := l.LREM(1, "1"); n != 1 {
+			t.Errorf("LREM 1 = %d", n)
+		}
+		checkList(t, l, "a", "x", "b", "1", "c", "y")
+		l.RPUSH("x", "x")
+		if n := l.LREM(0, "x"); n != 3 {
+			t.Errorf("LREM 0 = %d", n)
+		}
+		checkList(t, l, "a", "b", "1", "c", "y")
+	})
+}
+
+func TestList_Pos(t *testing.T) {
+	withListOptions(t, func(t *testing.T) {
+		l := newList("a", "b", "c", "1", "2", "3", "c", "c")
+
+		tests := []struct {
+			rank, count, maxLen int
+			want                []int
+		}{
+			{1, 1, 0, []int{2}},
+			{2, 1, 0, []int{6}},
+			{1, 0, 0, []int{2, 6, 7}},
+			{-1, 2, 0, []int{7, 6}},
+			{-3, 0, 0, []int{2}},
+			{4, 0, 0, nil},
+			{1, 0, 7, []int{2, 6}},
+			{-1, 0, 2, []int{7, 6}},
+		}
+		for _, tt := range tests {
+			got, err := l.LPOS("c", tt.rank, tt.count, tt.maxLen)
+			if err != nil || len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("LPOS(c, %d, %d, %d) = %v, %v, want %v", tt.rank, tt.count, tt.maxLen, got, err, tt.want)
+			}
+		}
+		if got, _ := l.LPOS("2", 1, 1, 0); !reflect.DeepEqual(got, []int{4}) {
+			t.Errorf("LPOS(2) = %v", got)
+		}
+
+		if _, err := l.LPOS("c", 0, 1, 0); err != ErrRankZero {
+			t.Errorf("LPOS rank 0 err = %v", err)
+		}
+		if _, err := l.LPOS("c", 1, -1, 0); err != ErrNegativeCount {
+			t.Errorf("LPOS negative count err = %v", err)
+		}
+		if _, err := l.LPOS("c", 1, 1, -1); err != ErrNegativeMaxLen {
+			t.Errorf("LPOS negative maxlen err = %v", err)
+		}
+	})
+}
+
+func TestList_Move(t *testing.T) {
+	withListOptions(t, func(t *testing.T) {
+		src, dst := newList("a", "b", "c"), newList("x")
+
+		if e, ok := src.LMOVE(dst, LIST_TAIL, LIST_HEAD); e != "c" || !ok {
+			t.Errorf("LMOVE = %q, %v", e, ok)
+		}
+		if e, _ := src.LMOVE(dst, LIST_HEAD, LIST_TAIL); e != "a" {
+			t.Errorf("LMOVE = %q", e)
+		}
+		checkList(t, src, "b")
+		checkList(t, dst, "c", "x", "a")
+
+		// rotate
+		if e, _ := dst.LMOVE(dst, LIST_TAIL, LIST_HEAD); e != "a" {
+			t.Errorf("LMOVE rotate = %q", e)
+		}
+		checkList(t, dst, "a", "c", "x")
+		if e, _ := dst.LMOVE(dst, LIST_HEAD, LIST_TAIL); e != "a" {
+			t.Errorf("LMOVE rotate back = %q", e)
+		}
+		checkList(t, dst, "c", "x", "a")
+		if e, _ := dst.LMOVE(dst, LIST_HEAD, LIST_HEAD); e != "c" {
+			t.Errorf("LMOVE head to head = %q", e)
+		}
+		if e, _ := dst.LMOVE(dst, LIST_TAIL, LIST_TAIL); e != "a" {
+			t.Errorf("LMOVE tail to tail = %q", e)
+		}
+		checkList(t, dst, "c", "x", "a")
+
+		src.LPOP(1)
+		if _, ok := src.LMOVE(dst, LIST_HEAD, LIST_HEAD); ok {
+			t.Errorf("LMOVE from empty list")
+		}
+	})
+}
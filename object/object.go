@@ -1,19 +1,32 @@
 package object
 
+import (
+	"strconv"
+
+	"github.com/viktorxhzj/mykv/datastructure"
+	"github.com/viktorxhzj/mykv/util"
+)
+
 const (
 	OBJ_STRING = 0
 	OBJ_LIST   = 1
 	OBJ_SET    = 2
 	OBJ_ZSET   = 3
 	OBJ_HASH   = 4
+	OBJ_STREAM = 5
 
-	OBJ_ENCODING_STR       = 0 // string
+	OBJ_ENCODING_RAW       = 0 // raw []byte
 	OBJ_ENCODING_INT       = 1 // int64
 	OBJ_ENCODING_HT        = 2 // hash table
 	OBJ_ENCODING_ZIPLIST   = 3 // ziplist
 	OBJ_ENCODING_INTSET    = 4 // intset
 	OBJ_ENCODING_SKIPLIST  = 5 // skiplist
 	OBJ_ENCODING_QUICKLIST = 6 // quicklist
+	OBJ_ENCODING_EMBSTR    = 7 // embedded string
+	OBJ_ENCODING_STREAM    = 8 // radix tree of listpacks
+
+	// Deprecated: OBJ_ENCODING_STR is the former name of OBJ_ENCODING_RAW.
+	OBJ_ENCODING_STR = OBJ_ENCODING_RAW
 )
 
 type ValueObject struct {
@@ -31,3 +44,50 @@ func (o *ValueObject) GetType() (ot, et uint8) {
 	et = o.Type & 0x0F
 	return
 }
+
+//
+// ziplist helpers for the objects encoded as ziplists,
+// which store a string as an integer entry if it is the canonical form of an integer.
+//
+
+const zipListIntMaxLength = 32
+
+// zipListInsert inserts s before the entry at idx,
+// or at the tail if idx equals the length of the ziplist.
+func zipListInsert(zl *datastructure.ZipList, idx int, s string) error {
+	if i, ok := zipListTryInt(s); ok {
+		return zl.InsertInt(idx, i)
+	}
+	return zl.InsertString(idx, s)
+}
+
+// zipListReplace replaces the entry at idx with s.
+func zipListReplace(zl *datastructure.ZipList, idx int, s string) {
+	if i, ok := zipListTryInt(s); ok {
+		zl.ReplaceInt(idx, i)
+	} else {
+		zl.ReplaceString(idx, s)
+	}
+}
+
+func zipListTryInt(s string) (int, bool) {
+	if len(s) > zipListIntMaxLength {
+		return 0, false
+	}
+	i, ok := util.StringToInt64([]byte(s))
+	return int(i), ok
+}
+
+// zipListString converts an element returned by a ziplist to a string.
+func zipListString(e interface{}) string {
+	if i, ok := e.(int); ok {
+		return strconv.Itoa(i)
+	}
+	return e.(string)
+}
+
+// zipListGet returns the entry at idx as a string.
+func zipListGet(zl *datastructure.ZipList, idx int) string {
+	e, _ := zl.Get(idx)
+	return zipListString(e)
+}
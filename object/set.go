@@ -0,0 +1,322 @@
+package object
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"github.com/viktorxhzj/mykv/datastructure"
+	"github.com/viktorxhzj/mykv/util"
+)
+
+//
+// A set object is encoded as:
+// OBJ_ENCODING_INTSET: an intset, while every member is an integer;
+// OBJ_ENCODING_HT:     a dict from member to "".
+//
+// A set converts from intset to dict once a member is not an integer,
+// or it has more members than set-max-intset-entries.
+// It converts back only by Compact or Convert.
+//
+
+var (
+	ErrNegativeLimit    = errors.New("ERR LIMIT can't be negative")
+	ErrValueNotPositive = errors.New("ERR value is out of range, must be positive")
+)
+
+// Set is a set object.
+type Set struct {
+	*ValueObject
+}
+
+// NewSetObject creates an empty set object encoded as an intset.
+func NewSetObject() Set {
+	o := new(ValueObject)
+	o.SetType(OBJ_SET, OBJ_ENCODING_INTSET)
+	o.Structure = datastructure.NewIntSet()
+	return Set{o}
+}
+
+// SADD adds the members, and returns the number of members that are new.
+func (s Set) SADD(members ...string) int {
+	added := 0
+	for _, m := range members {
+		if s.add(m) {
+			added++
+		}
+	}
+	return added
+}
+
+// SREM removes the members, and returns the number of members that existed.
+func (s Set) SREM(members ...string) int {
+	removed := 0
+	for _, m := range members {
+		if s.remove(m) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// SISMEMBER reports whether the member is in the set.
+func (s Set) SISMEMBER(member string) bool {
+	if _, et := s.GetType(); et == OBJ_ENCODING_HT {
+		_, ok := s.Structure.(*datastructure.Dict).Find(member)
+		return ok
+	}
+	i, ok := util.StringToInt64([]byte(member))
+	if !ok {
+		return false
+	}
+	_, ok = s.Structure.(*datastructure.IntSet).Find(int(i))
+	return ok
+}
+
+// SMISMEMBER reports whether each of the members is in the set.
+func (s Set) SMISMEMBER(members ...string) []bool {
+	res := make([]bool, len(members))
+	for i, m := range members {
+		res[i] = s.SISMEMBER(m)
+	}
+	return res
+}
+
+// SCARD returns the number of members.
+func (s Set) SCARD() int {
+	if _, et := s.GetType(); et == OBJ_ENCODING_HT {
+		return s.Structure.(*datastructure.Dict).Size()
+	}
+	return s.Structure.(*datastructure.IntSet).Size()
+}
+
+// SMEMBERS returns all the members.
+// The members of an intset encoded set are returned in ascending order.
+func (s Set) SMEMBERS() []string {
+	res := make([]string, 0, s.SCARD())
+	if _, et := s.GetType(); et == OBJ_ENCODING_HT {
+		it := s.Structure.(*datastructure.Dict).Iterator()
+		for e := it.Next(); e != nil; e = it.Next() {
+			res = append(res, e.(datastructure.DictEntry).Key)
+		}
+		return res
+	}
+	is := s.Structure.(*datastructure.IntSet)
+	for i := 0; i < is.Size(); i++ {
+		v, _ := is.Get(i)
+		res = append(res, strconv.Itoa(v))
+	}
+	return res
+}
+
+// SPOP removes and returns count random members,
+// or all the members if there are no more than count.
+// It returns ErrValueNotPositive if count is negative.
+func (s Set) SPOP(count int) ([]string, error) {
+	if count < 0 {
+		return nil, ErrValueNotPositive
+	}
+	if count >= s.SCARD() {
+		res := s.SMEMBERS()
+		s.clear()
+		return res, nil
+	}
+	res := make([]string, 0, count)
+	for len(res) < count {
+		m := s.random()
+		s.remove(m)
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+// SRANDMEMBER returns count random members.
+// If count is positive, the members are distinct, and at most all the members are returned.
+// If count is negative, -count members are returned, which may repeat.
+func (s Set) SRANDMEMBER(count int) []string {
+	size := s.SCARD()
+	if count == 0 || size == 0 {
+		return nil
+	}
+
+	if count < 0 {
+		res := make([]string, -count)
+		for i := range res {
+			res[i] = s.random()
+		}
+		return res
+	}
+
+	all := s.SMEMBERS()
+	if count >= size {
+		return all
+	}
+	// partial Fisher-Yates shuffle
+	for i := 0; i < count; i++ {
+		j := i + rand.Intn(size-i)
+		all[i], all[j] = all[j], all[i]
+	}
+	return all[:count]
+}
+
+// SMOVE moves the member from the set to dst,
+// and returns false if the member is not in the set.
+func (s Set) SMOVE(dst Set, member string) bool {
+	if s.ValueObject == dst.ValueObject {
+		return s.SISMEMBER(member)
+	}
+	if !s.remove(member) {
+		return false
+	}
+	dst.add(member)
+	return true
+}
+
+// SINTER returns a new set of the members that are in all the sets.
+func SINTER(sets ...Set) Set {
+	res := NewSetObject()
+	if len(sets) == 0 {
+		return res
+	}
+
+	// check the members of the smallest set against the others
+	sorted := sortSetsBySize(sets)
+	for _, m := range sorted[0].SMEMBERS() {
+		if isMemberOfAll(m, sorted[1:]) {
+			res.add(m)
+		}
+	}
+	return res
+}
+
+// SINTERCARD returns the number of members that are in all the sets,
+// counting no further than limit if limit is positive.
+func SINTERCARD(limit int, sets ...Set) (int, error) {
+	if limit < 0 {
+		return 0, ErrNegativeLimit
+	}
+	if len(sets) == 0 {
+		return 0, nil
+	}
+
+	sorted := sortSetsBySize(sets)
+	count := 0
+	for _, m := range sorted[0].SMEMBERS() {
+		if isMemberOfAll(m, sorted[1:]) {
+			count++
+			if count == limit {
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+// SUNION returns a new set of the members that are in any of the sets.
+func SUNION(sets ...Set) Set {
+	res := NewSetObject()
+	for _, s := range sets {
+		for _, m := range s.SMEMBERS() {
+			res.add(m)
+		}
+	}
+	return res
+}
+
+// SDIFF returns a new set of the members of the first set that are in none of the others.
+func SDIFF(sets ...Set) Set {
+	res := NewSetObject()
+	if len(sets) == 0 {
+		return res
+	}
+	for _, m := range sets[0].SMEMBERS() {
+		isMember := false
+		for _, s := range sets[1:] {
+			if s.SISMEMBER(m) {
+				isMember = true
+				break
+			}
+		}
+		if !isMember {
+			res.add(m)
+		}
+	}
+	return res
+}
+
+func sortSetsBySize(sets []Set) []Set {
+	sorted := append([]Set(nil), sets...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].SCARD() < sorted[j].SCARD()
+	})
+	return sorted
+}
+
+func isMemberOfAll(member string, sets []Set) bool {
+	for _, s := range sets {
+		if !s.SISMEMBER(member) {
+			return false
+		}
+	}
+	return true
+}
+
+// add adds the member, and returns true if it is new.
+func (s Set) add(member string) bool {
+	if _, et := s.GetType(); et == OBJ_ENCODING_INTSET {
+		i, ok := util.StringToInt64([]byte(member))
+		if !ok {
+			s.convert()
+		} else {
+			is := s.Structure.(*datastructure.IntSet)
+			if is.Add(int(i)) != nil {
+				return false
+			}
+			if !setIntSetEntriesFit(is.Size()) {
+				s.convert()
+			}
+			return true
+		}
+	}
+
+	d := s.Structure.(*datastructure.Dict)
+	if _, ok := d.Find(member); ok {
+		return false
+	}
+	d.Put(member, "")
+	return true
+}
+
+// remove removes the member, and returns true if it existed.
+func (s Set) remove(member string) bool {
+	if _, et := s.GetType(); et == OBJ_ENCODING_HT {
+		d := s.Structure.(*datastructure.Dict)
+		if _, ok := d.Find(member); !ok {
+			return false
+		}
+		d.Delete(member)
+		return true
+	}
+	i, ok := util.StringToInt64([]byte(member))
+	return ok && s.Structure.(*datastructure.IntSet).Remove(int(i))
+}
+
+// random returns a random member, the set must not be empty.
+func (s Set) random() string {
+	if _, et := s.GetType(); et == OBJ_ENCODING_HT {
+		e, _ := s.Structure.(*datastructure.Dict).RandomEntry()
+		return e.Key
+	}
+	v, _ := s.Structure.(*datastructure.IntSet).Random()
+	return strconv.Itoa(v)
+}
+
+// clear removes all the members, keeping the encoding.
+func (s Set) clear() {
+	if _, et := s.GetType(); et == OBJ_ENCODING_HT {
+		s.Structure = datastructure.NewDict()
+	} else {
+		s.Structure = datastructure.NewIntSet()
+	}
+}
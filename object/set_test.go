@@ -0,0 +1,154 @@
+package object
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/config"
+)
+
+func setEncoding(s Set) uint8 {
+	_, et := s.GetType()
+	return et
+}
+
+func sortedMembers(s Set) []string {
+	members := s.SMEMBERS()
+	sort.Strings(members)
+	return members
+}
+
+func newSet(members ...string) Set {
+	s := NewSetObject()
+	s.SADD(members...)
+	return s
+}
+
+func TestSet_Commands(t *testing.T) {
+	for _, members := range [][]string{{"1", "2", "3"}, {"1", "2", "c"}} {
+		s := NewSetObject()
+		if n := s.SADD(append(members, members[0])...); n != 3 {
+			t.Errorf("SADD = %d", n)
+		}
+		if n := s.SCARD(); n != 3 {
+			t.Errorf("SCARD = %d", n)
+		}
+		if !s.SISMEMBER("2") || s.SISMEMBER("02") || s.SISMEMBER("x") {
+			t.Errorf("SISMEMBER")
+		}
+		if got := s.SMISMEMBER("1", "4", members[2]); !reflect.DeepEqual(got, []bool{true, false, true}) {
+			t.Errorf("SMISMEMBER = %v", got)
+		}
+		if n := s.SREM("2", "x", "02"); n != 1 {
+			t.Errorf("SREM = %d", n)
+		}
+		if got := sortedMembers(s); !reflect.DeepEqual(got, []string{"1", members[2]}) {
+			t.Errorf("SMEMBERS = %v", got)
+		}
+
+		s.SADD("5", "6", "7")
+		popped, _ := s.SPOP(2)
+		if len(popped) != 2 || s.SCARD() != 3 {
+			t.Errorf("SPOP 2 = %v, SCARD = %d", popped, s.SCARD())
+		}
+		for _, m := range popped {
+			if s.SISMEMBER(m) {
+				t.Errorf("SPOP does not remove %s", m)
+			}
+		}
+		if _, err := s.SPOP(-1); err != ErrValueNotPositive || s.SCARD() != 3 {
+			t.Errorf("SPOP -1 err = %v, SCARD = %d", err, s.SCARD())
+		}
+		if popped, _ := s.SPOP(10); len(popped) != 3 || s.SCARD() != 0 {
+			t.Errorf("SPOP 10 = %v, SCARD = %d", popped, s.SCARD())
+		}
+	}
+}
+
+func TestSet_Conversion(t *testing.T) {
+	s := newSet("3", "-1", "2")
+	if setEncoding(s) != OBJ_ENCODING_INTSET {
+		t.Fatalf("a set of integers is not intset encoded")
+	}
+	if got := s.SMEMBERS(); !reflect.DeepEqual(got, []string{"-1", "2", "3"}) {
+		t.Errorf("SMEMBERS of intset = %v", got)
+	}
+	s.SADD("a")
+	if setEncoding(s) != OBJ_ENCODING_HT || s.SCARD() != 4 || !s.SISMEMBER("-1") {
+		t.Errorf("not converted after adding a non-integer")
+	}
+
+	defer func(entries int) { config.SetMaxIntsetEntries = entries }(config.SetMaxIntsetEntries)
+	config.SetMaxIntsetEntries = 3
+	s = newSet("1", "2", "3")
+	if setEncoding(s) != OBJ_ENCODING_INTSET {
+		t.Fatalf("converted before passing set-max-intset-entries")
+	}
+	s.SADD("4")
+	if setEncoding(s) != OBJ_ENCODING_HT || s.SCARD() != 4 {
+		t.Errorf("not converted after passing set-max-intset-entries")
+	}
+}
+
+func TestSet_RandMemberAndMove(t *testing.T) {
+	for _, members := range [][]string{{"1", "2", "3", "4", "5"}, {"a", "b", "c", "d", "e"}} {
+		s := newSet(members...)
+		res := s.SRANDMEMBER(3)
+		seen := make(map[string]bool)
+		for _, m := range res {
+			if seen[m] || !s.SISMEMBER(m) {
+				t.Errorf("SRANDMEMBER 3 = %v", res)
+			}
+			seen[m] = true
+		}
+		if len(res) != 3 {
+			t.Errorf("SRANDMEMBER 3 returns %d members", len(res))
+		}
+		if res := s.SRANDMEMBER(-8); len(res) != 8 {
+			t.Errorf("SRANDMEMBER -8 returns %d members", len(res))
+		}
+		if res := s.SRANDMEMBER(8); len(res) != 5 {
+			t.Errorf("SRANDMEMBER 8 returns %d members", len(res))
+		}
+
+		dst := newSet("x")
+		if !s.SMOVE(dst, members[0]) || s.SISMEMBER(members[0]) || !dst.SISMEMBER(members[0]) {
+			t.Errorf("SMOVE")
+		}
+		if s.SMOVE(dst, "missing") || !s.SMOVE(s, members[1]) || s.SCARD() != 4 {
+			t.Errorf("SMOVE missing member or to itself")
+		}
+	}
+}
+
+func TestSet_Algebra(t *testing.T) {
+	a, b, c := newSet("1", "2", "3", "x"), newSet("2", "3", "4"), newSet("3", "x", "y")
+
+	if got := sortedMembers(SINTER(a, b, c)); !reflect.DeepEqual(got, []string{"3"}) {
+		t.Errorf("SINTER = %v", got)
+	}
+	inter := SINTER(a, b)
+	if got := sortedMembers(inter); !reflect.DeepEqual(got, []string{"2", "3"}) || setEncoding(inter) != OBJ_ENCODING_INTSET {
+		t.Errorf("SINTER = %v, encoding %d", got, setEncoding(inter))
+	}
+	if got := sortedMembers(SUNION(a, b, c)); !reflect.DeepEqual(got, []string{"1", "2", "3", "4", "x", "y"}) {
+		t.Errorf("SUNION = %v", got)
+	}
+	if got := sortedMembers(SDIFF(a, b, c)); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("SDIFF = %v", got)
+	}
+	if got := SINTER(a, NewSetObject()).SCARD(); got != 0 {
+		t.Errorf("SINTER with empty set = %d", got)
+	}
+
+	if n, _ := SINTERCARD(0, a, b); n != 2 {
+		t.Errorf("SINTERCARD = %d", n)
+	}
+	if n, _ := SINTERCARD(1, a, b); n != 1 {
+		t.Errorf("SINTERCARD LIMIT 1 = %d", n)
+	}
+	if _, err := SINTERCARD(-1, a, b); err != ErrNegativeLimit {
+		t.Errorf("SINTERCARD negative limit err = %v", err)
+	}
+}
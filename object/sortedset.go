@@ -1,9 +1,18 @@
 package object
 
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/viktorxhzj/mykv/datastructure"
+)
+
 type ZSet interface {
 
-	// ZADD adds one or more members in the sorted set
-	ZADD(...ZSetMember) error
+	// ZADD adds one or more members in the sorted set, as the ZADD flags specify
+	ZADD(flags int, members ...ZSetMember) (int, error)
 
 	// ZCARD gets the number of members in the sorted set
 	ZCARD() int
@@ -14,31 +23,570 @@ type ZSet interface {
 	// ZDIFF subtracts the sorted set and other multiple sorted sets
 	ZDIFF(...ZSet) []ZSetMember
 
-	// ZDIFFSTORE subtracts the sorted set and other multiple sorted sets and stores it
-	// ZDIFFSTORE() ZSet
-	
-	ZINCRBY(key string) error
-	
+	// ZINCRBY increments the score of a member in the sorted set
+	ZINCRBY(key string, increment float64) (float64, error)
+
 	// ZINTER intersect the sorted set and other multiple sorted sets
-	ZINTER()
+	ZINTER(...ZSet) []ZSetMember
+
+	// ZDIFFSTORE, ZINTERSTORE and ZUNIONSTORE store at a key, so they are DB commands,
+	// built on the package-level ZDIFF, ZINTER and ZUNION.
 
-	// ZINTERSTORE()
 	// ZLEXOUT()
 
 	ZRANGEBYSCORE(min, max float64) []ZSetMember
 
-	ZRANGEBYLEX(min, max string) []ZSetMember
+	ZRANGEBYLEX(min, max string) ([]ZSetMember, error)
 
 	ZRANK(key string) int
 
-	ZSCORE(key string) float64
-
-	ZPOPMAX() ZSetMember
-	ZPOPMIN() ZSetMember
+	ZSCORE(key string) (float64, bool)
 
+	ZPOPMAX() (ZSetMember, bool)
+	ZPOPMIN() (ZSetMember, bool)
 }
 
 type ZSetMember struct {
-	Key string
+	Key   string
 	Value float64
-}
\ No newline at end of file
+}
+
+//
+// A sorted set object is encoded as:
+// OBJ_ENCODING_ZIPLIST:  a ziplist of members each followed by its score,
+//                        ordered by score then member, for small sorted sets;
+// OBJ_ENCODING_SKIPLIST: a datastructure.ZSetSkipList.
+//
+// A sorted set converts from ziplist to skiplist once it has more members than zset-max-ziplist-entries,
+// or a member longer than zset-max-ziplist-value.
+// It converts back only by Compact or Convert.
+//
+
+// ZADD flags
+const (
+	ZADD_NX   = 1 << iota // only add new members
+	ZADD_XX               // only update existing members
+	ZADD_GT               // only update a score to a greater one
+	ZADD_LT               // only update a score to a less one
+	ZADD_CH               // count the updated members along with the added ones
+	ZADD_INCR             // increment the score instead of setting it
+)
+
+var (
+	ErrZAddXXAndNX   = errors.New("ERR XX and NX options at the same time are not compatible")
+	ErrZAddGTLTAndNX = errors.New("ERR GT, LT, and/or NX options at the same time are not compatible")
+	ErrZAddIncrPair  = errors.New("ERR INCR option supports a single increment-element pair")
+	ErrScoreNaN      = errors.New("ERR resulting score is not a number (NaN)")
+)
+
+// SortedSet is a sorted set object, implementing ZSet.
+type SortedSet struct {
+	*ValueObject
+}
+
+// NewSortedSetObject creates an empty sorted set object encoded as a ziplist.
+func NewSortedSetObject() SortedSet {
+	o := new(ValueObject)
+	o.SetType(OBJ_ZSET, OBJ_ENCODING_ZIPLIST)
+	o.Structure = datastructure.NewZipList()
+	return SortedSet{o}
+}
+
+// ZADD adds the members with their scores, or updates the scores of existing members,
+// and returns the number of added members,
+// or the number of added and updated members if ZADD_CH is set.
+//
+// Flags:
+// ZADD_NX and ZADD_XX only add new members and only update existing members respectively;
+// ZADD_GT and ZADD_LT only update a score if the new score is greater or less respectively;
+// ZADD_INCR increments the score of a single member by the given score, as ZINCRBY does,
+// and returns 1 unless the other flags abort the increment.
+func (z SortedSet) ZADD(flags int, members ...ZSetMember) (int, error) {
+	nx, xx, gt, lt := flags&ZADD_NX != 0, flags&ZADD_XX != 0, flags&ZADD_GT != 0, flags&ZADD_LT != 0
+	incr := flags&ZADD_INCR != 0
+	switch {
+	case nx && xx:
+		return 0, ErrZAddXXAndNX
+	case (gt && nx) || (lt && nx) || (gt && lt):
+		return 0, ErrZAddGTLTAndNX
+	case incr && len(members) != 1:
+		return 0, ErrZAddIncrPair
+	}
+
+	// no member is added if any score is invalid
+	for _, m := range members {
+		if math.IsNaN(m.Value) {
+			return 0, ErrScoreNaN
+		}
+	}
+
+	added, updated, processed := 0, 0, 0
+	for _, m := range members {
+		score := m.Value
+		cur, exists := z.ZSCORE(m.Key)
+		if !exists {
+			if xx {
+				continue
+			}
+			z.insert(m.Key, score)
+			added++
+			processed++
+			continue
+		}
+
+		if nx {
+			continue
+		}
+		if incr {
+			score += cur
+			if math.IsNaN(score) {
+				return added, ErrScoreNaN
+			}
+		}
+		if (lt && score >= cur) || (gt && score <= cur) {
+			continue
+		}
+		processed++
+		if score != cur {
+			z.update(m.Key, score)
+			updated++
+		}
+	}
+
+	if incr {
+		return processed, nil
+	}
+	if flags&ZADD_CH != 0 {
+		return added + updated, nil
+	}
+	return added, nil
+}
+
+// ZINCRBY increments the score of the member by increment,
+// where a missing member counts as 0, and returns the new score.
+func (z SortedSet) ZINCRBY(key string, increment float64) (float64, error) {
+	if _, err := z.ZADD(ZADD_INCR, ZSetMember{key, increment}); err != nil {
+		return 0, err
+	}
+	score, _ := z.ZSCORE(key)
+	return score, nil
+}
+
+// ZCARD returns the number of members.
+func (z SortedSet) ZCARD() int {
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		return z.Structure.(*datastructure.ZSetSkipList).Size()
+	}
+	return z.Structure.(*datastructure.ZipList).ZLLen() / 2
+}
+
+// ZCOUNT returns the number of members with scores within [min, max].
+func (z SortedSet) ZCOUNT(min, max float64) int {
+	return len(z.rangeByScore(&datastructure.RangeSpec{Min: min, Max: max}))
+}
+
+// ZRANGEBYSCORE returns the members with scores within [min, max], ordered by score.
+func (z SortedSet) ZRANGEBYSCORE(min, max float64) []ZSetMember {
+	return z.rangeByScore(&datastructure.RangeSpec{Min: min, Max: max})
+}
+
+// ZRANGEBYLEX returns the members within the lexicographic range,
+// given as "[member", "(member", "-" or "+".
+// The members are expected to have the same score.
+func (z SortedSet) ZRANGEBYLEX(min, max string) ([]ZSetMember, error) {
+	spec, err := datastructure.ParseLexRange(min, max)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []ZSetMember
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		for _, node := range z.Structure.(*datastructure.ZSetSkipList).SL.RangeByLex(spec) {
+			res = append(res, ZSetMember{node.Key, node.Score})
+		}
+		return res, nil
+	}
+	for _, m := range z.members() {
+		if spec.Contains(m.Key) {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+// ZRANK returns the 0-based rank of the member, ordered by score,
+// or -1 if the member does not exist.
+func (z SortedSet) ZRANK(key string) int {
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		return z.Structure.(*datastructure.ZSetSkipList).Rank(key)
+	}
+	idx := z.Structure.(*datastructure.ZipList).Find(key, 1)
+	if idx == -1 {
+		return -1
+	}
+	return idx / 2
+}
+
+// ZSCORE returns the score of the member, and false if the member does not exist.
+func (z SortedSet) ZSCORE(key string) (float64, bool) {
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		return z.Structure.(*datastructure.ZSetSkipList).Score(key)
+	}
+	zl := z.Structure.(*datastructure.ZipList)
+	idx := zl.Find(key, 1)
+	if idx == -1 {
+		return 0, false
+	}
+	e, _ := zl.Get(idx + 1)
+	return zipListScore(e), true
+}
+
+// ZPOPMIN removes and returns the member with the lowest score,
+// and false if the sorted set is empty.
+func (z SortedSet) ZPOPMIN() (ZSetMember, bool) {
+	return z.pop(0)
+}
+
+// ZPOPMAX removes and returns the member with the highest score,
+// and false if the sorted set is empty.
+func (z SortedSet) ZPOPMAX() (ZSetMember, bool) {
+	return z.pop(-1)
+}
+
+// ZDIFF returns the members of the sorted set that are in none of the others,
+// ordered by score.
+func (z SortedSet) ZDIFF(others ...ZSet) []ZSetMember {
+	return ZDIFF(z.withOthers(others)...)
+}
+
+// ZINTER returns the members of the sorted set that are in all the others,
+// with the sum of their scores, ordered by score.
+func (z SortedSet) ZINTER(others ...ZSet) []ZSetMember {
+	return ZINTER(nil, ZAGGREGATE_SUM, z.withOthers(others)...)
+}
+
+func (z SortedSet) withOthers(others []ZSet) []ZSetOperand {
+	operands := make([]ZSetOperand, 0, len(others)+1)
+	operands = append(operands, z)
+	for _, other := range others {
+		operands = append(operands, other)
+	}
+	return operands
+}
+
+// ZSetOperand is an input of ZUNION, ZINTER and ZDIFF,
+// either a ZSet or a set wrapped in SetOperand.
+type ZSetOperand interface {
+	ZCARD() int
+	ZSCORE(key string) (float64, bool)
+	ZRANGEBYSCORE(min, max float64) []ZSetMember
+}
+
+// SetOperand lets a set take part in sorted set algebra, each member scoring 1.
+type SetOperand struct {
+	Set
+}
+
+func (s SetOperand) ZCARD() int {
+	return s.SCARD()
+}
+
+func (s SetOperand) ZSCORE(key string) (float64, bool) {
+	return 1, s.SISMEMBER(key)
+}
+
+func (s SetOperand) ZRANGEBYSCORE(min, max float64) []ZSetMember {
+	if min > 1 || max < 1 {
+		return nil
+	}
+	members := s.SMEMBERS()
+	res := make([]ZSetMember, len(members))
+	for i, m := range members {
+		res[i] = ZSetMember{m, 1}
+	}
+	sortZSetMembers(res)
+	return res
+}
+
+// aggregate functions of ZUNION and ZINTER
+const (
+	ZAGGREGATE_SUM = iota
+	ZAGGREGATE_MIN
+	ZAGGREGATE_MAX
+)
+
+// ZUNION returns the members that are in any of the operands, ordered by score.
+// The score of a member is the aggregate of its scores multiplied by the weights of the operands,
+// where nil weights are all 1.
+func ZUNION(weights []float64, aggregate int, operands ...ZSetOperand) []ZSetMember {
+	scores := make(map[string]float64)
+	var keys []string
+	for i, op := range operands {
+		for _, m := range allMembers(op) {
+			score := weightedScore(m.Value, weights, i)
+			if cur, ok := scores[m.Key]; ok {
+				scores[m.Key] = aggregateScores(aggregate, cur, score)
+			} else {
+				scores[m.Key] = score
+				keys = append(keys, m.Key)
+			}
+		}
+	}
+
+	res := make([]ZSetMember, len(keys))
+	for i, key := range keys {
+		res[i] = ZSetMember{key, scores[key]}
+	}
+	sortZSetMembers(res)
+	return res
+}
+
+// ZINTER returns the members that are in all the operands, ordered by score.
+// The score of a member is the aggregate of its scores multiplied by the weights of the operands,
+// where nil weights are all 1.
+func ZINTER(weights []float64, aggregate int, operands ...ZSetOperand) []ZSetMember {
+	if len(operands) == 0 {
+		return nil
+	}
+
+	// check the members of the smallest operand against the others,
+	// aggregating the scores in the order of the operands
+	smallest := 0
+	for i, op := range operands {
+		if op.ZCARD() < operands[smallest].ZCARD() {
+			smallest = i
+		}
+	}
+	var res []ZSetMember
+	for _, m := range allMembers(operands[smallest]) {
+		score, isMember := 0.0, true
+		for i, op := range operands {
+			s := m.Value
+			if i != smallest {
+				if s, isMember = op.ZSCORE(m.Key); !isMember {
+					break
+				}
+			}
+			if i == 0 {
+				score = weightedScore(s, weights, i)
+			} else {
+				score = aggregateScores(aggregate, score, weightedScore(s, weights, i))
+			}
+		}
+		if isMember {
+			res = append(res, ZSetMember{m.Key, score})
+		}
+	}
+	sortZSetMembers(res)
+	return res
+}
+
+// ZDIFF returns the members of the first operand that are in none of the others,
+// with their scores in the first operand, ordered by score.
+func ZDIFF(operands ...ZSetOperand) []ZSetMember {
+	if len(operands) == 0 {
+		return nil
+	}
+	var res []ZSetMember
+	for _, m := range allMembers(operands[0]) {
+		isMember := false
+		for _, op := range operands[1:] {
+			if _, ok := op.ZSCORE(m.Key); ok {
+				isMember = true
+				break
+			}
+		}
+		if !isMember {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+func allMembers(op ZSetOperand) []ZSetMember {
+	return op.ZRANGEBYSCORE(math.Inf(-1), math.Inf(1))
+}
+
+// weightedScore multiplies the score of the i-th operand by its weight,
+// where inf * 0 is 0 rather than NaN.
+func weightedScore(score float64, weights []float64, i int) float64 {
+	if weights == nil {
+		return score
+	}
+	if res := score * weights[i]; !math.IsNaN(res) {
+		return res
+	}
+	return 0
+}
+
+// aggregateScores aggregates two scores, where inf + -inf is 0 rather than NaN.
+func aggregateScores(aggregate int, a, b float64) float64 {
+	switch aggregate {
+	case ZAGGREGATE_MIN:
+		return math.Min(a, b)
+	case ZAGGREGATE_MAX:
+		return math.Max(a, b)
+	}
+	if res := a + b; !math.IsNaN(res) {
+		return res
+	}
+	return 0
+}
+
+// members returns all the members, ordered by score.
+func (z SortedSet) members() []ZSetMember {
+	res := make([]ZSetMember, 0, z.ZCARD())
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		it := z.Structure.(*datastructure.ZSetSkipList).SL.IteratorFromRank(0, false)
+		for e := it.Next(); e != nil; e = it.Next() {
+			node := e.(*datastructure.SkipListNode)
+			res = append(res, ZSetMember{node.Key, node.Score})
+		}
+		return res
+	}
+	entries := z.Structure.(*datastructure.ZipList).Range(0, -1)
+	for i := 0; i < len(entries); i += 2 {
+		res = append(res, ZSetMember{zipListString(entries[i]), zipListScore(entries[i+1])})
+	}
+	return res
+}
+
+// rangeByScore returns the members with scores within the range, ordered by score.
+func (z SortedSet) rangeByScore(spec *datastructure.RangeSpec) []ZSetMember {
+	var res []ZSetMember
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		it := z.Structure.(*datastructure.ZSetSkipList).SL.IteratorFromScore(spec.Min, false)
+		for e := it.Next(); e != nil; e = it.Next() {
+			node := e.(*datastructure.SkipListNode)
+			if !spec.Contains(node.Score) {
+				if node.Score > spec.Max {
+					break
+				}
+				continue
+			}
+			res = append(res, ZSetMember{node.Key, node.Score})
+		}
+		return res
+	}
+	for _, m := range z.members() {
+		if spec.Contains(m.Value) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// insert inserts a new member, converting the sorted set to a skiplist if needed.
+func (z SortedSet) insert(key string, score float64) {
+	if _, et := z.GetType(); et == OBJ_ENCODING_ZIPLIST &&
+		(!zsetZipListEntriesFit(z.ZCARD()+1) || !zsetZipListValuesFit(key)) {
+		z.convert()
+	}
+
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		z.Structure.(*datastructure.ZSetSkipList).Add(key, score)
+		return
+	}
+
+	// insert before the first member that goes after it
+	zl := z.Structure.(*datastructure.ZipList)
+	idx := 0
+	for _, m := range z.members() {
+		if score < m.Value || (score == m.Value && key < m.Key) {
+			break
+		}
+		idx += 2
+	}
+	zipListInsert(zl, idx, key)
+	zipListInsert(zl, idx+1, strconv.FormatFloat(score, 'g', -1, 64))
+}
+
+// update updates the score of an existing member,
+// in place if the sorted set is a skiplist.
+func (z SortedSet) update(key string, score float64) {
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		z.Structure.(*datastructure.ZSetSkipList).Add(key, score)
+		return
+	}
+	// a ziplist is ordered by score, so the member moves to its new position
+	z.remove(key)
+	z.insert(key, score)
+}
+
+// remove removes the member, and returns false if it does not exist.
+func (z SortedSet) remove(key string) bool {
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		return z.Structure.(*datastructure.ZSetSkipList).Delete(key)
+	}
+	zl := z.Structure.(*datastructure.ZipList)
+	idx := zl.Find(key, 1)
+	if idx == -1 {
+		return false
+	}
+	zl.DeleteRange(idx, 2)
+	return true
+}
+
+// pop removes and returns the member at the 0-based rank,
+// where a negative rank counts from the highest score.
+func (z SortedSet) pop(rank int) (ZSetMember, bool) {
+	if z.ZCARD() == 0 {
+		return ZSetMember{}, false
+	}
+
+	var m ZSetMember
+	if _, et := z.GetType(); et == OBJ_ENCODING_SKIPLIST {
+		node := z.Structure.(*datastructure.ZSetSkipList).SL.GetByRank(rank)
+		m = ZSetMember{node.Key, node.Score}
+	} else {
+		zl := z.Structure.(*datastructure.ZipList)
+		e, _ := zl.Get((rank + z.ZCARD()) % z.ZCARD() * 2)
+		m.Key = zipListString(e)
+		m.Value, _ = z.ZSCORE(m.Key)
+	}
+	z.remove(m.Key)
+	return m, true
+}
+
+// NewSortedSetObjectFromMembers creates a sorted set object of the members,
+// which must be unique, encoded by the number and the length of them.
+func NewSortedSetObjectFromMembers(members []ZSetMember) SortedSet {
+	z := NewSortedSetObject()
+	small := zsetZipListEntriesFit(len(members))
+	for i := 0; small && i < len(members); i++ {
+		small = zsetZipListValuesFit(members[i].Key)
+	}
+	if !small {
+		z.convert()
+		zs := z.Structure.(*datastructure.ZSetSkipList)
+		for _, m := range members {
+			zs.Add(m.Key, m.Value)
+		}
+		return z
+	}
+
+	sortZSetMembers(members)
+	zl := z.Structure.(*datastructure.ZipList)
+	for i, m := range members {
+		zipListInsert(zl, 2*i, m.Key)
+		zipListInsert(zl, 2*i+1, strconv.FormatFloat(m.Value, 'g', -1, 64))
+	}
+	return z
+}
+
+// zipListScore converts a score entry returned by a ziplist to a float64.
+func zipListScore(e interface{}) float64 {
+	if i, ok := e.(int); ok {
+		return float64(i)
+	}
+	score, _ := strconv.ParseFloat(e.(string), 64)
+	return score
+}
+
+// sortZSetMembers sorts the members by score then member.
+func sortZSetMembers(members []ZSetMember) {
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		return a.Value < b.Value || (a.Value == b.Value && a.Key < b.Key)
+	})
+}
@@ -0,0 +1,272 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/viktorxhzj/mykv/config"
+)
+
+func zsetEncoding(z SortedSet) uint8 {
+	_, et := z.GetType()
+	return et
+}
+
+func newSortedSet(members ...ZSetMember) SortedSet {
+	z := NewSortedSetObject()
+	z.ZADD(0, members...)
+	return z
+}
+
+func keysOf(members []ZSetMember) string {
+	keys := make([]string, len(members))
+	for i, m := range members {
+		keys[i] = m.Key
+	}
+	return strings.Join(keys, ",")
+}
+
+// withZSetEncodings runs f with sorted sets encoded as ziplists, then as skiplists.
+func withZSetEncodings(t *testing.T, f func(t *testing.T)) {
+	defer func(entries int) { config.ZSetMaxZiplistEntries = entries }(config.ZSetMaxZiplistEntries)
+
+	for _, entries := range []int{128, 0} {
+		config.ZSetMaxZiplistEntries = entries
+		t.Run(fmt.Sprint("entries=", entries), f)
+	}
+}
+
+func TestSortedSet_Commands(t *testing.T) {
+	withZSetEncodings(t, func(t *testing.T) {
+		z := NewSortedSetObject()
+		if n, err := z.ZADD(0, ZSetMember{"b", 2}, ZSetMember{"a", 1}, ZSetMember{"10", 1.5}, ZSetMember{"c", 3}, ZSetMember{"a", 1}); n != 4 || err != nil {
+			t.Fatalf("ZADD = %d, %v", n, err)
+		}
+		if n := z.ZCARD(); n != 4 {
+			t.Errorf("ZCARD = %d", n)
+		}
+		if s, ok := z.ZSCORE("10"); s != 1.5 || !ok {
+			t.Errorf("ZSCORE = %v, %v", s, ok)
+		}
+		if _, ok := z.ZSCORE("missing"); ok {
+			t.Errorf("ZSCORE missing member")
+		}
+		if r := z.ZRANK("b"); r != 2 {
+			t.Errorf("ZRANK = %d", r)
+		}
+		if r := z.ZRANK("missing"); r != -1 {
+			t.Errorf("ZRANK missing = %d", r)
+		}
+		if n := z.ZCOUNT(1.5, 3); n != 3 {
+			t.Errorf("ZCOUNT = %d", n)
+		}
+		if got := keysOf(z.ZRANGEBYSCORE(math.Inf(-1), 2)); got != "a,10,b" {
+			t.Errorf("ZRANGEBYSCORE = %s", got)
+		}
+
+		if s, err := z.ZINCRBY("a", 2.5); s != 3.5 || err != nil {
+			t.Errorf("ZINCRBY = %v, %v", s, err)
+		}
+		if s, err := z.ZINCRBY("d", -1); s != -1 || err != nil {
+			t.Errorf("ZINCRBY new member = %v, %v", s, err)
+		}
+		if got := keysOf(z.ZRANGEBYSCORE(math.Inf(-1), math.Inf(1))); got != "d,10,b,c,a" {
+			t.Errorf("order after ZINCRBY = %s", got)
+		}
+
+		if m, ok := z.ZPOPMIN(); m != (ZSetMember{"d", -1}) || !ok {
+			t.Errorf("ZPOPMIN = %v, %v", m, ok)
+		}
+		if m, ok := z.ZPOPMAX(); m != (ZSetMember{"a", 3.5}) || !ok {
+			t.Errorf("ZPOPMAX = %v, %v", m, ok)
+		}
+		for z.ZCARD() > 0 {
+			z.ZPOPMAX()
+		}
+		if _, ok := z.ZPOPMIN(); ok {
+			t.Errorf("ZPOPMIN on an empty sorted set")
+		}
+	})
+}
+
+func TestSortedSet_AddFlags(t *testing.T) {
+	withZSetEncodings(t, func(t *testing.T) {
+		z := newSortedSet(ZSetMember{"a", 1}, ZSetMember{"b", 2})
+
+		for _, flags := range []int{ZADD_NX | ZADD_XX, ZADD_NX | ZADD_GT, ZADD_GT | ZADD_LT} {
+			if _, err := z.ZADD(flags, ZSetMember{"a", 1}); err == nil {
+				t.Errorf("ZADD with flags %b should fail", flags)
+			}
+		}
+		if _, err := z.ZADD(ZADD_INCR, ZSetMember{"a", 1}, ZSetMember{"b", 1}); err != ErrZAddIncrPair {
+			t.Errorf("ZADD INCR with two pairs = %v", err)
+		}
+		if _, err := z.ZADD(0, ZSetMember{"a", math.NaN()}); err != ErrScoreNaN {
+			t.Errorf("ZADD NaN = %v", err)
+		}
+		if _, err := z.ZADD(0, ZSetMember{"x", 1}, ZSetMember{"a", 9}, ZSetMember{"y", math.NaN()}); err != ErrScoreNaN {
+			t.Errorf("ZADD with a NaN after valid scores = %v", err)
+		}
+		if score, _ := z.ZSCORE("a"); z.ZCARD() != 2 || score != 1 {
+			t.Errorf("ZADD with a NaN is partly applied, ZCARD = %d", z.ZCARD())
+		}
+
+		if n, _ := z.ZADD(ZADD_NX, ZSetMember{"a", 5}, ZSetMember{"c", 3}); n != 1 {
+			t.Errorf("ZADD NX = %d", n)
+		}
+		if n, _ := z.ZADD(ZADD_XX|ZADD_CH, ZSetMember{"a", 5}, ZSetMember{"d", 4}); n != 1 {
+			t.Errorf("ZADD XX CH = %d", n)
+		}
+		if _, ok := z.ZSCORE("d"); ok {
+			t.Errorf("ZADD XX added a member")
+		}
+		if n, _ := z.ZADD(ZADD_GT|ZADD_CH, ZSetMember{"a", 4}, ZSetMember{"b", 6}); n != 1 {
+			t.Errorf("ZADD GT CH = %d", n)
+		}
+		if n, _ := z.ZADD(ZADD_LT|ZADD_CH, ZSetMember{"a", 6}, ZSetMember{"c", 0}); n != 1 {
+			t.Errorf("ZADD LT CH = %d", n)
+		}
+		want := []ZSetMember{{"c", 0}, {"a", 5}, {"b", 6}}
+		if got := z.ZRANGEBYSCORE(math.Inf(-1), math.Inf(1)); !reflect.DeepEqual(got, want) {
+			t.Errorf("members = %v", got)
+		}
+
+		if n, _ := z.ZADD(ZADD_INCR|ZADD_GT, ZSetMember{"a", -1}); n != 0 {
+			t.Errorf("ZADD INCR GT with a negative increment = %d", n)
+		}
+		if _, err := z.ZADD(ZADD_INCR, ZSetMember{"inf", math.Inf(1)}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := z.ZADD(ZADD_INCR, ZSetMember{"inf", math.Inf(-1)}); err != ErrScoreNaN {
+			t.Errorf("ZADD INCR to NaN = %v", err)
+		}
+	})
+}
+
+func TestSortedSet_RangeByLex(t *testing.T) {
+	withZSetEncodings(t, func(t *testing.T) {
+		z := newSortedSet(ZSetMember{"a", 0}, ZSetMember{"b", 0}, ZSetMember{"c", 0}, ZSetMember{"d", 0})
+
+		tests := []struct {
+			min, max string
+			want     string
+		}{
+			{"-", "+", "a,b,c,d"},
+			{"[b", "(d", "b,c"},
+			{"(a", "[c", "b,c"},
+			{"[e", "+", ""},
+		}
+		for _, tt := range tests {
+			got, err := z.ZRANGEBYLEX(tt.min, tt.max)
+			if err != nil || keysOf(got) != tt.want {
+				t.Errorf("ZRANGEBYLEX(%s, %s) = %v, %v", tt.min, tt.max, got, err)
+			}
+		}
+		if _, err := z.ZRANGEBYLEX("a", "+"); err == nil {
+			t.Errorf("ZRANGEBYLEX with an invalid bound should fail")
+		}
+	})
+}
+
+func TestSortedSet_InterAndDiff(t *testing.T) {
+	withZSetEncodings(t, func(t *testing.T) {
+		z1 := newSortedSet(ZSetMember{"a", 1}, ZSetMember{"b", 2}, ZSetMember{"c", 3})
+		z2 := newSortedSet(ZSetMember{"a", 10}, ZSetMember{"c", 1}, ZSetMember{"d", 4})
+
+		want := []ZSetMember{{"c", 4}, {"a", 11}}
+		if got := z1.ZINTER(z2); !reflect.DeepEqual(got, want) {
+			t.Errorf("ZINTER = %v", got)
+		}
+		want = []ZSetMember{{"b", 2}}
+		if got := z1.ZDIFF(z2); !reflect.DeepEqual(got, want) {
+			t.Errorf("ZDIFF = %v", got)
+		}
+		if got := z1.ZDIFF(); len(got) != 3 {
+			t.Errorf("ZDIFF without others = %v", got)
+		}
+	})
+}
+
+func TestSortedSet_Conversion(t *testing.T) {
+	defer func(entries, value int) {
+		config.ZSetMaxZiplistEntries, config.ZSetMaxZiplistValue = entries, value
+	}(config.ZSetMaxZiplistEntries, config.ZSetMaxZiplistValue)
+	config.ZSetMaxZiplistEntries, config.ZSetMaxZiplistValue = 4, 8
+
+	z := NewSortedSetObject()
+	for i := 0; i < 4; i++ {
+		z.ZADD(0, ZSetMember{fmt.Sprint(i), float64(i) / 2})
+	}
+	if et := zsetEncoding(z); et != OBJ_ENCODING_ZIPLIST {
+		t.Fatalf("encoding = %d", et)
+	}
+	z.ZADD(0, ZSetMember{"4", 2})
+	if et := zsetEncoding(z); et != OBJ_ENCODING_SKIPLIST {
+		t.Fatalf("encoding after exceeding the entries = %d", et)
+	}
+	if got := keysOf(z.ZRANGEBYSCORE(0, 2)); got != "0,1,2,3,4" {
+		t.Errorf("members after conversion = %s", got)
+	}
+	if s, _ := z.ZSCORE("3"); s != 1.5 {
+		t.Errorf("ZSCORE after conversion = %v", s)
+	}
+
+	z = newSortedSet(ZSetMember{"short", 1})
+	z.ZADD(0, ZSetMember{"a long member", 2})
+	if et := zsetEncoding(z); et != OBJ_ENCODING_SKIPLIST {
+		t.Errorf("encoding after a long member = %d", et)
+	}
+}
+
+func TestSortedSet_Algebra(t *testing.T) {
+	z1 := newSortedSet(ZSetMember{"a", 1}, ZSetMember{"b", 2}, ZSetMember{"c", 3})
+	z2 := newSortedSet(ZSetMember{"b", 10}, ZSetMember{"c", math.Inf(-1)}, ZSetMember{"d", 4})
+	s := SetOperand{newSet("a", "d")}
+
+	want := []ZSetMember{{"c", math.Inf(-1)}, {"a", 3}, {"d", 10}, {"b", 22}}
+	if got := ZUNION([]float64{1, 2, 2}, ZAGGREGATE_SUM, z1, z2, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("ZUNION SUM = %v", got)
+	}
+	want = []ZSetMember{{"c", math.Inf(-1)}, {"a", 1}, {"d", 1}, {"b", 2}}
+	if got := ZUNION(nil, ZAGGREGATE_MIN, z1, z2, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("ZUNION MIN = %v", got)
+	}
+	want = []ZSetMember{{"c", 3}, {"b", 10}}
+	if got := ZINTER(nil, ZAGGREGATE_MAX, z1, z2); !reflect.DeepEqual(got, want) {
+		t.Errorf("ZINTER MAX = %v", got)
+	}
+	// inf * 0 counts as 0
+	want = []ZSetMember{{"d", 1}}
+	if got := ZINTER([]float64{0, 1}, ZAGGREGATE_SUM, z2, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("ZINTER with a zero weight = %v", got)
+	}
+	want = []ZSetMember{{"b", 2}, {"c", 3}}
+	if got := ZDIFF(z1, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("ZDIFF = %v", got)
+	}
+}
+
+func TestSortedSet_FromMembers(t *testing.T) {
+	defer func(entries int) { config.ZSetMaxZiplistEntries = entries }(config.ZSetMaxZiplistEntries)
+	config.ZSetMaxZiplistEntries = 2
+
+	members := []ZSetMember{{"b", 2}, {"a", 1}}
+	z := NewSortedSetObjectFromMembers(members)
+	if et := zsetEncoding(z); et != OBJ_ENCODING_ZIPLIST {
+		t.Errorf("encoding of 2 members = %d", et)
+	}
+	if got := keysOf(z.ZRANGEBYSCORE(math.Inf(-1), math.Inf(1))); got != "a,b" {
+		t.Errorf("members = %s", got)
+	}
+
+	z = NewSortedSetObjectFromMembers(append(members, ZSetMember{"c", 0}))
+	if et := zsetEncoding(z); et != OBJ_ENCODING_SKIPLIST {
+		t.Errorf("encoding of 3 members = %d", et)
+	}
+	if got := keysOf(z.ZRANGEBYSCORE(math.Inf(-1), math.Inf(1))); got != "c,a,b" {
+		t.Errorf("members = %s", got)
+	}
+}
@@ -0,0 +1,632 @@
+package object
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viktorxhzj/mykv/config"
+	"github.com/viktorxhzj/mykv/datastructure"
+	"github.com/viktorxhzj/mykv/util"
+)
+
+//
+// A stream object is encoded as OBJ_ENCODING_STREAM:
+// a radix tree from the ID of the first entry of each macro node, the master ID,
+// to the node, a listpack of entries.
+//
+// A listpack node starts with the master entry:
+// <count> <deleted> <num-fields> <field-1> ... <field-N> <0>
+// where count and deleted are the numbers of valid and deleted entries,
+// followed by the entries:
+// <flags> <ms-diff> <seq-diff> <num-fields> <field-1> <value-1> ... <field-N> <value-N> <lp-count>
+// or, if the entry has the same fields as the master entry:
+// <flags> <ms-diff> <seq-diff> <value-1> ... <value-N> <lp-count>
+// where the ID is stored as the difference from the master ID,
+// and lp-count is the number of listpack entries of the entry before it.
+//
+// A deleted entry is only flagged, and the node is removed once all its entries are deleted.
+//
+
+// stream entry flags
+const (
+	STREAM_ITEM_FLAG_NONE       = 0
+	STREAM_ITEM_FLAG_DELETED    = 1 << 0
+	STREAM_ITEM_FLAG_SAMEFIELDS = 1 << 1
+)
+
+// trimming strategies of XADD and XTRIM
+const (
+	STREAM_TRIM_MAXLEN = iota + 1
+	STREAM_TRIM_MINID
+)
+
+var (
+	ErrStreamInvalidID     = errors.New("ERR Invalid stream ID specified as stream command argument")
+	ErrStreamIDTooSmall    = errors.New("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+	ErrStreamIDZero        = errors.New("ERR The ID specified in XADD must be greater than 0-0")
+	ErrStreamExhausted     = errors.New("ERR The stream has exhausted the last possible ID, unable to add more items")
+	ErrStreamFieldsArity   = errors.New("ERR wrong number of arguments for 'xadd' command")
+	ErrStreamNegativeTrim  = errors.New("ERR The MAXLEN argument must be >= 0.")
+	ErrStreamLimitNotExact = errors.New("ERR syntax error, LIMIT cannot be used without the special ~ option")
+)
+
+// timeNow is the clock of streams, for IDs and idle times.
+var timeNow = time.Now
+
+// StreamID is the ID of a stream entry, <ms>-<seq>.
+type StreamID struct {
+	Ms, Seq uint64
+}
+
+// StreamEntry is an entry of a stream,
+// where Fields holds the fields and the values in turn.
+// The Fields of an entry that was deleted but is still pending is nil.
+type StreamEntry struct {
+	ID     StreamID
+	Fields []string
+}
+
+// StreamTrimArgs are the trimming arguments of XADD and XTRIM.
+type StreamTrimArgs struct {
+	Strategy int      // STREAM_TRIM_MAXLEN or STREAM_TRIM_MINID
+	MaxLen   int      // STREAM_TRIM_MAXLEN only
+	MinID    StreamID // STREAM_TRIM_MINID only
+	Approx   bool     // only remove whole nodes
+	Limit    int      // the maximum number of entries to remove if Approx, 0 for unlimited
+}
+
+// Stream is a stream object.
+type Stream struct {
+	*ValueObject
+}
+
+type stream struct {
+	rax     *datastructure.Rax // master ID -> *datastructure.ListPack
+	length  int
+	lastID  StreamID
+	cgroups *datastructure.Rax // name -> *streamCG
+}
+
+// NewStreamObject creates an empty stream object.
+func NewStreamObject() Stream {
+	o := new(ValueObject)
+	o.SetType(OBJ_STREAM, OBJ_ENCODING_STREAM)
+	o.Structure = &stream{
+		rax:     datastructure.NewRax(),
+		cgroups: datastructure.NewRax(),
+	}
+	return Stream{o}
+}
+
+func (s Stream) stream() *stream {
+	return s.Structure.(*stream)
+}
+
+//
+// stream IDs
+//
+
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// Compare returns -1, 0 or 1 if id is less than, equal to or greater than other.
+func (id StreamID) Compare(other StreamID) int {
+	switch {
+	case id.Ms < other.Ms || (id.Ms == other.Ms && id.Seq < other.Seq):
+		return -1
+	case id == other:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Incr returns the next ID, and false if id is the greatest one.
+func (id StreamID) Incr() (StreamID, bool) {
+	switch {
+	case id.Seq != math.MaxUint64:
+		return StreamID{id.Ms, id.Seq + 1}, true
+	case id.Ms != math.MaxUint64:
+		return StreamID{id.Ms + 1, 0}, true
+	default:
+		return id, false
+	}
+}
+
+// Decr returns the previous ID, and false if id is the least one.
+func (id StreamID) Decr() (StreamID, bool) {
+	switch {
+	case id.Seq != 0:
+		return StreamID{id.Ms, id.Seq - 1}, true
+	case id.Ms != 0:
+		return StreamID{id.Ms - 1, math.MaxUint64}, true
+	default:
+		return id, false
+	}
+}
+
+// encode encodes the ID into 16 big-endian bytes, the key of a radix tree,
+// so that the keys are in the order of the IDs.
+func (id StreamID) encode() []byte {
+	b := make([]byte, 16)
+	util.UI64ToB(id.Ms, b, 0)
+	util.UI64ToB(id.Seq, b, 8)
+	return b
+}
+
+func decodeStreamID(b []byte) StreamID {
+	return StreamID{util.BToUI64(b, 0), util.BToUI64(b, 8)}
+}
+
+// ParseStreamID parses "<ms>-<seq>", or "<ms>" whose seq is missingSeq.
+func ParseStreamID(s string, missingSeq uint64) (StreamID, error) {
+	ms, seq, hasSeq := s, "", false
+	if i := strings.IndexByte(s, '-'); i != -1 {
+		ms, seq, hasSeq = s[:i], s[i+1:], true
+	}
+
+	id := StreamID{Seq: missingSeq}
+	var err error
+	if id.Ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return StreamID{}, ErrStreamInvalidID
+	}
+	if hasSeq {
+		if id.Seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+			return StreamID{}, ErrStreamInvalidID
+		}
+	}
+	return id, nil
+}
+
+// ParseStreamRangeID parses an ID of XRANGE, XREVRANGE or XPENDING,
+// which is also "-" or "+" for the least or the greatest ID,
+// or an ID prefixed by "(" to exclude it.
+// A missing seq is 0 for the start of the range, or the greatest seq for the end.
+func ParseStreamRangeID(s string, isEnd bool) (StreamID, error) {
+	switch s {
+	case "-":
+		return StreamID{}, nil
+	case "+":
+		return StreamID{math.MaxUint64, math.MaxUint64}, nil
+	}
+
+	var missingSeq uint64
+	if isEnd {
+		missingSeq = math.MaxUint64
+	}
+	if !strings.HasPrefix(s, "(") {
+		return ParseStreamID(s, missingSeq)
+	}
+
+	id, err := ParseStreamID(s[1:], missingSeq)
+	if err != nil {
+		return StreamID{}, err
+	}
+	ok := false
+	if isEnd {
+		id, ok = id.Decr()
+	} else {
+		id, ok = id.Incr()
+	}
+	if !ok {
+		return StreamID{}, ErrStreamInvalidID
+	}
+	return id, nil
+}
+
+//
+// commands
+//
+
+// XADD appends an entry of the fields and values to the stream, and returns its ID.
+// id is "*" for an ID generated from the current time,
+// "<ms>-*" for an ID generated from ms,
+// or "<ms>-<seq>", which must be greater than the last ID.
+func (s Stream) XADD(id string, fields ...string) (StreamID, error) {
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return StreamID{}, ErrStreamFieldsArity
+	}
+	st := s.stream()
+	newID, err := st.nextID(id)
+	if err != nil {
+		return StreamID{}, err
+	}
+	st.append(newID, fields)
+	return newID, nil
+}
+
+// XLEN returns the number of entries.
+func (s Stream) XLEN() int {
+	return s.stream().length
+}
+
+// LastID returns the ID of the last entry ever added, which may have been deleted.
+func (s Stream) LastID() StreamID {
+	return s.stream().lastID
+}
+
+// XRANGE returns the entries whose IDs are within [start, end],
+// no more than count if count is positive.
+func (s Stream) XRANGE(start, end StreamID, count int) []StreamEntry {
+	return s.stream().rangeEntries(start, end, count, false)
+}
+
+// XREVRANGE returns the entries whose IDs are within [start, end] in reverse order,
+// no more than count if count is positive.
+func (s Stream) XREVRANGE(end, start StreamID, count int) []StreamEntry {
+	return s.stream().rangeEntries(start, end, count, true)
+}
+
+// XDEL deletes the entries, and returns the number of deleted ones.
+func (s Stream) XDEL(ids ...StreamID) int {
+	n := 0
+	for _, id := range ids {
+		if s.stream().delete(id) {
+			n++
+		}
+	}
+	return n
+}
+
+// XTRIM trims the stream as args specify, and returns the number of deleted entries.
+// STREAM_TRIM_MAXLEN deletes the oldest entries until at most MaxLen entries are left,
+// STREAM_TRIM_MINID deletes the entries whose IDs are less than MinID.
+func (s Stream) XTRIM(args StreamTrimArgs) (int, error) {
+	if err := args.Validate(); err != nil {
+		return 0, err
+	}
+	return s.stream().trim(args), nil
+}
+
+// Validate checks the trimming arguments.
+func (args StreamTrimArgs) Validate() error {
+	if args.Strategy == STREAM_TRIM_MAXLEN && args.MaxLen < 0 {
+		return ErrStreamNegativeTrim
+	}
+	if args.Limit != 0 && !args.Approx {
+		return ErrStreamLimitNotExact
+	}
+	return nil
+}
+
+//
+// storage
+//
+
+// nextID returns the ID of a new entry, given as XADD does.
+func (st *stream) nextID(s string) (StreamID, error) {
+	if s == "*" {
+		ms := uint64(timeNow().UnixNano() / int64(time.Millisecond))
+		if ms > st.lastID.Ms {
+			return StreamID{ms, 0}, nil
+		}
+		id, ok := st.lastID.Incr()
+		if !ok {
+			return StreamID{}, ErrStreamExhausted
+		}
+		return id, nil
+	}
+
+	if !strings.HasSuffix(s, "-*") {
+		id, err := ParseStreamID(s, 0)
+		switch {
+		case err != nil:
+			return StreamID{}, err
+		case id == StreamID{}:
+			return StreamID{}, ErrStreamIDZero
+		case id.Compare(st.lastID) <= 0:
+			return StreamID{}, ErrStreamIDTooSmall
+		}
+		return id, nil
+	}
+
+	// the seq follows the last ID if they have the same ms, which also avoids 0-0
+	ms, err := strconv.ParseUint(s[:len(s)-2], 10, 64)
+	switch {
+	case err != nil:
+		return StreamID{}, ErrStreamInvalidID
+	case ms < st.lastID.Ms || ms == st.lastID.Ms && st.lastID.Seq == math.MaxUint64:
+		return StreamID{}, ErrStreamIDTooSmall
+	case ms == st.lastID.Ms:
+		return StreamID{ms, st.lastID.Seq + 1}, nil
+	default:
+		return StreamID{ms, 0}, nil
+	}
+}
+
+// append appends an entry to the last node,
+// or to a new node if the last one is full.
+func (st *stream) append(id StreamID, fields []string) {
+	var lp *datastructure.ListPack
+	var master StreamID
+	it := st.rax.Iterator()
+	if it.Seek("$", nil) && it.Next() {
+		lp, master = it.Value.(*datastructure.ListPack), decodeStreamID(it.Key)
+		if st.nodeIsFull(lp, fields) {
+			lp = nil
+		}
+	}
+
+	// the master fields are the fields of the first entry
+	if lp == nil {
+		lp, master = datastructure.NewListPack(), id
+		lp.AddInt(0)
+		lp.AddInt(0)
+		lp.AddInt(len(fields) / 2)
+		for i := 0; i < len(fields); i += 2 {
+			listPackAdd(lp, fields[i])
+		}
+		lp.AddInt(0)
+		st.rax.Insert(id.encode(), lp)
+	}
+
+	valid, deleted, masterFields := decodeStreamMaster(lp)
+	flags := STREAM_ITEM_FLAG_NONE
+	if len(masterFields) == len(fields)/2 {
+		flags = STREAM_ITEM_FLAG_SAMEFIELDS
+		for i, f := range masterFields {
+			if fields[2*i] != f {
+				flags = STREAM_ITEM_FLAG_NONE
+				break
+			}
+		}
+	}
+
+	lp.AddInt(flags)
+	lp.AddInt(int(id.Ms - master.Ms))
+	lp.AddInt(int(id.Seq - master.Seq))
+	count := 3
+	if flags&STREAM_ITEM_FLAG_SAMEFIELDS != 0 {
+		for i := 1; i < len(fields); i += 2 {
+			listPackAdd(lp, fields[i])
+		}
+		count += len(fields) / 2
+	} else {
+		lp.AddInt(len(fields) / 2)
+		for _, f := range fields {
+			listPackAdd(lp, f)
+		}
+		count += len(fields) + 1
+	}
+	lp.AddInt(count)
+
+	setStreamNodeCounts(lp, valid+1, deleted)
+	st.length++
+	st.lastID = id
+}
+
+// nodeIsFull reports whether the node cannot take an entry of the fields and values,
+// as limited by stream-node-max-bytes and stream-node-max-entries.
+func (st *stream) nodeIsFull(lp *datastructure.ListPack, fields []string) bool {
+	size := 0
+	for _, f := range fields {
+		size += len(f)
+	}
+	if config.StreamNodeMaxBytes > 0 && lp.LPBytes()+size >= config.StreamNodeMaxBytes {
+		return true
+	}
+	valid, deleted, _ := decodeStreamMaster(lp)
+	if config.StreamNodeMaxEntries > 0 && valid+deleted >= config.StreamNodeMaxEntries {
+		return true
+	}
+	// an entry takes at most 5 listpack entries more than its fields and values
+	return lp.LPLen()+len(fields)+5 > datastructure.LP_MAX_LEN
+}
+
+// streamNodeEntry is an entry decoded from a node.
+type streamNodeEntry struct {
+	StreamEntry
+	flags    int
+	flagsPos int // the position of the flags in the listpack
+}
+
+func decodeStreamMaster(lp *datastructure.ListPack) (valid, deleted int, fields []string) {
+	p := lp.First()
+	valid = lp.GetAt(p).(int)
+	p = lp.Next(p)
+	deleted = lp.GetAt(p).(int)
+	p = lp.Next(p)
+	fields = make([]string, lp.GetAt(p).(int))
+	for i := range fields {
+		p = lp.Next(p)
+		fields[i] = zipListString(lp.GetAt(p))
+	}
+	return
+}
+
+// decodeStreamNode decodes all the entries of a node, including the deleted ones.
+func decodeStreamNode(master StreamID, lp *datastructure.ListPack) []streamNodeEntry {
+	_, _, masterFields := decodeStreamMaster(lp)
+	p := lp.First()
+	for i := 0; i < 4+len(masterFields); i++ {
+		p = lp.Next(p)
+	}
+
+	next := func() interface{} {
+		e := lp.GetAt(p)
+		p = lp.Next(p)
+		return e
+	}
+	var entries []streamNodeEntry
+	for p != -1 {
+		var e streamNodeEntry
+		e.flagsPos = p
+		e.flags = next().(int)
+		e.ID.Ms = master.Ms + uint64(next().(int))
+		e.ID.Seq = master.Seq + uint64(next().(int))
+		if e.flags&STREAM_ITEM_FLAG_SAMEFIELDS != 0 {
+			e.Fields = make([]string, 2*len(masterFields))
+			for i, f := range masterFields {
+				e.Fields[2*i] = f
+				e.Fields[2*i+1] = zipListString(next())
+			}
+		} else {
+			e.Fields = make([]string, 2*next().(int))
+			for i := range e.Fields {
+				e.Fields[i] = zipListString(next())
+			}
+		}
+		next() // lp-count
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func setStreamNodeCounts(lp *datastructure.ListPack, valid, deleted int) {
+	p := lp.First()
+	lp.ReplaceAt(p, valid)
+	lp.ReplaceAt(lp.Next(p), deleted)
+}
+
+// iterate calls f with the valid entries whose IDs are within [start, end],
+// in reverse order if rev, until f returns false.
+func (st *stream) iterate(start, end StreamID, rev bool, f func(e StreamEntry) bool) {
+	if start.Compare(end) > 0 {
+		return
+	}
+
+	it := st.rax.Iterator()
+	move := it.Next
+	if rev {
+		move = it.Prev
+		if !it.Seek("<=", end.encode()) {
+			return
+		}
+	} else if !it.Seek("<=", start.encode()) {
+		it.Seek("^", nil)
+	}
+
+	for move() {
+		entries := decodeStreamNode(decodeStreamID(it.Key), it.Value.(*datastructure.ListPack))
+		for i := range entries {
+			e := entries[i]
+			if rev {
+				e = entries[len(entries)-1-i]
+			}
+			if e.flags&STREAM_ITEM_FLAG_DELETED != 0 {
+				continue
+			}
+			if (!rev && e.ID.Compare(end) > 0) || (rev && e.ID.Compare(start) < 0) {
+				return
+			}
+			if e.ID.Compare(start) >= 0 && e.ID.Compare(end) <= 0 && !f(e.StreamEntry) {
+				return
+			}
+		}
+	}
+}
+
+func (st *stream) rangeEntries(start, end StreamID, count int, rev bool) []StreamEntry {
+	var res []StreamEntry
+	st.iterate(start, end, rev, func(e StreamEntry) bool {
+		res = append(res, e)
+		return count <= 0 || len(res) < count
+	})
+	return res
+}
+
+// lookup returns the entry of the ID, and false if it does not exist.
+func (st *stream) lookup(id StreamID) (StreamEntry, bool) {
+	entries := st.rangeEntries(id, id, 1, false)
+	if len(entries) == 0 {
+		return StreamEntry{}, false
+	}
+	return entries[0], true
+}
+
+// delete flags the entry of the ID as deleted, and returns false if it does not exist.
+func (st *stream) delete(id StreamID) bool {
+	it := st.rax.Iterator()
+	if !it.Seek("<=", id.encode()) || !it.Next() {
+		return false
+	}
+	lp := it.Value.(*datastructure.ListPack)
+	for _, e := range decodeStreamNode(decodeStreamID(it.Key), lp) {
+		if e.ID == id && e.flags&STREAM_ITEM_FLAG_DELETED == 0 {
+			st.deleteNodeEntry(it.Key, lp, e)
+			return true
+		}
+	}
+	return false
+}
+
+// deleteNodeEntry flags an entry of a node as deleted, and removes the node if it gets empty.
+func (st *stream) deleteNodeEntry(key []byte, lp *datastructure.ListPack, e streamNodeEntry) {
+	// the flags keep their size, so the positions of the other entries stay the same
+	lp.ReplaceAt(e.flagsPos, e.flags|STREAM_ITEM_FLAG_DELETED)
+	valid, deleted, _ := decodeStreamMaster(lp)
+	if valid == 1 {
+		st.rax.Remove(key)
+	} else {
+		setStreamNodeCounts(lp, valid-1, deleted+1)
+	}
+	st.length--
+}
+
+// trim removes the whole nodes from the head, then, unless args.Approx,
+// flags the entries of the first node left as deleted.
+func (st *stream) trim(args StreamTrimArgs) int {
+	removed := 0
+	it := st.rax.Iterator()
+	it.Seek("^", nil)
+	for it.Next() {
+		if args.Strategy == STREAM_TRIM_MAXLEN && st.length <= args.MaxLen {
+			break
+		}
+
+		lp := it.Value.(*datastructure.ListPack)
+		valid, _, _ := decodeStreamMaster(lp)
+		entries := decodeStreamNode(decodeStreamID(it.Key), lp)
+		var removeNode bool
+		if args.Strategy == STREAM_TRIM_MAXLEN {
+			removeNode = st.length-valid >= args.MaxLen
+		} else {
+			removeNode = entries[len(entries)-1].ID.Compare(args.MinID) < 0
+		}
+		if removeNode {
+			if args.Limit > 0 && removed+valid > args.Limit {
+				break
+			}
+			st.rax.Remove(it.Key)
+			st.length -= valid
+			removed += valid
+			continue
+		}
+		if args.Approx {
+			break
+		}
+
+		// deleting an entry may move the others, so decode the node again each time
+		for st.length > 0 {
+			var e *streamNodeEntry
+			for _, ne := range decodeStreamNode(decodeStreamID(it.Key), lp) {
+				if ne.flags&STREAM_ITEM_FLAG_DELETED == 0 {
+					e = &ne
+					break
+				}
+			}
+			if e == nil || args.Strategy == STREAM_TRIM_MAXLEN && st.length <= args.MaxLen ||
+				args.Strategy == STREAM_TRIM_MINID && e.ID.Compare(args.MinID) >= 0 {
+				break
+			}
+			st.deleteNodeEntry(it.Key, lp, *e)
+			removed++
+		}
+		break
+	}
+	return removed
+}
+
+// listPackAdd adds s at the tail of the listpack,
+// as an integer entry if it is the canonical form of an integer.
+func listPackAdd(lp *datastructure.ListPack, s string) {
+	if i, ok := zipListTryInt(s); ok {
+		lp.AddInt(i)
+	} else {
+		lp.AddString(s)
+	}
+}
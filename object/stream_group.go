@@ -0,0 +1,371 @@
+package object
+
+import (
+	"errors"
+	"time"
+
+	"github.com/viktorxhzj/mykv/datastructure"
+)
+
+//
+// A consumer group reads a stream from its last delivered ID,
+// and keeps each delivered entry pending until it is acknowledged,
+// in the pending entries list, PEL, of the group,
+// and in the PEL of the consumer it was delivered to.
+// The two PELs are radix trees sharing the same streamNACK of an entry.
+//
+
+var (
+	ErrStreamBusyGroup = errors.New("BUSYGROUP Consumer Group name already exists")
+	ErrStreamNoGroup   = errors.New("NOGROUP No such key or consumer group")
+)
+
+type streamCG struct {
+	lastID    StreamID           // the last ID delivered to the group
+	pel       *datastructure.Rax // ID -> *streamNACK
+	consumers *datastructure.Rax // name -> *streamConsumer
+}
+
+type streamConsumer struct {
+	name     string
+	seenTime time.Time
+	pel      *datastructure.Rax // ID -> *streamNACK
+}
+
+// streamNACK is a pending entry, delivered but not acknowledged.
+type streamNACK struct {
+	deliveryTime  time.Time
+	deliveryCount int
+	consumer      *streamConsumer
+}
+
+// StreamPendingSummary is the summary of the pending entries of a group.
+type StreamPendingSummary struct {
+	Count     int
+	Min, Max  StreamID // the least and the greatest pending IDs
+	Consumers []StreamConsumerPending
+}
+
+// StreamConsumerPending is the number of pending entries of a consumer.
+type StreamConsumerPending struct {
+	Name  string
+	Count int
+}
+
+// StreamPendingEntry is a pending entry of a group.
+type StreamPendingEntry struct {
+	ID            StreamID
+	Consumer      string
+	Idle          time.Duration
+	DeliveryCount int
+}
+
+// StreamClaimArgs are the options of XCLAIM.
+type StreamClaimArgs struct {
+	Idle       time.Duration // set the idle time of the claimed entries if positive
+	Time       time.Time     // set the delivery time of the claimed entries if not zero
+	RetryCount int           // set the delivery count of the claimed entries if positive
+	Force      bool          // claim the entries that are not pending, if they exist in the stream
+	JustID     bool          // reply the IDs only, and leave the delivery count as it is
+}
+
+// XGROUPCREATE creates a group that reads the entries after id.
+func (s Stream) XGROUPCREATE(group string, id StreamID) error {
+	cg := &streamCG{
+		lastID:    id,
+		pel:       datastructure.NewRax(),
+		consumers: datastructure.NewRax(),
+	}
+	if _, ok := s.stream().cgroups.Find([]byte(group)); ok {
+		return ErrStreamBusyGroup
+	}
+	s.stream().cgroups.Insert([]byte(group), cg)
+	return nil
+}
+
+// XREADGROUP reads the entries for the consumer of the group,
+// no more than count if count is positive.
+// If newEntries, it delivers the entries never delivered to the group,
+// which are pending until acknowledged unless noAck,
+// otherwise it returns the entries pending for the consumer whose IDs are greater than after,
+// where the Fields of a deleted entry are nil.
+func (s Stream) XREADGROUP(group, consumer string, after StreamID, newEntries bool, count int, noAck bool) ([]StreamEntry, error) {
+	cg, ok := s.group(group)
+	if !ok {
+		return nil, ErrStreamNoGroup
+	}
+	c := cg.consumer(consumer, true)
+	now := timeNow()
+	c.seenTime = now
+
+	st := s.stream()
+	if !newEntries {
+		var res []StreamEntry
+		it := c.pel.Iterator()
+		it.Seek(">", after.encode())
+		for (count <= 0 || len(res) < count) && it.Next() {
+			id := decodeStreamID(it.Key)
+			e, ok := st.lookup(id)
+			if ok {
+				nack := it.Value.(*streamNACK)
+				nack.deliveryTime = now
+				nack.deliveryCount++
+			}
+			e.ID = id
+			res = append(res, e)
+		}
+		return res, nil
+	}
+
+	start, ok := cg.lastID.Incr()
+	if !ok {
+		return nil, nil
+	}
+	res := st.rangeEntries(start, st.lastID, count, false)
+	for _, e := range res {
+		cg.lastID = e.ID
+		if noAck {
+			continue
+		}
+		key := e.ID.encode()
+		if v, ok := cg.pel.Find(key); ok {
+			// delivered again after the last ID of the group was set back
+			nack := v.(*streamNACK)
+			nack.consumer.pel.Remove(key)
+			nack.deliveryTime, nack.deliveryCount, nack.consumer = now, 1, c
+			c.pel.Insert(key, nack)
+		} else {
+			nack := &streamNACK{now, 1, c}
+			cg.pel.Insert(key, nack)
+			c.pel.Insert(key, nack)
+		}
+	}
+	return res, nil
+}
+
+// XACK acknowledges the pending entries of the group, and returns the number of them.
+func (s Stream) XACK(group string, ids ...StreamID) int {
+	cg, ok := s.group(group)
+	if !ok {
+		return 0
+	}
+	n := 0
+	for _, id := range ids {
+		if cg.ack(id) {
+			n++
+		}
+	}
+	return n
+}
+
+// XPENDING returns the summary of the pending entries of the group.
+func (s Stream) XPENDING(group string) (StreamPendingSummary, error) {
+	cg, ok := s.group(group)
+	if !ok {
+		return StreamPendingSummary{}, ErrStreamNoGroup
+	}
+
+	var summary StreamPendingSummary
+	summary.Count = cg.pel.Size()
+	if summary.Count == 0 {
+		return summary, nil
+	}
+	it := cg.pel.Iterator()
+	it.Seek("^", nil)
+	it.Next()
+	summary.Min = decodeStreamID(it.Key)
+	it.Seek("$", nil)
+	it.Next()
+	summary.Max = decodeStreamID(it.Key)
+
+	it = cg.consumers.Iterator()
+	it.Seek("^", nil)
+	for it.Next() {
+		c := it.Value.(*streamConsumer)
+		if n := c.pel.Size(); n > 0 {
+			summary.Consumers = append(summary.Consumers, StreamConsumerPending{c.name, n})
+		}
+	}
+	return summary, nil
+}
+
+// XPENDINGRANGE returns the pending entries of the group whose IDs are within [start, end],
+// no more than count, idle for at least minIdle,
+// and only those of the consumer if consumer is not empty.
+func (s Stream) XPENDINGRANGE(group string, minIdle time.Duration, start, end StreamID, count int, consumer string) ([]StreamPendingEntry, error) {
+	cg, ok := s.group(group)
+	if !ok {
+		return nil, ErrStreamNoGroup
+	}
+	pel := cg.pel
+	if consumer != "" {
+		c := cg.consumer(consumer, false)
+		if c == nil {
+			return nil, nil
+		}
+		pel = c.pel
+	}
+
+	var res []StreamPendingEntry
+	now := timeNow()
+	it := pel.Iterator()
+	it.Seek(">=", start.encode())
+	for len(res) < count && it.Next() {
+		id := decodeStreamID(it.Key)
+		if id.Compare(end) > 0 {
+			break
+		}
+		nack := it.Value.(*streamNACK)
+		if idle := now.Sub(nack.deliveryTime); idle >= minIdle {
+			res = append(res, StreamPendingEntry{id, nack.consumer.name, idle, nack.deliveryCount})
+		}
+	}
+	return res, nil
+}
+
+// XCLAIM transfers the pending entries idle for at least minIdle to the consumer,
+// and returns the claimed entries, whose Fields are nil if args.JustID.
+// A pending entry that no longer exists in the stream is acknowledged instead.
+func (s Stream) XCLAIM(group, consumer string, minIdle time.Duration, ids []StreamID, args StreamClaimArgs) ([]StreamEntry, error) {
+	cg, ok := s.group(group)
+	if !ok {
+		return nil, ErrStreamNoGroup
+	}
+	now := timeNow()
+	deliveryTime := now
+	if args.Idle > 0 {
+		deliveryTime = now.Add(-args.Idle)
+	} else if !args.Time.IsZero() {
+		deliveryTime = args.Time
+	}
+
+	c := cg.consumer(consumer, true)
+	var res []StreamEntry
+	for _, id := range ids {
+		key := id.encode()
+		v, ok := cg.pel.Find(key)
+		if !ok {
+			if _, exists := s.stream().lookup(id); !args.Force || !exists {
+				continue
+			}
+			v = &streamNACK{consumer: c}
+			cg.pel.Insert(key, v)
+			c.pel.Insert(key, v)
+		}
+
+		nack := v.(*streamNACK)
+		if e, ok := s.claim(cg, c, id, nack, minIdle, deliveryTime, args.JustID); ok {
+			if args.RetryCount > 0 {
+				nack.deliveryCount = args.RetryCount
+			}
+			res = append(res, e)
+		}
+	}
+	c.seenTime = now
+	return res, nil
+}
+
+// XAUTOCLAIM transfers up to count pending entries idle for at least minIdle to the consumer,
+// scanning the pending entries from start, as XCLAIM does.
+// It returns the ID to scan from next time, 0-0 if the scan is over,
+// the claimed entries, and the IDs of the pending entries that no longer exist in the stream,
+// which are acknowledged instead.
+func (s Stream) XAUTOCLAIM(group, consumer string, minIdle time.Duration, start StreamID, count int, justID bool) (StreamID, []StreamEntry, []StreamID, error) {
+	cg, ok := s.group(group)
+	if !ok {
+		return StreamID{}, nil, nil, ErrStreamNoGroup
+	}
+	now := timeNow()
+	c := cg.consumer(consumer, true)
+	c.seenTime = now
+
+	var claimed []StreamEntry
+	var deleted []StreamID
+	attempts := count * 10
+	it := cg.pel.Iterator()
+	it.Seek(">=", start.encode())
+	for attempts > 0 && len(claimed) < count && it.Next() {
+		attempts--
+		id := decodeStreamID(it.Key)
+		if _, exists := s.stream().lookup(id); !exists {
+			cg.ack(id)
+			deleted = append(deleted, id)
+			continue
+		}
+		if e, ok := s.claim(cg, c, id, it.Value.(*streamNACK), minIdle, now, justID); ok {
+			claimed = append(claimed, e)
+		}
+	}
+
+	var next StreamID
+	if it.Next() {
+		next = decodeStreamID(it.Key)
+	}
+	return next, claimed, deleted, nil
+}
+
+// claim transfers the pending entry to the consumer, if it is idle for at least minIdle,
+// and returns it, or false if it is not claimed.
+func (s Stream) claim(cg *streamCG, c *streamConsumer, id StreamID, nack *streamNACK, minIdle time.Duration, deliveryTime time.Time, justID bool) (StreamEntry, bool) {
+	if minIdle > 0 && timeNow().Sub(nack.deliveryTime) < minIdle {
+		return StreamEntry{}, false
+	}
+	e, exists := s.stream().lookup(id)
+	if !exists {
+		cg.ack(id)
+		return StreamEntry{}, false
+	}
+
+	key := id.encode()
+	if nack.consumer != c {
+		nack.consumer.pel.Remove(key)
+		c.pel.Insert(key, nack)
+		nack.consumer = c
+	}
+	nack.deliveryTime = deliveryTime
+	if justID {
+		return StreamEntry{ID: id}, true
+	}
+	nack.deliveryCount++
+	return e, true
+}
+
+// HasGroup reports whether the group of the name exists.
+func (s Stream) HasGroup(name string) bool {
+	_, ok := s.group(name)
+	return ok
+}
+
+// group returns the group of the name, and false if it does not exist.
+func (s Stream) group(name string) (*streamCG, bool) {
+	v, ok := s.stream().cgroups.Find([]byte(name))
+	if !ok {
+		return nil, false
+	}
+	return v.(*streamCG), true
+}
+
+// ack removes the entry from the PELs, and returns false if it is not pending.
+func (cg *streamCG) ack(id StreamID) bool {
+	key := id.encode()
+	v, ok := cg.pel.Find(key)
+	if !ok {
+		return false
+	}
+	cg.pel.Remove(key)
+	v.(*streamNACK).consumer.pel.Remove(key)
+	return true
+}
+
+// consumer returns the consumer of the name, which is created if it does not exist and create is set.
+func (cg *streamCG) consumer(name string, create bool) *streamConsumer {
+	if v, ok := cg.consumers.Find([]byte(name)); ok {
+		return v.(*streamConsumer)
+	}
+	if !create {
+		return nil
+	}
+	c := &streamConsumer{name: name, seenTime: timeNow(), pel: datastructure.NewRax()}
+	cg.consumers.Insert([]byte(name), c)
+	return c
+}
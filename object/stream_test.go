@@ -0,0 +1,346 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/viktorxhzj/mykv/config"
+)
+
+// withClock sets the clock of streams to the returned pointer until the test ends.
+func withClock(t *testing.T) *time.Time {
+	now := time.Unix(1000, 0)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+	return &now
+}
+
+// withStreamNodeMaxEntries limits the entries of a stream node until the test ends.
+func withStreamNodeMaxEntries(t *testing.T, n int) {
+	defer func(old int) { t.Cleanup(func() { config.StreamNodeMaxEntries = old }) }(config.StreamNodeMaxEntries)
+	config.StreamNodeMaxEntries = n
+}
+
+func idsOf(entries []StreamEntry) string {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID.String()
+	}
+	return fmt.Sprint(ids)
+}
+
+// newStream creates a stream with the entries 1-1 ... n-1, each of which has a field "f" of value i.
+func newStream(t *testing.T, n int) Stream {
+	s := NewStreamObject()
+	for i := 1; i <= n; i++ {
+		if _, err := s.XADD(fmt.Sprintf("%d-1", i), "f", fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+var streamMax = StreamID{math.MaxUint64, math.MaxUint64}
+
+func TestStreamID_Parse(t *testing.T) {
+	tests := []struct {
+		s     string
+		isEnd bool
+		want  StreamID
+		err   error
+	}{
+		{"5-3", false, StreamID{5, 3}, nil},
+		{"5", false, StreamID{5, 0}, nil},
+		{"5", true, StreamID{5, math.MaxUint64}, nil},
+		{"-", false, StreamID{}, nil},
+		{"+", true, streamMax, nil},
+		{"(5-3", false, StreamID{5, 4}, nil},
+		{"(5-0", true, StreamID{4, math.MaxUint64}, nil},
+		{"(0-0", true, StreamID{}, ErrStreamInvalidID},
+		{"5-x", false, StreamID{}, ErrStreamInvalidID},
+		{"-1", false, StreamID{}, ErrStreamInvalidID},
+	}
+	for _, tt := range tests {
+		if got, err := ParseStreamRangeID(tt.s, tt.isEnd); got != tt.want || err != tt.err {
+			t.Errorf("ParseStreamRangeID(%q, %v) = %v, %v, want %v, %v", tt.s, tt.isEnd, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestStream_XADD(t *testing.T) {
+	now := withClock(t)
+	s := NewStreamObject()
+
+	if _, err := s.XADD("1-1", "f"); err != ErrStreamFieldsArity {
+		t.Errorf("odd fields err = %v", err)
+	}
+	if _, err := s.XADD("0-0", "f", "v"); err != ErrStreamIDZero {
+		t.Errorf("0-0 err = %v", err)
+	}
+
+	adds := []struct {
+		id   string
+		want string
+		err  error
+	}{
+		{"*", "1000000-0", nil},
+		{"*", "1000000-1", nil},
+		{"1000000-1", "", ErrStreamIDTooSmall},
+		{"1000000-*", "1000000-2", nil},
+		{"999-*", "", ErrStreamIDTooSmall},
+		{"2000000-*", "2000000-0", nil},
+		{"2000000-5", "2000000-5", nil},
+		{"*", "2000000-6", nil}, // the clock is behind the last ID
+		{"x-*", "", ErrStreamInvalidID},
+	}
+	for _, add := range adds {
+		id, err := s.XADD(add.id, "f", "v")
+		if err != add.err || err == nil && id.String() != add.want {
+			t.Errorf("XADD(%s) = %v, %v, want %s, %v", add.id, id, err, add.want, add.err)
+		}
+	}
+	*now = now.Add(time.Hour)
+	if id, _ := s.XADD("*", "f", "v"); id != (StreamID{4600000, 0}) {
+		t.Errorf("XADD(*) after an hour = %v", id)
+	}
+	if s.XLEN() != 7 || s.LastID() != (StreamID{4600000, 0}) {
+		t.Errorf("XLEN() = %d, LastID() = %v", s.XLEN(), s.LastID())
+	}
+
+	s = NewStreamObject()
+	s.XADD(fmt.Sprintf("%d-%d", uint64(math.MaxUint64), uint64(math.MaxUint64)), "f", "v")
+	if _, err := s.XADD("*", "f", "v"); err != ErrStreamExhausted {
+		t.Errorf("XADD after the last ID err = %v", err)
+	}
+}
+
+func TestStream_Range(t *testing.T) {
+	withStreamNodeMaxEntries(t, 3)
+	s := newStream(t, 10)
+	// entries of fields other than the master fields
+	s.XADD("11-1", "g", "11", "h", "-7")
+	s.XADD("12-1", "f", "12", "g", "x")
+
+	if n := s.stream().rax.Size(); n != 4 {
+		t.Errorf("nodes = %d, want 4", n)
+	}
+	if got := idsOf(s.XRANGE(StreamID{}, streamMax, 0)); got != "[1-1 2-1 3-1 4-1 5-1 6-1 7-1 8-1 9-1 10-1 11-1 12-1]" {
+		t.Errorf("XRANGE(-, +) = %v", got)
+	}
+	if got := idsOf(s.XRANGE(StreamID{3, 1}, StreamID{7, 0}, 0)); got != "[3-1 4-1 5-1 6-1]" {
+		t.Errorf("XRANGE(3-1, 7-0) = %v", got)
+	}
+	if got := idsOf(s.XRANGE(StreamID{3, 2}, streamMax, 2)); got != "[4-1 5-1]" {
+		t.Errorf("XRANGE(3-2, +, 2) = %v", got)
+	}
+	if got := idsOf(s.XREVRANGE(StreamID{7, 1}, StreamID{2, 5}, 0)); got != "[7-1 6-1 5-1 4-1 3-1]" {
+		t.Errorf("XREVRANGE(7-1, 2-5) = %v", got)
+	}
+	if got := idsOf(s.XREVRANGE(streamMax, StreamID{}, 2)); got != "[12-1 11-1]" {
+		t.Errorf("XREVRANGE(+, -, 2) = %v", got)
+	}
+	if got := s.XRANGE(StreamID{5, 0}, StreamID{4, 0}, 0); len(got) != 0 {
+		t.Errorf("XRANGE of an empty range = %v", got)
+	}
+
+	got := s.XRANGE(StreamID{10, 0}, streamMax, 0)
+	want := []StreamEntry{
+		{StreamID{10, 1}, []string{"f", "10"}},
+		{StreamID{11, 1}, []string{"g", "11", "h", "-7"}},
+		{StreamID{12, 1}, []string{"f", "12", "g", "x"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
+
+func TestStream_XDEL(t *testing.T) {
+	withStreamNodeMaxEntries(t, 3)
+	s := newStream(t, 7)
+
+	// 2-1 is deleted once, 2-2 does not exist
+	if n := s.XDEL(StreamID{2, 1}, StreamID{2, 1}, StreamID{2, 2}, StreamID{5, 1}); n != 2 {
+		t.Errorf("XDEL = %d, want 2", n)
+	}
+	if got := idsOf(s.XRANGE(StreamID{}, streamMax, 0)); got != "[1-1 3-1 4-1 6-1 7-1]" || s.XLEN() != 5 {
+		t.Errorf("after XDEL, entries = %v, XLEN() = %d", got, s.XLEN())
+	}
+
+	// the node is removed once all its entries are deleted
+	if n := s.XDEL(StreamID{4, 1}, StreamID{6, 1}); n != 2 || s.stream().rax.Size() != 2 {
+		t.Errorf("XDEL = %d, nodes = %d", n, s.stream().rax.Size())
+	}
+	if got := idsOf(s.XREVRANGE(streamMax, StreamID{}, 0)); got != "[7-1 3-1 1-1]" {
+		t.Errorf("XREVRANGE after XDEL = %v", got)
+	}
+
+	// deleting the last entry keeps the last ID
+	s.XDEL(StreamID{7, 1})
+	if s.LastID() != (StreamID{7, 1}) {
+		t.Errorf("LastID() = %v", s.LastID())
+	}
+	if _, err := s.XADD("7-1", "f", "v"); err != ErrStreamIDTooSmall {
+		t.Errorf("XADD of a deleted ID err = %v", err)
+	}
+}
+
+func TestStream_XTRIM(t *testing.T) {
+	withStreamNodeMaxEntries(t, 3)
+
+	tests := []struct {
+		args    StreamTrimArgs
+		removed int
+		want    string
+	}{
+		{StreamTrimArgs{Strategy: STREAM_TRIM_MAXLEN, MaxLen: 5}, 5, "[6-1 7-1 8-1 9-1 10-1]"},
+		{StreamTrimArgs{Strategy: STREAM_TRIM_MAXLEN, MaxLen: 5, Approx: true}, 3, "[4-1 5-1 6-1 7-1 8-1 9-1 10-1]"},
+		{StreamTrimArgs{Strategy: STREAM_TRIM_MAXLEN, MaxLen: 5, Approx: true, Limit: 2}, 0, "[1-1 2-1 3-1 4-1 5-1 6-1 7-1 8-1 9-1 10-1]"},
+		{StreamTrimArgs{Strategy: STREAM_TRIM_MAXLEN, MaxLen: 0}, 10, "[]"},
+		{StreamTrimArgs{Strategy: STREAM_TRIM_MINID, MinID: StreamID{5, 1}}, 4, "[5-1 6-1 7-1 8-1 9-1 10-1]"},
+		{StreamTrimArgs{Strategy: STREAM_TRIM_MINID, MinID: StreamID{8, 0}, Approx: true}, 6, "[7-1 8-1 9-1 10-1]"},
+		{StreamTrimArgs{Strategy: STREAM_TRIM_MINID, MinID: StreamID{1, 1}}, 0, "[1-1 2-1 3-1 4-1 5-1 6-1 7-1 8-1 9-1 10-1]"},
+	}
+	for _, tt := range tests {
+		s := newStream(t, 10)
+		removed, err := s.XTRIM(tt.args)
+		if got := idsOf(s.XRANGE(StreamID{}, streamMax, 0)); err != nil || removed != tt.removed || got != tt.want || s.XLEN() != 10-removed {
+			t.Errorf("XTRIM(%+v) = %d, %v, entries = %v, want %d, %v", tt.args, removed, err, got, tt.removed, tt.want)
+		}
+	}
+
+	s := newStream(t, 3)
+	if _, err := s.XTRIM(StreamTrimArgs{Strategy: STREAM_TRIM_MAXLEN, MaxLen: -1}); err != ErrStreamNegativeTrim {
+		t.Errorf("negative MAXLEN err = %v", err)
+	}
+	if _, err := s.XTRIM(StreamTrimArgs{Strategy: STREAM_TRIM_MAXLEN, Limit: 1}); err != ErrStreamLimitNotExact {
+		t.Errorf("exact LIMIT err = %v", err)
+	}
+}
+
+func TestStream_Groups(t *testing.T) {
+	now := withClock(t)
+	s := newStream(t, 5)
+
+	if err := s.XGROUPCREATE("g", StreamID{2, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.XGROUPCREATE("g", StreamID{}); err != ErrStreamBusyGroup {
+		t.Errorf("XGROUPCREATE of an existing group err = %v", err)
+	}
+	if _, err := s.XREADGROUP("nogroup", "c", StreamID{}, true, 0, false); err != ErrStreamNoGroup {
+		t.Errorf("XREADGROUP of a missing group err = %v", err)
+	}
+
+	got, _ := s.XREADGROUP("g", "alice", StreamID{}, true, 2, false)
+	if idsOf(got) != "[3-1 4-1]" {
+		t.Errorf("alice reads %v", idsOf(got))
+	}
+	*now = now.Add(time.Second)
+	got, _ = s.XREADGROUP("g", "bob", StreamID{}, true, 0, false)
+	if idsOf(got) != "[5-1]" {
+		t.Errorf("bob reads %v", idsOf(got))
+	}
+	if got, _ = s.XREADGROUP("g", "bob", StreamID{}, true, 0, false); len(got) != 0 {
+		t.Errorf("bob reads again %v", idsOf(got))
+	}
+
+	// the history of a consumer is its pending entries
+	got, _ = s.XREADGROUP("g", "alice", StreamID{3, 1}, false, 0, false)
+	if idsOf(got) != "[4-1]" {
+		t.Errorf("alice history = %v", idsOf(got))
+	}
+
+	summary, _ := s.XPENDING("g")
+	want := StreamPendingSummary{3, StreamID{3, 1}, StreamID{5, 1}, []StreamConsumerPending{{"alice", 2}, {"bob", 1}}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("XPENDING = %+v, want %+v", summary, want)
+	}
+
+	*now = now.Add(time.Second)
+	pending, _ := s.XPENDINGRANGE("g", 0, StreamID{}, streamMax, 10, "")
+	wantPending := []StreamPendingEntry{
+		{StreamID{3, 1}, "alice", 2 * time.Second, 1},
+		{StreamID{4, 1}, "alice", time.Second, 2},
+		{StreamID{5, 1}, "bob", time.Second, 1},
+	}
+	if !reflect.DeepEqual(pending, wantPending) {
+		t.Errorf("XPENDINGRANGE = %+v, want %+v", pending, wantPending)
+	}
+	if pending, _ = s.XPENDINGRANGE("g", 2*time.Second, StreamID{}, streamMax, 10, ""); len(pending) != 1 {
+		t.Errorf("XPENDINGRANGE IDLE = %+v", pending)
+	}
+	if pending, _ = s.XPENDINGRANGE("g", 0, StreamID{}, streamMax, 10, "bob"); len(pending) != 1 || pending[0].ID != (StreamID{5, 1}) {
+		t.Errorf("XPENDINGRANGE of bob = %+v", pending)
+	}
+
+	if n := s.XACK("g", StreamID{3, 1}, StreamID{3, 1}, StreamID{1, 1}); n != 1 {
+		t.Errorf("XACK = %d", n)
+	}
+	if summary, _ = s.XPENDING("g"); summary.Count != 2 || summary.Consumers[0] != (StreamConsumerPending{"alice", 1}) {
+		t.Errorf("XPENDING after XACK = %+v", summary)
+	}
+
+	// a deleted pending entry is replied without fields
+	s.XDEL(StreamID{4, 1})
+	got, _ = s.XREADGROUP("g", "alice", StreamID{}, false, 0, false)
+	if len(got) != 1 || got[0].ID != (StreamID{4, 1}) || got[0].Fields != nil {
+		t.Errorf("history of a deleted entry = %+v", got)
+	}
+
+	// NOACK entries are not pending
+	s.XADD("6-1", "f", "6")
+	s.XREADGROUP("g", "bob", StreamID{}, true, 0, true)
+	if summary, _ = s.XPENDING("g"); summary.Count != 2 {
+		t.Errorf("XPENDING after NOACK = %+v", summary)
+	}
+}
+
+func TestStream_Claim(t *testing.T) {
+	now := withClock(t)
+	s := newStream(t, 5)
+	s.XGROUPCREATE("g", StreamID{})
+	s.XREADGROUP("g", "alice", StreamID{}, true, 4, false)
+	*now = now.Add(time.Minute)
+
+	// too young to claim
+	got, _ := s.XCLAIM("g", "bob", 2*time.Minute, []StreamID{{1, 1}}, StreamClaimArgs{})
+	if len(got) != 0 {
+		t.Errorf("XCLAIM of young entries = %v", idsOf(got))
+	}
+	got, _ = s.XCLAIM("g", "bob", time.Minute, []StreamID{{1, 1}, {2, 1}, {5, 1}}, StreamClaimArgs{})
+	if idsOf(got) != "[1-1 2-1]" || got[0].Fields[1] != "1" {
+		t.Errorf("XCLAIM = %v", got)
+	}
+	pending, _ := s.XPENDINGRANGE("g", 0, StreamID{}, streamMax, 10, "bob")
+	if len(pending) != 2 || pending[0].DeliveryCount != 2 || pending[0].Idle != 0 {
+		t.Errorf("bob pending = %+v", pending)
+	}
+
+	// FORCE claims an entry not pending, JUSTID leaves the delivery count
+	got, _ = s.XCLAIM("g", "bob", 0, []StreamID{{5, 1}, {9, 1}, {3, 1}}, StreamClaimArgs{Force: true, JustID: true, Idle: time.Hour, RetryCount: 7})
+	if idsOf(got) != "[5-1 3-1]" || got[0].Fields != nil {
+		t.Errorf("XCLAIM FORCE JUSTID = %v", got)
+	}
+	pending, _ = s.XPENDINGRANGE("g", time.Hour, StreamID{}, streamMax, 10, "bob")
+	if idsOf([]StreamEntry{{ID: pending[0].ID}, {ID: pending[1].ID}}) != "[3-1 5-1]" || pending[1].DeliveryCount != 7 {
+		t.Errorf("bob pending after FORCE = %+v", pending)
+	}
+
+	// XAUTOCLAIM scans from start, and acknowledges the deleted entries
+	s.XDEL(StreamID{4, 1})
+	*now = now.Add(time.Minute)
+	next, claimed, deleted, _ := s.XAUTOCLAIM("g", "carol", time.Minute, StreamID{}, 2, false)
+	if next != (StreamID{3, 1}) || idsOf(claimed) != "[1-1 2-1]" || len(deleted) != 0 {
+		t.Errorf("XAUTOCLAIM = %v, %v, %v", next, idsOf(claimed), deleted)
+	}
+	next, claimed, deleted, _ = s.XAUTOCLAIM("g", "carol", time.Minute, next, 10, true)
+	if next != (StreamID{}) || idsOf(claimed) != "[3-1 5-1]" || fmt.Sprint(deleted) != "[4-1]" {
+		t.Errorf("XAUTOCLAIM = %v, %v, %v", next, idsOf(claimed), deleted)
+	}
+	if summary, _ := s.XPENDING("g"); summary.Count != 4 || len(summary.Consumers) != 1 {
+		t.Errorf("XPENDING after XAUTOCLAIM = %+v", summary)
+	}
+}
@@ -1,4 +1,199 @@
 package object
 
-// String is a wrapper
-type String string
\ No newline at end of file
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/viktorxhzj/mykv/util"
+)
+
+//
+// A string object is encoded as:
+// OBJ_ENCODING_INT:    an int64, for values that are the canonical form of an integer;
+// OBJ_ENCODING_EMBSTR: an immutable string, for short values;
+// OBJ_ENCODING_RAW:    a []byte, for long values and for values that are modified in place.
+//
+// Integers from 0 to OBJ_SHARED_INTEGERS-1 are shared objects,
+// which must never be modified. Use UnshareString before modifying a string object.
+//
+
+const (
+	OBJ_SHARED_INTEGERS            = 10000
+	OBJ_ENCODING_EMBSTR_SIZE_LIMIT = 44
+	OBJ_STRING_INT_MAX_LENGTH      = 20
+	OBJ_STRING_MAX_SIZE            = 512 * 1024 * 1024
+)
+
+var sharedIntegers [OBJ_SHARED_INTEGERS]*ValueObject
+
+func init() {
+	for i := range sharedIntegers {
+		o := new(ValueObject)
+		o.SetType(OBJ_STRING, OBJ_ENCODING_INT)
+		o.Structure = int64(i)
+		sharedIntegers[i] = o
+	}
+}
+
+// NewStringObject creates a string object holding a copy of b,
+// which is EMBSTR encoded if b is short enough and RAW encoded otherwise.
+func NewStringObject(b []byte) *ValueObject {
+	if len(b) <= OBJ_ENCODING_EMBSTR_SIZE_LIMIT {
+		return newEmbeddedStringObject(string(b))
+	}
+	return NewRawStringObject(b)
+}
+
+// NewRawStringObject creates a RAW encoded string object holding a copy of b.
+func NewRawStringObject(b []byte) *ValueObject {
+	o := new(ValueObject)
+	o.SetType(OBJ_STRING, OBJ_ENCODING_RAW)
+	o.Structure = append(make([]byte, 0, len(b)), b...)
+	return o
+}
+
+func newEmbeddedStringObject(s string) *ValueObject {
+	o := new(ValueObject)
+	o.SetType(OBJ_STRING, OBJ_ENCODING_EMBSTR)
+	o.Structure = s
+	return o
+}
+
+// NewStringObjectFromInt64 creates an INT encoded string object,
+// which is a shared object if v is small enough.
+func NewStringObjectFromInt64(v int64) *ValueObject {
+	if v >= 0 && v < OBJ_SHARED_INTEGERS {
+		return sharedIntegers[v]
+	}
+	o := new(ValueObject)
+	o.SetType(OBJ_STRING, OBJ_ENCODING_INT)
+	o.Structure = v
+	return o
+}
+
+// NewEncodedStringObject creates a string object holding a copy of b
+// in the most compact encoding, as Redis does for the value of SET.
+func NewEncodedStringObject(b []byte) *ValueObject {
+	if len(b) <= OBJ_STRING_INT_MAX_LENGTH {
+		if v, ok := util.StringToInt64(b); ok {
+			return NewStringObjectFromInt64(v)
+		}
+	}
+	return NewStringObject(b)
+}
+
+// IsShared reports whether the object is shared, and so must not be modified.
+func (o *ValueObject) IsShared() bool {
+	if v, ok := o.Structure.(int64); ok && v >= 0 && v < OBJ_SHARED_INTEGERS {
+		return sharedIntegers[v] == o
+	}
+	return false
+}
+
+// StringBytes returns the value of a string object.
+// The result must not be modified, as it may be the object's own buffer,
+// unless the object is one returned by UnshareString.
+func (o *ValueObject) StringBytes() []byte {
+	switch v := o.Structure.(type) {
+	case int64:
+		return strconv.AppendInt(nil, v, 10)
+	case string:
+		return []byte(v)
+	default:
+		return v.([]byte)
+	}
+}
+
+// StringLen returns the length of the value of a string object.
+func (o *ValueObject) StringLen() int {
+	switch v := o.Structure.(type) {
+	case int64:
+		return len(strconv.FormatInt(v, 10))
+	case string:
+		return len(v)
+	default:
+		return len(v.([]byte))
+	}
+}
+
+// UnshareString returns a string object that can be modified in place:
+// o itself if it is RAW encoded, or else a RAW encoded copy of it.
+func UnshareString(o *ValueObject) *ValueObject {
+	if _, et := o.GetType(); et == OBJ_ENCODING_RAW {
+		return o
+	}
+	return NewRawStringObject(o.StringBytes())
+}
+
+// AppendString appends b to a RAW encoded string object,
+// and returns the new length.
+func (o *ValueObject) AppendString(b []byte) int {
+	raw := append(o.Structure.([]byte), b...)
+	o.Structure = raw
+	return len(raw)
+}
+
+// SetStringRange overwrites a RAW encoded string object with b starting at offset,
+// padding it with zero bytes if it is shorter than offset,
+// and returns the new length.
+func (o *ValueObject) SetStringRange(offset int, b []byte) int {
+	o.GrowString(offset + len(b))
+	raw := o.Structure.([]byte)
+	copy(raw[offset:], b)
+	return len(raw)
+}
+
+// GrowString pads a RAW encoded string object with zero bytes up to n bytes,
+// and returns the new length.
+func (o *ValueObject) GrowString(n int) int {
+	raw := o.Structure.([]byte)
+	if n > len(raw) {
+		raw = append(raw, make([]byte, n-len(raw))...)
+		o.Structure = raw
+	}
+	return len(raw)
+}
+
+// StringRange returns the bytes from start to end (both inclusive) of a string object,
+// where negative indices count from the end, as GETRANGE does.
+func (o *ValueObject) StringRange(start, end int) []byte {
+	b := o.StringBytes()
+	n := len(b)
+	if start < 0 && end < 0 && start > end {
+		return []byte{}
+	}
+	if start < 0 {
+		start += n
+	}
+	if end < 0 {
+		end += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 {
+		end = 0
+	}
+	if end >= n {
+		end = n - 1
+	}
+	if n == 0 || start > end {
+		return []byte{}
+	}
+	return b[start : end+1]
+}
+
+// StringInt64 returns the value of a string object as an int64,
+// and false if the value is not the canonical form of an integer.
+func (o *ValueObject) StringInt64() (int64, bool) {
+	if v, ok := o.Structure.(int64); ok {
+		return v, true
+	}
+	return util.StringToInt64(o.StringBytes())
+}
+
+// StringLongDouble returns the value of a string object as a long double,
+// and false if the value is not a valid float.
+func (o *ValueObject) StringLongDouble() (*big.Float, bool) {
+	return util.StringToLongDouble(o.StringBytes())
+}
@@ -0,0 +1,89 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStringObject_Encoding(t *testing.T) {
+	tests := []struct {
+		value    string
+		encoding uint8
+	}{
+		{"0", OBJ_ENCODING_INT},
+		{"9999", OBJ_ENCODING_INT},
+		{"-9223372036854775808", OBJ_ENCODING_INT},
+		{"9223372036854775808", OBJ_ENCODING_EMBSTR},
+		{"007", OBJ_ENCODING_EMBSTR},
+		{"+7", OBJ_ENCODING_EMBSTR},
+		{"-0", OBJ_ENCODING_EMBSTR},
+		{"", OBJ_ENCODING_EMBSTR},
+		{strings.Repeat("a", OBJ_ENCODING_EMBSTR_SIZE_LIMIT), OBJ_ENCODING_EMBSTR},
+		{strings.Repeat("a", OBJ_ENCODING_EMBSTR_SIZE_LIMIT+1), OBJ_ENCODING_RAW},
+	}
+	for _, tt := range tests {
+		o := NewEncodedStringObject([]byte(tt.value))
+		if ot, et := o.GetType(); ot != OBJ_STRING || et != tt.encoding {
+			t.Errorf("%q is encoded as %d, want %d", tt.value, et, tt.encoding)
+		}
+		if got := string(o.StringBytes()); got != tt.value || o.StringLen() != len(tt.value) {
+			t.Errorf("%q is decoded as %q", tt.value, got)
+		}
+	}
+
+	if a, b := NewEncodedStringObject([]byte("42")), NewStringObjectFromInt64(42); a != b || !a.IsShared() {
+		t.Errorf("small integers should be shared")
+	}
+	if o := NewStringObjectFromInt64(10000); o.IsShared() {
+		t.Errorf("10000 should not be shared")
+	}
+}
+
+func TestStringObject_Modify(t *testing.T) {
+	shared := NewStringObjectFromInt64(12)
+	o := UnshareString(shared)
+	if o == shared || o.IsShared() {
+		t.Fatalf("UnshareString returns a shared object")
+	}
+	if UnshareString(o) != o {
+		t.Errorf("UnshareString copies a RAW object")
+	}
+	if n := o.AppendString([]byte("\x00ab")); n != 5 {
+		t.Errorf("AppendString = %d", n)
+	}
+	if n := o.SetStringRange(7, []byte("z")); n != 8 {
+		t.Errorf("SetStringRange = %d", n)
+	}
+	if got := o.StringBytes(); !bytes.Equal(got, []byte("12\x00ab\x00\x00z")) {
+		t.Errorf("value = %q", got)
+	}
+	if shared.Structure.(int64) != 12 {
+		t.Errorf("the shared object is modified")
+	}
+}
+
+func TestStringObject_Range(t *testing.T) {
+	o := NewStringObject([]byte("This is a string"))
+	tests := []struct {
+		start, end int
+		want       string
+	}{
+		{0, 3, "This"},
+		{-3, -1, "ing"},
+		{0, -1, "This is a string"},
+		{10, 100, "string"},
+		{-100, 3, "This"},
+		{5, 3, ""},
+		{-1, -5, ""},
+		{100, 200, ""},
+	}
+	for _, tt := range tests {
+		if got := string(o.StringRange(tt.start, tt.end)); got != tt.want {
+			t.Errorf("StringRange(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+	if got := NewStringObject(nil).StringRange(0, -1); len(got) != 0 {
+		t.Errorf("StringRange of empty string = %q", got)
+	}
+}
@@ -1,5 +1,7 @@
 package util
 
+import "math/bits"
+
 //
 // bytes to uint
 //
@@ -117,3 +119,54 @@ func I16ToB(n int16, res []byte, offset int) {
 func I8ToB(n int8, res []byte, offset int) {
 	res[offset] = byte(n)
 }
+
+//
+// bits, where bit 0 is the most significant bit of b[0]
+//
+
+// GetBit returns the bit at offset, or 0 if offset is past the end of b.
+func GetBit(b []byte, offset int64) int {
+	idx := offset >> 3
+	if idx >= int64(len(b)) {
+		return 0
+	}
+	return int(b[idx]>>(7-uint(offset&7))) & 1
+}
+
+// SetBit sets the bit at offset to bit, and returns the old bit.
+func SetBit(b []byte, offset int64, bit int) int {
+	idx := offset >> 3
+	shift := 7 - uint(offset&7)
+	old := int(b[idx]>>shift) & 1
+	b[idx] &^= 1 << shift
+	b[idx] |= byte(bit&1) << shift
+	return old
+}
+
+// PopCount returns the number of set bits in b.
+func PopCount(b []byte) (count int) {
+	for len(b) >= 8 {
+		count += bits.OnesCount64(BToUI64(b, 0))
+		b = b[8:]
+	}
+	for _, c := range b {
+		count += bits.OnesCount8(c)
+	}
+	return
+}
+
+// GetBits returns the n bits (n <= 64) starting at offset as an unsigned integer,
+// where the bits past the end of b are 0.
+func GetBits(b []byte, offset int64, n int) (res uint64) {
+	for i := 0; i < n; i++ {
+		res = res<<1 | uint64(GetBit(b, offset+int64(i)))
+	}
+	return
+}
+
+// SetBits sets the n bits (n <= 64) starting at offset to the lowest n bits of v.
+func SetBits(b []byte, offset int64, n int, v uint64) {
+	for i := 0; i < n; i++ {
+		SetBit(b, offset+int64(i), int(v>>uint(n-1-i))&1)
+	}
+}
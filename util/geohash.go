@@ -0,0 +1,273 @@
+package util
+
+import (
+	"math"
+)
+
+//
+// Geohash, as Redis uses to index positions in sorted sets.
+//
+// The longitude and the latitude are each divided into 2^step ranges,
+// and the indices of the ranges are interleaved into the bits of a hash,
+// the latitude in the even bits and the longitude in the odd bits,
+// so that the positions of a cell share the prefix of its hash.
+// At GEO_STEP_MAX, the hash has 52 bits, which a float64 score holds exactly.
+//
+// The latitude is limited as in EPSG:3857, the Web Mercator projection,
+// except for the standard geohash strings, which cover the whole globe.
+//
+
+const (
+	GEO_STEP_MAX = 26
+
+	GEO_LAT_MIN  = -85.05112878
+	GEO_LAT_MAX  = 85.05112878
+	GEO_LONG_MIN = -180
+	GEO_LONG_MAX = 180
+
+	// EarthRadiusInMeters is the radius of the earth of Redis's distances.
+	EarthRadiusInMeters = 6372797.560856
+	mercatorMax         = 20037726.37
+)
+
+// GeoHashBits is a geohash of step*2 bits.
+type GeoHashBits struct {
+	Bits uint64
+	Step uint8
+}
+
+// GeoHashRange is a range of longitudes or latitudes.
+type GeoHashRange struct {
+	Min, Max float64
+}
+
+// GeoHashArea is the cell of a geohash.
+type GeoHashArea struct {
+	Hash      GeoHashBits
+	Longitude GeoHashRange
+	Latitude  GeoHashRange
+}
+
+// GeoHashNeighbors are the cells around a cell.
+type GeoHashNeighbors struct {
+	North, East, West, South                   GeoHashBits
+	NorthEast, SouthEast, NorthWest, SouthWest GeoHashBits
+}
+
+var (
+	geoLongRange = GeoHashRange{GEO_LONG_MIN, GEO_LONG_MAX}
+	geoLatRange  = GeoHashRange{GEO_LAT_MIN, GEO_LAT_MAX}
+)
+
+// IsZero reports whether the hash is zero, which marks an excluded neighbor.
+func (hash GeoHashBits) IsZero() bool {
+	return hash.Bits == 0 && hash.Step == 0
+}
+
+// Align52Bits returns the hash shifted to 52 bits, the least score of the cell.
+func (hash GeoHashBits) Align52Bits() uint64 {
+	return hash.Bits << (GEO_STEP_MAX*2 - uint(hash.Step)*2)
+}
+
+// GeoHashEncode returns the hash of the position within the ranges,
+// and false if the position is out of them.
+func GeoHashEncode(longRange, latRange GeoHashRange, longitude, latitude float64, step uint8) (GeoHashBits, bool) {
+	if step == 0 || step > 32 ||
+		longitude < longRange.Min || longitude > longRange.Max || latitude < latRange.Min || latitude > latRange.Max {
+		return GeoHashBits{}, false
+	}
+	latOffset := (latitude - latRange.Min) / (latRange.Max - latRange.Min)
+	longOffset := (longitude - longRange.Min) / (longRange.Max - longRange.Min)
+	latOffset *= float64(uint64(1) << step)
+	longOffset *= float64(uint64(1) << step)
+	return GeoHashBits{interleave64(uint32(latOffset), uint32(longOffset)), step}, true
+}
+
+// GeoHashEncodeWGS84 returns the hash of the position within the Web Mercator limits.
+func GeoHashEncodeWGS84(longitude, latitude float64, step uint8) (GeoHashBits, bool) {
+	return GeoHashEncode(geoLongRange, geoLatRange, longitude, latitude, step)
+}
+
+// GeoHashDecode returns the cell of the hash within the ranges.
+func GeoHashDecode(longRange, latRange GeoHashRange, hash GeoHashBits) GeoHashArea {
+	lat, long := deinterleave64(hash.Bits)
+	latScale := latRange.Max - latRange.Min
+	longScale := longRange.Max - longRange.Min
+	cells := float64(uint64(1) << hash.Step)
+	return GeoHashArea{
+		Hash: hash,
+		Latitude: GeoHashRange{
+			latRange.Min + float64(lat)/cells*latScale,
+			latRange.Min + float64(lat+1)/cells*latScale,
+		},
+		Longitude: GeoHashRange{
+			longRange.Min + float64(long)/cells*longScale,
+			longRange.Min + float64(long+1)/cells*longScale,
+		},
+	}
+}
+
+// GeoHashDecodeWGS84 returns the cell of the hash within the Web Mercator limits.
+func GeoHashDecodeWGS84(hash GeoHashBits) GeoHashArea {
+	return GeoHashDecode(geoLongRange, geoLatRange, hash)
+}
+
+// Center returns the position at the center of the cell, within the Web Mercator limits.
+func (area GeoHashArea) Center() (longitude, latitude float64) {
+	longitude = math.Max(GEO_LONG_MIN, math.Min(GEO_LONG_MAX, (area.Longitude.Min+area.Longitude.Max)/2))
+	latitude = math.Max(GEO_LAT_MIN, math.Min(GEO_LAT_MAX, (area.Latitude.Min+area.Latitude.Max)/2))
+	return
+}
+
+// Neighbors returns the 8 cells around the cell of the hash, of the same step.
+func (hash GeoHashBits) Neighbors() GeoHashNeighbors {
+	var n GeoHashNeighbors
+	n.East = hash.move(1, 0)
+	n.West = hash.move(-1, 0)
+	n.South = hash.move(0, -1)
+	n.North = hash.move(0, 1)
+	n.SouthWest = hash.move(-1, -1)
+	n.SouthEast = hash.move(1, -1)
+	n.NorthWest = hash.move(-1, 1)
+	n.NorthEast = hash.move(1, 1)
+	return n
+}
+
+// move returns the hash of the cell dx cells east and dy cells north, wrapping around.
+func (hash GeoHashBits) move(dx, dy int) GeoHashBits {
+	const evenBits, oddBits = 0x5555555555555555, 0xaaaaaaaaaaaaaaaa
+	shift := 64 - uint(hash.Step)*2
+	x, y := hash.Bits&oddBits, hash.Bits&evenBits
+
+	// adding 1 to the bits of one coordinate carries through the bits of the other set to 1
+	if dx != 0 {
+		zz := uint64(evenBits) >> shift
+		if dx > 0 {
+			x += zz + 1
+		} else {
+			x = (x | zz) - (zz + 1)
+		}
+		x &= uint64(oddBits) >> shift
+	}
+	if dy != 0 {
+		zz := uint64(oddBits) >> shift
+		if dy > 0 {
+			y += zz + 1
+		} else {
+			y = (y | zz) - (zz + 1)
+		}
+		y &= uint64(evenBits) >> shift
+	}
+	return GeoHashBits{x | y, hash.Step}
+}
+
+// GeoHashDistance returns the distance in meters between two positions,
+// by the haversine formula.
+func GeoHashDistance(long1, lat1, long2, lat2 float64) float64 {
+	lat1r, long1r := degRad(lat1), degRad(long1)
+	lat2r, long2r := degRad(lat2), degRad(long2)
+	u := math.Sin((lat2r - lat1r) / 2)
+	v := math.Sin((long2r - long1r) / 2)
+	return 2 * EarthRadiusInMeters * math.Asin(math.Sqrt(u*u+math.Cos(lat1r)*math.Cos(lat2r)*v*v))
+}
+
+// GeoHashLatDistance returns the distance in meters between two latitudes on a meridian.
+func GeoHashLatDistance(lat1, lat2 float64) float64 {
+	return EarthRadiusInMeters * math.Abs(degRad(lat2)-degRad(lat1))
+}
+
+// GeoHashEstimateStepsByRadius returns the step of the cells,
+// such that the cell of a position and its neighbors cover the radius in meters around it.
+func GeoHashEstimateStepsByRadius(radius, latitude float64) uint8 {
+	if radius == 0 {
+		return GEO_STEP_MAX
+	}
+	step := 1
+	for radius < mercatorMax {
+		radius *= 2
+		step++
+	}
+	// make sure the range is included in most of the base cases
+	step -= 2
+
+	// the cells are narrower near the poles
+	if latitude > 66 || latitude < -66 {
+		step--
+		if latitude > 80 || latitude < -80 {
+			step--
+		}
+	}
+	if step < 1 {
+		step = 1
+	}
+	if step > GEO_STEP_MAX {
+		step = GEO_STEP_MAX
+	}
+	return uint8(step)
+}
+
+// GeoHashBoundingBox returns the bounds, min longitude, min latitude, max longitude and max latitude,
+// of the box centered on the position that extends width meters east and west,
+// and height meters north and south.
+func GeoHashBoundingBox(longitude, latitude, width, height float64) [4]float64 {
+	latr := degRad(latitude)
+	latDelta := radDeg(height / EarthRadiusInMeters)
+	longDeltaTop := radDeg(width / EarthRadiusInMeters / math.Cos(latr+height/EarthRadiusInMeters))
+	longDeltaBottom := radDeg(width / EarthRadiusInMeters / math.Cos(latr-height/EarthRadiusInMeters))
+
+	// the box is wider on the side nearer the equator
+	longDelta := longDeltaTop
+	if latitude < 0 {
+		longDelta = longDeltaBottom
+	}
+	return [4]float64{longitude - longDelta, latitude - latDelta, longitude + longDelta, latitude + latDelta}
+}
+
+// GeoHashString returns the standard 11-character geohash string of the position,
+// whose latitude is not limited by Web Mercator.
+func GeoHashString(longitude, latitude float64) string {
+	const alphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+	hash, _ := GeoHashEncode(geoLongRange, GeoHashRange{-90, 90}, longitude, latitude, GEO_STEP_MAX)
+	buf := make([]byte, 11)
+	for i := range buf {
+		// the 52 bits make 10 characters and 2 bits, the last character is padded with 0
+		idx := 0
+		if i < 10 {
+			idx = int(hash.Bits >> (52 - uint(i+1)*5) & 0x1f)
+		}
+		buf[i] = alphabet[idx]
+	}
+	return string(buf)
+}
+
+func degRad(deg float64) float64 {
+	return deg * (math.Pi / 180)
+}
+
+func radDeg(rad float64) float64 {
+	return rad / (math.Pi / 180)
+}
+
+// interleave64 interleaves the bits of x into the even bits and the bits of y into the odd bits.
+func interleave64(xlo, ylo uint32) uint64 {
+	b := [...]uint64{0x5555555555555555, 0x3333333333333333, 0x0F0F0F0F0F0F0F0F, 0x00FF00FF00FF00FF, 0x0000FFFF0000FFFF}
+	s := [...]uint{1, 2, 4, 8, 16}
+	x, y := uint64(xlo), uint64(ylo)
+	for i := 4; i >= 0; i-- {
+		x = (x | x<<s[i]) & b[i]
+		y = (y | y<<s[i]) & b[i]
+	}
+	return x | y<<1
+}
+
+// deinterleave64 is the inverse of interleave64.
+func deinterleave64(interleaved uint64) (x, y uint32) {
+	b := [...]uint64{0x5555555555555555, 0x3333333333333333, 0x0F0F0F0F0F0F0F0F, 0x00FF00FF00FF00FF, 0x0000FFFF0000FFFF, 0x00000000FFFFFFFF}
+	s := [...]uint{0, 1, 2, 4, 8, 16}
+	xx, yy := interleaved, interleaved>>1
+	for i := 0; i < 6; i++ {
+		xx = (xx | xx>>s[i]) & b[i]
+		yy = (yy | yy>>s[i]) & b[i]
+	}
+	return uint32(xx), uint32(yy)
+}
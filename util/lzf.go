@@ -0,0 +1,186 @@
+package util
+
+//
+// LZF compression, a port of liblzf by Marc Alexander Lehmann,
+// which is what Redis uses to compress quicklist nodes.
+//
+// The compressed data is a sequence of chunks:
+// |000L LLLL|...                         literal run of L+1 bytes
+// |LLLo oooo|oooo oooo|                  back reference of L+2 bytes, L < 7
+// |111o oooo|LLLL LLLL|oooo oooo|        back reference of L+9 bytes
+// where o is the offset to copy from, counting backward from the current position.
+//
+
+const (
+	lzfHLog   = 16
+	lzfHSize  = 1 << lzfHLog
+	lzfMaxLit = 1 << 5
+	lzfMaxOff = 1 << 13
+	lzfMaxRef = (1 << 8) + (1 << 3)
+)
+
+func lzfHash(a, b, c byte) int {
+	h := uint32(a)<<16 | uint32(b)<<8 | uint32(c)
+	return int(((h >> (3*8 - lzfHLog)) - h*5) & (lzfHSize - 1))
+}
+
+// LZFCompress compresses in into out, and returns the number of bytes written.
+// If the compressed data does not fit in out, it returns 0.
+func LZFCompress(in, out []byte) int {
+	inLen, outLen := len(in), len(out)
+	if inLen == 0 || outLen == 0 {
+		return 0
+	}
+
+	// positions (+1) of the last occurrences of 3-byte sequences
+	htab := make([]int, lzfHSize)
+
+	// out[0] is reserved for the length of the first literal run
+	ip, op, lit := 0, 1, 0
+
+	for ip < inLen-2 {
+		h := lzfHash(in[ip], in[ip+1], in[ip+2])
+		ref := htab[h] - 1
+		htab[h] = ip + 1
+
+		if off := ip - ref - 1; ref >= 0 && off < lzfMaxOff &&
+			in[ref] == in[ip] && in[ref+1] == in[ip+1] && in[ref+2] == in[ip+2] {
+
+			// a back reference takes 3 bytes at most, plus 1 for the next literal run
+			if op+3+1 >= outLen {
+				return 0
+			}
+
+			// stop the literal run
+			out[op-lit-1] = byte(lit - 1)
+			if lit == 0 {
+				op--
+			}
+
+			length := 2
+			maxLen := inLen - ip - length
+			if maxLen > lzfMaxRef {
+				maxLen = lzfMaxRef
+			}
+			for {
+				length++
+				if length >= maxLen || in[ref+length] != in[ip+length] {
+					break
+				}
+			}
+
+			length -= 2
+			ip++
+
+			if length < 7 {
+				out[op] = byte(off>>8 + length<<5)
+				op++
+			} else {
+				out[op] = byte(off>>8 + 7<<5)
+				out[op+1] = byte(length - 7)
+				op += 2
+			}
+			out[op] = byte(off)
+			op++
+
+			// start a new literal run
+			lit = 0
+			op++
+
+			ip += length + 1
+			continue
+		}
+
+		if op >= outLen {
+			return 0
+		}
+		lit++
+		out[op] = in[ip]
+		op++
+		ip++
+
+		if lit == lzfMaxLit {
+			out[op-lit-1] = byte(lit - 1)
+			lit = 0
+			op++
+		}
+	}
+
+	// the last bytes are too short to be referenced
+	for ip < inLen {
+		if op >= outLen {
+			return 0
+		}
+		lit++
+		out[op] = in[ip]
+		op++
+		ip++
+
+		if lit == lzfMaxLit {
+			out[op-lit-1] = byte(lit - 1)
+			lit = 0
+			op++
+		}
+	}
+
+	// stop the last literal run
+	if lit == 0 {
+		op--
+	} else {
+		out[op-lit-1] = byte(lit - 1)
+	}
+	return op
+}
+
+// LZFDecompress decompresses in into out, and returns the number of bytes written.
+// If the decompressed data does not fit in out, or in is corrupted, it returns 0.
+func LZFDecompress(in, out []byte) int {
+	inLen, outLen := len(in), len(out)
+	ip, op := 0, 0
+
+	for ip < inLen {
+		ctrl := int(in[ip])
+		ip++
+
+		if ctrl < lzfMaxLit {
+			// literal run
+			ctrl++
+			if op+ctrl > outLen || ip+ctrl > inLen {
+				return 0
+			}
+			copy(out[op:], in[ip:ip+ctrl])
+			op += ctrl
+			ip += ctrl
+			continue
+		}
+
+		// back reference
+		length := ctrl >> 5
+		ref := op - (ctrl&0x1F)<<8 - 1
+
+		if ip >= inLen {
+			return 0
+		}
+		if length == 7 {
+			length += int(in[ip])
+			ip++
+			if ip >= inLen {
+				return 0
+			}
+		}
+		ref -= int(in[ip])
+		ip++
+		length += 2
+
+		if op+length > outLen || ref < 0 {
+			return 0
+		}
+		// byte by byte, as the reference may overlap with the output
+		for i := 0; i < length; i++ {
+			out[op] = out[ref]
+			op++
+			ref++
+		}
+	}
+	return op
+}
@@ -0,0 +1,115 @@
+package util
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func lzfRoundTrip(t *testing.T, name string, in []byte) int {
+	t.Helper()
+	out := make([]byte, len(in)+len(in)/32+16)
+	n := LZFCompress(in, out)
+	if n == 0 {
+		t.Fatalf("%s: LZFCompress of %d bytes = 0", name, len(in))
+	}
+	dec := make([]byte, len(in))
+	if m := LZFDecompress(out[:n], dec); m != len(in) || !bytes.Equal(dec, in) {
+		t.Fatalf("%s: LZFDecompress = %d bytes, want %d", name, m, len(in))
+	}
+	return n
+}
+
+func TestLZF_RoundTrip(t *testing.T) {
+	if n := LZFCompress(nil, make([]byte, 16)); n != 0 {
+		t.Errorf("LZFCompress of empty input = %d", n)
+	}
+	if n := LZFDecompress(nil, make([]byte, 16)); n != 0 {
+		t.Errorf("LZFDecompress of empty input = %d", n)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	random := make([]byte, 4096)
+	r.Read(random)
+	if n := lzfRoundTrip(t, "random", random); n <= len(random) {
+		t.Errorf("random input compressed to %d bytes", n)
+	}
+	// incompressible input does not fit in a buffer of its own size
+	if n := LZFCompress(random, make([]byte, len(random))); n != 0 {
+		t.Errorf("LZFCompress of random input into its size = %d", n)
+	}
+
+	repetitive := bytes.Repeat([]byte("abcabcab"), 1024)
+	if n := lzfRoundTrip(t, "repetitive", repetitive); n > len(repetitive)/20 {
+		t.Errorf("repetitive input compressed to %d bytes", n)
+	}
+	zeros := make([]byte, 100000)
+	lzfRoundTrip(t, "zeros", zeros)
+
+	for _, size := range []int{1, 2, 3, 31, 32, 33, 264, 265} {
+		in := make([]byte, size)
+		for i := range in {
+			in[i] = byte(r.Intn(3))
+		}
+		lzfRoundTrip(t, "short", in)
+	}
+}
+
+func TestLZF_Bounds(t *testing.T) {
+	in := bytes.Repeat([]byte("hello, world! "), 100)
+	out := make([]byte, len(in))
+	n := LZFCompress(in, out)
+	if n == 0 {
+		t.Fatalf("LZFCompress = 0")
+	}
+	compressed := out[:n]
+
+	// any output buffer too small returns 0
+	for size := 0; size < n; size++ {
+		if m := LZFCompress(in, make([]byte, size)); m != 0 {
+			t.Fatalf("LZFCompress into %d bytes = %d", size, m)
+		}
+	}
+	for size := 0; size < len(in); size++ {
+		if m := LZFDecompress(compressed, make([]byte, size)); m != 0 {
+			t.Fatalf("LZFDecompress into %d bytes = %d", size, m)
+		}
+	}
+
+	// a truncated input never indexes out of range, and a corrupted one returns 0
+	for size := 1; size < n; size++ {
+		LZFDecompress(compressed[:size], make([]byte, len(in)))
+	}
+	if m := LZFDecompress([]byte{0x20, 0x00}, make([]byte, 16)); m != 0 {
+		t.Errorf("LZFDecompress of a reference before the start = %d", m)
+	}
+	if m := LZFDecompress([]byte{0x05, 'a'}, make([]byte, 16)); m != 0 {
+		t.Errorf("LZFDecompress of a truncated literal run = %d", m)
+	}
+	if m := LZFDecompress([]byte{0x00, 'a', 0xE0}, make([]byte, 16)); m != 0 {
+		t.Errorf("LZFDecompress of a truncated long reference = %d", m)
+	}
+
+	// random input never indexes out of range
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		corrupt := make([]byte, r.Intn(64))
+		r.Read(corrupt)
+		LZFDecompress(corrupt, make([]byte, r.Intn(256)))
+	}
+
+	// compression into any buffer either fails or round trips
+	for i := 0; i < 2000; i++ {
+		in := make([]byte, 1+r.Intn(300))
+		for j := range in {
+			in[j] = byte(r.Intn(1 + i%8))
+		}
+		out := make([]byte, r.Intn(len(in)+16))
+		if n := LZFCompress(in, out); n > 0 {
+			dec := make([]byte, len(in))
+			if m := LZFDecompress(out[:n], dec); m != len(in) || !bytes.Equal(dec, in) {
+				t.Fatalf("round trip through %d bytes = %d bytes", len(out), m)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+//
+// strict conversions between strings and numbers,
+// where a string converts only if converting the number back gives the same string.
+//
+
+// StringToInt64 parses s as a base-10 int64.
+// Leading "+", leading zeros, spaces and "-0" are rejected.
+func StringToInt64(s []byte) (int64, bool) {
+	if len(s) == 0 || len(s) > 20 {
+		return 0, false
+	}
+	if len(s) == 1 && s[0] == '0' {
+		return 0, true
+	}
+	p := 0
+	if s[0] == '-' {
+		p++
+	}
+	if p == len(s) || s[p] < '1' || s[p] > '9' {
+		return 0, false
+	}
+	for _, c := range s[p+1:] {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseInt(string(s), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// LongDoublePrec is the precision of the mantissa of an x87 long double,
+// in which Redis does the arithmetic of INCRBYFLOAT and HINCRBYFLOAT.
+const LongDoublePrec = 64
+
+// longDoubleMaxExp is the largest exponent of a long double, as big.Float.MantExp returns it.
+const longDoubleMaxExp = 16384
+
+// StringToLongDouble parses s as a long double, i.e. a big.Float of LongDoublePrec bits.
+// Spaces, NaN and finite values out of the range of a long double are rejected,
+// while "inf" parses, as in Redis, for AddLongDouble to reject.
+func StringToLongDouble(s []byte) (*big.Float, bool) {
+	f, _, err := big.ParseFloat(string(s), 10, LongDoublePrec, big.ToNearestEven)
+	if err != nil || (!f.IsInf() && f.MantExp(nil) > longDoubleMaxExp) {
+		return nil, false
+	}
+	return f, true
+}
+
+// Float64ToLongDouble converts f to a long double through the fewest digits that parse back to f,
+// so that 0.2 becomes the long double nearest to 0.2 rather than the float64 0.2.
+// NaN is rejected.
+func Float64ToLongDouble(f float64) (*big.Float, bool) {
+	return StringToLongDouble([]byte(strconv.FormatFloat(f, 'g', -1, 64)))
+}
+
+// AddLongDouble returns the sum of a and b as a long double,
+// and false if the sum is NaN or Infinity, or out of the range of a long double.
+func AddLongDouble(a, b *big.Float) (*big.Float, bool) {
+	if a.IsInf() || b.IsInf() {
+		return nil, false
+	}
+	sum := new(big.Float).SetPrec(LongDoublePrec).Add(a, b)
+	if sum.MantExp(nil) > longDoubleMaxExp {
+		return nil, false
+	}
+	return sum, true
+}
+
+// LongDoubleToString formats f in the human friendly form of INCRBYFLOAT,
+// as Redis's ld2string with LD_STR_HUMAN does:
+// 17 digits after the decimal point, less the trailing zeros, and "0" for "-0".
+func LongDoubleToString(f *big.Float) string {
+	s := strings.TrimRight(f.Text('f', 17), "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}